@@ -19,21 +19,159 @@ const (
 	pageOffsetSegTop uint16 = 84 // 页所属索引的非叶子节点段的头信息的地址（只有 B+ 树索引的根页面中会有值，Change Buffer 的根页面中不会有值），10 字节
 )
 
+// BTreePage 从 Page 的内存数据中解析 INDEX 页的页头
 type BTreePage struct {
-	file *File
+	filePage *Page
 }
 
-func NewBTreePage(file *File) BTreePage {
+func NewBTreePage(filePage *Page) BTreePage {
 	return BTreePage{
-		file: file,
+		filePage: filePage,
 	}
 }
 
+// ReadBTreePage 读取 INDEX 页，页类型不是 INDEX 时返回 ErrNotIndexPage
+func (file *File)ReadBTreePage(pageNo uint32) (BTreePage, error) {
+	errPrefix := "File::ReadBTreePage()"
+
+	page, err := file.readBTreePage(pageNo, pageTypeIndex)
+	if err != nil {
+		return BTreePage{}, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return page, nil
+}
+
+// ReadSdiPage 读取 SDI 索引页，SDI 页与 INDEX 页的格式相同，页类型不是 SDI 时返回 ErrNotIndexPage
+func (file *File)ReadSdiPage(pageNo uint32) (BTreePage, error) {
+	errPrefix := "File::ReadSdiPage()"
+
+	page, err := file.readBTreePage(pageNo, pageTypeSdi)
+	if err != nil {
+		return BTreePage{}, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return page, nil
+}
+
+// readBTreePage 读取 B+ 树页，页类型必须是 expectedPageTypes 之一
+func (file *File)readBTreePage(pageNo uint32, expectedPageTypes ...uint16) (BTreePage, error) {
+	filePage, err := file.ReadPage(pageNo)
+	if err != nil {
+		return BTreePage{}, err
+	}
+
+	pageType, err := filePage.GetPageType()
+	if err != nil {
+		return BTreePage{}, err
+	}
+
+	for _, expectedPageType := range expectedPageTypes {
+		if pageType == expectedPageType {
+			return NewBTreePage(filePage), nil
+		}
+	}
+
+	err = fmt.Errorf("%w: page type %d (%s), expected %s", ErrNotIndexPage, pageType, pageTypeMap[pageType], pageTypeMap[expectedPageTypes[0]])
+	return BTreePage{}, newPageError(pageNo, uint32(fileOffsetPageType), "FIL_PAGE_TYPE", err)
+}
+
+// btreeDecoder 遍历 B+ 树时按索引的记录格式解析页中的记录
+type btreeDecoder struct {
+	pageType uint16 // 索引页的页类型：INDEX 或 SDI
+	// childPageNo 返回非叶子节点中最左边的目录项指向的子节点，页中没有记录时返回 false
+	childPageNo func(page BTreePage) (uint32, bool, error)
+	// visitLeaf 处理一个叶子节点中的记录
+	visitLeaf func(page BTreePage) error
+}
+
+// walkBTreeLeaves 从根页沿最左边的目录项下降到叶子节点，再沿 FIL_PAGE_NEXT 遍历所有叶子节点。
+// 子节点的层级必须是父节点的层级减 1，叶子节点的层级必须为 0，叶子节点链表中有环时返回 ErrListCycle，
+// 保证损坏的文件不会使遍历陷入死循环
+func (file *File)walkBTreeLeaves(rootPageNo uint32, decoder btreeDecoder) error {
+	pageNo := rootPageNo
+	page, err := file.readBTreePage(pageNo, decoder.pageType)
+	if err != nil {
+		return err
+	}
+
+	level, err := page.GetPageLevel()
+	if err != nil {
+		return err
+	}
+
+	for level > 0 {
+		childPageNo, ok, err := decoder.childPageNo(page)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			err := fmt.Errorf("non-leaf page at level %d has no records", level)
+			return newPageError(pageNo, 0, "", err)
+		}
+
+		pageNo = childPageNo
+		page, err = file.readBTreePage(pageNo, decoder.pageType)
+		if err != nil {
+			return err
+		}
+
+		childLevel, err := page.GetPageLevel()
+		if err != nil {
+			return err
+		}
+		if childLevel != level - 1 {
+			err := fmt.Errorf("%w: child page level %d, expected %d", ErrPageLevelMismatch, childLevel, level - 1)
+			return newPageError(pageNo, uint32(pageOffsetPageLevel), "PAGE_LEVEL", err)
+		}
+		level = childLevel
+	}
+
+	visited := map[uint32]bool{}
+	for {
+		if visited[pageNo] {
+			return fmt.Errorf("%w: leaf page %d is visited twice", ErrListCycle, pageNo)
+		}
+		visited[pageNo] = true
+
+		if err := decoder.visitLeaf(page); err != nil {
+			return err
+		}
+
+		nextPageNo, err := page.filePage.GetNextPageNo()
+		if err != nil {
+			return err
+		}
+		if nextPageNo == fileNull {
+			return nil
+		}
+
+		pageNo = nextPageNo
+		page, err = file.readBTreePage(pageNo, decoder.pageType)
+		if err != nil {
+			return err
+		}
+
+		level, err := page.GetPageLevel()
+		if err != nil {
+			return err
+		}
+		if level != 0 {
+			err := fmt.Errorf("%w: leaf page level %d, expected 0", ErrPageLevelMismatch, level)
+			return newPageError(pageNo, uint32(pageOffsetPageLevel), "PAGE_LEVEL", err)
+		}
+	}
+}
+
+func (page *BTreePage)GetPage() *Page {
+	return page.filePage
+}
+
 func (page *BTreePage)GetPageLevel() (uint16, error) {
 	errPrefix := "BTreePage::GetPageLevel()"
-	level, err := page.file.getUint16Header(pageOffsetPageLevel)
+	level, err := page.filePage.getUint16(uint32(pageOffsetPageLevel))
 	if err != nil {
-		return 0, fmt.Errorf("%s, [%s]", errPrefix, err)
+		return 0, fmt.Errorf("%s, [%w]", errPrefix, withField(err, "PAGE_LEVEL"))
 	}
 
 	return level, nil
@@ -41,9 +179,9 @@ func (page *BTreePage)GetPageLevel() (uint16, error) {
 
 func (page *BTreePage)GetSlotsCount() (uint16, error) {
 	errPrefix := "BTreePage::GetSlotsCount()"
-	slotsCount, err := page.file.getUint16Header(pageOffsetNSlots)
+	slotsCount, err := page.filePage.getUint16(uint32(pageOffsetNSlots))
 	if err != nil {
-		return 0, fmt.Errorf("%s: [%s]", errPrefix, err)
+		return 0, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "PAGE_N_DIR_SLOTS"))
 	}
 
 	return slotsCount, nil
@@ -51,9 +189,9 @@ func (page *BTreePage)GetSlotsCount() (uint16, error) {
 
 func (page *BTreePage)GetHeapTop() (uint16, error) {
 	errPrefix := "BTreePage::GetHeapTop()"
-	heapTop, err := page.file.getUint16Header(pageOffsetHeapTop)
+	heapTop, err := page.filePage.getUint16(uint32(pageOffsetHeapTop))
 	if err != nil {
-		return 0, fmt.Errorf("%s: [%s]", errPrefix, err)
+		return 0, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "PAGE_HEAP_TOP"))
 	}
 
 	return heapTop, nil
@@ -61,9 +199,9 @@ func (page *BTreePage)GetHeapTop() (uint16, error) {
 
 func (page *BTreePage)GetHeapCount() (uint16, error) {
 	errPrefix := "BTreePage::GetHeapCount()"
-	heapCount, err := page.file.getUint16Header(pageOffsetNHeap)
+	heapCount, err := page.filePage.getUint16(uint32(pageOffsetNHeap))
 	if err != nil {
-		return 0, fmt.Errorf("%s: [%s]", errPrefix, err)
+		return 0, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "PAGE_N_HEAP"))
 	}
 
 	return heapCount, nil
@@ -71,9 +209,9 @@ func (page *BTreePage)GetHeapCount() (uint16, error) {
 
 func (page *BTreePage)GetRecordCount() (uint16, error)  {
 	errPrefix := "BTreePage::GetRecordCount()"
-	recordCount, err := page.file.getUint16Header(pageOffsetNRecs)
+	recordCount, err := page.filePage.getUint16(uint32(pageOffsetNRecs))
 	if err != nil {
-		return 0, fmt.Errorf("%s: [%s]", errPrefix, err)
+		return 0, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "PAGE_N_RECS"))
 	}
 
 	return recordCount, nil
@@ -81,9 +219,9 @@ func (page *BTreePage)GetRecordCount() (uint16, error)  {
 
 func (page *BTreePage)GetLastInsertDirection() (uint16, error) {
 	errPrefix := "BTreePage::GetLastInsertDirection()"
-	direction, err := page.file.getUint16Header(pageOffsetDirection)
+	direction, err := page.filePage.getUint16(uint32(pageOffsetDirection))
 	if err != nil {
-		return 0, fmt.Errorf("%s: [%s]", errPrefix, err)
+		return 0, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "PAGE_DIRECTION"))
 	}
 
 	return direction, nil
@@ -91,9 +229,9 @@ func (page *BTreePage)GetLastInsertDirection() (uint16, error) {
 
 func (page *BTreePage)GetDirectionInsertCount() (uint16, error) {
 	errPrefix := "BTreePage::GetDirectionInsertCount()"
-	insertCount, err := page.file.getUint16Header(pageOffsetNDirection)
+	insertCount, err := page.filePage.getUint16(uint32(pageOffsetNDirection))
 	if err != nil {
-		return 0, fmt.Errorf("%s: [%s]", errPrefix, err)
+		return 0, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "PAGE_N_DIRECTION"))
 	}
 
 	return insertCount, err
@@ -101,9 +239,9 @@ func (page *BTreePage)GetDirectionInsertCount() (uint16, error) {
 
 func (page *BTreePage)GetLastInsertOffset() (uint16, error) {
 	errPrefix := "BTreePage::GetLastInsertOffset()"
-	insertOffset, err := page.file.getUint16Header(pageOffsetLastInsert)
+	insertOffset, err := page.filePage.getUint16(uint32(pageOffsetLastInsert))
 	if err != nil {
-		return 0, fmt.Errorf("%s: [%s]", errPrefix, err)
+		return 0, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "PAGE_LAST_INSERT"))
 	}
 
 	return insertOffset, nil
@@ -111,9 +249,9 @@ func (page *BTreePage)GetLastInsertOffset() (uint16, error) {
 
 func (page *BTreePage)GetGarbageSize() (uint16, error) {
 	errPrefix := "BTreePage::GetGarbageSize()"
-	size, err := page.file.getUint16Header(pageOffsetGarbage)
+	size, err := page.filePage.getUint16(uint32(pageOffsetGarbage))
 	if err != nil {
-		return 0, fmt.Errorf("%s: [%s]", errPrefix, err)
+		return 0, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "PAGE_GARBAGE"))
 	}
 
 	return size, nil
@@ -121,9 +259,9 @@ func (page *BTreePage)GetGarbageSize() (uint16, error) {
 
 func (page *BTreePage)GetFreeOffset() (uint16, error) {
 	errPrefix := "BTreePage::GetFreeOffset()"
-	freeOffset, err := page.file.getUint16Header(pageOffsetFree)
+	freeOffset, err := page.filePage.getUint16(uint32(pageOffsetFree))
 	if err != nil {
-		return 0, fmt.Errorf("%s: [%s]", errPrefix, err)
+		return 0, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "PAGE_FREE"))
 	}
 
 	return freeOffset, nil
@@ -131,9 +269,9 @@ func (page *BTreePage)GetFreeOffset() (uint16, error) {
 
 func (page *BTreePage)GetMaxTrxId() (uint64, error) {
 	errPrefix := "BTreePage::GetMaxTrxId()"
-	trxId, err := page.file.getUint64Header(pageOffsetMaxTrxId)
+	trxId, err := page.filePage.getUint64(uint32(pageOffsetMaxTrxId))
 	if err != nil {
-		return 0, fmt.Errorf("%s: [%s]", errPrefix, err)
+		return 0, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "PAGE_MAX_TRX_ID"))
 	}
 
 	return trxId, nil
@@ -143,7 +281,7 @@ func (page *BTreePage)GetBtrSegTop() (uint32, uint32, uint16, error) {
 	errPrefix := "BTreePage::GetBtrSegTop()"
 	spaceId, pageNo, pageOffset, err := page.getBtrInodeHeader(pageOffsetSegTop)
 	if err != nil {
-		return 0, 0, 0, fmt.Errorf("%s: [%s]", errPrefix, err)
+		return 0, 0, 0, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "PAGE_BTR_SEG_TOP"))
 	}
 
 	return spaceId, pageNo, pageOffset, nil
@@ -153,7 +291,7 @@ func (page *BTreePage)GetBtrSegLeaf() (uint32, uint32, uint16, error) {
 	errPrefix := "BTreePage::GetBtrSegLeaf()"
 	spaceId, pageNo, pageOffset, err := page.getBtrInodeHeader(pageOffsetSegLeaf)
 	if err != nil {
-		return 0, 0, 0, fmt.Errorf("%s: [%s]", errPrefix, err)
+		return 0, 0, 0, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "PAGE_BTR_SEG_LEAF"))
 	}
 
 	return spaceId, pageNo, pageOffset, nil
@@ -161,21 +299,21 @@ func (page *BTreePage)GetBtrSegLeaf() (uint32, uint32, uint16, error) {
 
 func (page *BTreePage)getBtrInodeHeader(inodeStartOffset uint16) (uint32, uint32, uint16, error) {
 	errPrefix := "BTree::getBtrInodeHeader()"
-	segTopSpaceId, err := page.file.getUint32Header(inodeStartOffset)
+	segTopSpaceId, err := page.filePage.getUint32(uint32(inodeStartOffset))
 	if err != nil {
-		return 0, 0, 0, fmt.Errorf("%s: [read space id: %s]", errPrefix, err)
+		return 0, 0, 0, fmt.Errorf("%s: [read space id: %w]", errPrefix, err)
 	}
 
 	pageNoOffset := inodeStartOffset + uint16(spaceIdSize)
-	segTopPageNo, err := page.file.getUint32Header(pageNoOffset)
+	segTopPageNo, err := page.filePage.getUint32(uint32(pageNoOffset))
 	if err != nil {
-		return 0, 0, 0, fmt.Errorf("%s: [read page no: %s]", errPrefix, err)
+		return 0, 0, 0, fmt.Errorf("%s: [read page no: %w]", errPrefix, err)
 	}
 
 	inodeOffset := pageNoOffset + uint16(pageNoSize)
-	segTopInodeOffset, err := page.file.getUint16Header(inodeOffset)
+	segTopInodeOffset, err := page.filePage.getUint16(uint32(inodeOffset))
 	if err != nil {
-		return 0, 0, 0, fmt.Errorf("%s: [read inode offset: %s]", errPrefix, err)
+		return 0, 0, 0, fmt.Errorf("%s: [read inode offset: %w]", errPrefix, err)
 	}
 
 	return segTopSpaceId, segTopPageNo, segTopInodeOffset, nil
@@ -184,10 +322,9 @@ func (page *BTreePage)getBtrInodeHeader(inodeStartOffset uint16) (uint32, uint32
 func (page *BTreePage)GetIndexId() (uint64, error) {
 	errPrefix := "BTreePage::GetIndexId()"
 
-	file := page.file
-	indexId, err := file.getUint64Header(pageOffsetIndexId)
+	indexId, err := page.filePage.getUint64(uint32(pageOffsetIndexId))
 	if err != nil {
-		return 0, fmt.Errorf("%s: [%s]", errPrefix, err)
+		return 0, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "PAGE_INDEX_ID"))
 	}
 
 	return indexId, nil
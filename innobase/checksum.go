@@ -0,0 +1,241 @@
+package innobase
+
+import (
+	"fmt"
+	"hash/crc32"
+)
+
+const (
+	ChecksumAlgorithmUnknown uint8 = 0
+	ChecksumAlgorithmCrc32 uint8 = 1 // innodb_checksum_algorithm=crc32，5.7 开始的默认值
+	ChecksumAlgorithmInnodb uint8 = 2 // innodb_checksum_algorithm=innodb，5.6 及之前的默认值
+	ChecksumAlgorithmNone uint8 = 3 // innodb_checksum_algorithm=none，检验和固定为 0xDEADBEEF
+)
+
+var checksumAlgorithmMap = map[uint8]string {
+	ChecksumAlgorithmUnknown: "unknown",
+	ChecksumAlgorithmCrc32: "crc32",
+	ChecksumAlgorithmInnodb: "innodb",
+	ChecksumAlgorithmNone: "none",
+}
+
+const (
+	checksumNoneMagic uint32 = 0xDEADBEEF // BUF_NO_CHECKSUM_MAGIC
+
+	hashRandomMask uint64 = 1463735687 // UT_HASH_RANDOM_MASK
+	hashRandomMask2 uint64 = 1653893711 // UT_HASH_RANDOM_MASK2
+
+	adler32Base uint32 = 65521
+)
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// ChecksumResult 一个页的检验和校验结果，保存页中存储的值和按各算法计算出的值
+type ChecksumResult struct {
+	PageNo uint32
+	Compressed bool // 压缩页只有页头中的检验和，没有页尾
+	Empty bool // 全部为 0 的页（未初始化），不需要校验
+	Valid bool
+	Algorithm uint8 // 通过校验的算法，校验失败时为 ChecksumAlgorithmUnknown
+
+	StoredChecksum uint32 // 页头中的检验和（FIL_PAGE_SPACE_OR_CHKSUM）
+	StoredTrailerChecksum uint32 // 页尾中的旧格式检验和，压缩页没有
+
+	Crc32Checksum uint32
+	InnodbChecksum uint32
+	InnodbOldChecksum uint32 // 页尾使用的旧格式 innodb 检验和，压缩页没有
+}
+
+func (result *ChecksumResult)GetAlgorithmName() string {
+	return checksumAlgorithmMap[result.Algorithm]
+}
+
+// Err 校验失败时返回包装了 ErrChecksumMismatch 的 PageError，校验通过时返回 nil
+func (result *ChecksumResult)Err() error {
+	if result.Valid {
+		return nil
+	}
+
+	err := fmt.Errorf("%w: stored 0x%08x, crc32 0x%08x, innodb 0x%08x",
+		ErrChecksumMismatch, result.StoredChecksum, result.Crc32Checksum, result.InnodbChecksum)
+
+	return newPageError(result.PageNo, uint32(fileOffsetPageChecksum), "FIL_PAGE_SPACE_OR_CHKSUM", err)
+}
+
+// VerifyChecksum 与 innochecksum 相同，依次尝试 crc32、innodb、none 三种算法，
+// 任意一种算法校验通过即认为页是完整的
+func (page *Page)VerifyChecksum() (ChecksumResult, error) {
+	errPrefix := "Page::VerifyChecksum()"
+
+	result := ChecksumResult{
+		PageNo: page.pageNo,
+		Compressed: page.compressed,
+	}
+
+	if page.GetSize() <= uint32(fileHeaderSize) + uint32(fileTrailerSize) {
+		return result, fmt.Errorf("%s: [%w]", errPrefix, newPageError(page.pageNo, page.GetSize(), "", ErrTruncatedPage))
+	}
+
+	if page.IsEmpty() {
+		result.Empty = true
+		result.Valid = true
+		return result, nil
+	}
+
+	if page.compressed {
+		page.verifyCompressedChecksum(&result)
+	} else {
+		page.verifyUncompressedChecksum(&result)
+	}
+
+	return result, nil
+}
+
+// IsEmpty 页中所有字节都为 0
+func (page *Page)IsEmpty() bool {
+	for _, b := range page.data {
+		if b != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (page *Page)verifyUncompressedChecksum(result *ChecksumResult) {
+	data := page.data
+	size := uint32(len(data))
+	trailerOffset := size - uint32(fileTrailerSize)
+
+	result.StoredChecksum, _ = page.getUint32(uint32(fileOffsetPageChecksum))
+	result.StoredTrailerChecksum, _ = page.getUint32(trailerOffset)
+
+	// 跳过页头中的检验和、FLUSH_LSN、SPACE_ID 以及页尾
+	header := data[fileOffsetPageNo:fileOffsetPageFlushedLsn]
+	body := data[fileHeaderSize:trailerOffset]
+
+	result.Crc32Checksum = crc32.Checksum(header, crc32cTable) ^ crc32.Checksum(body, crc32cTable)
+	result.InnodbChecksum = uint32(innodbFoldBinary(header) + innodbFoldBinary(body))
+	result.InnodbOldChecksum = uint32(innodbFoldBinary(data[:fileOffsetPageFlushedLsn]))
+
+	// 很老的版本在页尾的检验和位置写入的是 LSN 的高 32 位
+	lsnHigh, _ := page.getUint32(uint32(fileOffsetPageLsn))
+
+	stored := result.StoredChecksum
+	storedTrailer := result.StoredTrailerChecksum
+	switch {
+	case stored == storedTrailer && stored == result.Crc32Checksum:
+		result.Algorithm = ChecksumAlgorithmCrc32
+	case (storedTrailer == lsnHigh || storedTrailer == result.InnodbOldChecksum) &&
+		(stored == 0 || stored == result.InnodbChecksum):
+		result.Algorithm = ChecksumAlgorithmInnodb
+	case stored == checksumNoneMagic && storedTrailer == checksumNoneMagic:
+		result.Algorithm = ChecksumAlgorithmNone
+	default:
+		return
+	}
+
+	result.Valid = true
+}
+
+// verifyCompressedChecksum 压缩页的检验和只存储在页头中，
+// 计算范围与 page_zip_calc_checksum() 相同：FIL_PAGE_OFFSET 到 FIL_PAGE_LSN、FIL_PAGE_TYPE，
+// 以及从 FIL_PAGE_ARCH_LOG_NO_OR_SPACE_ID 开始到页尾的全部数据（包括表空间 ID）
+func (page *Page)verifyCompressedChecksum(result *ChecksumResult) {
+	data := page.data
+
+	result.StoredChecksum, _ = page.getUint32(uint32(fileOffsetPageChecksum))
+
+	ranges := [][]byte{
+		data[fileOffsetPageNo:fileOffsetPageLsn],
+		data[fileOffsetPageType:fileOffsetPageType + size2],
+		data[fileOffsetSpaceId:],
+	}
+
+	adler := uint32(0)
+	for _, r := range ranges {
+		result.Crc32Checksum ^= crc32.Checksum(r, crc32cTable)
+		adler = zlibAdler32(adler, r)
+	}
+	result.InnodbChecksum = adler
+
+	stored := result.StoredChecksum
+	switch stored {
+	case result.Crc32Checksum:
+		result.Algorithm = ChecksumAlgorithmCrc32
+	case result.InnodbChecksum:
+		result.Algorithm = ChecksumAlgorithmInnodb
+	case checksumNoneMagic:
+		result.Algorithm = ChecksumAlgorithmNone
+	default:
+		return
+	}
+
+	result.Valid = true
+}
+
+// innodbFoldBinary 对应 ut_fold_binary()，按 64 位无符号整数计算
+func innodbFoldBinary(data []byte) uint64 {
+	fold := uint64(0)
+	for _, b := range data {
+		fold = innodbFoldPair(fold, uint64(b))
+	}
+
+	return fold
+}
+
+// innodbFoldPair 对应 ut_fold_ulint_pair()
+func innodbFoldPair(n1 uint64, n2 uint64) uint64 {
+	return ((((n1 ^ n2 ^ hashRandomMask2) << 8) + n1) ^ hashRandomMask) + n2
+}
+
+// zlibAdler32 与 zlib 的 adler32(adler, buf, len) 相同，
+// InnoDB 压缩页以 0 而不是 1 作为初始值，所以不能使用 hash/adler32
+func zlibAdler32(adler uint32, data []byte) uint32 {
+	sum1 := adler & 0xffff
+	sum2 := adler >> 16
+	for _, b := range data {
+		sum1 = (sum1 + uint32(b)) % adler32Base
+		sum2 = (sum2 + sum1) % adler32Base
+	}
+
+	return sum2 << 16 | sum1
+}
+
+// VerifyPageChecksum 读取页并校验检验和，pageNo 为 InnoDB 页号（从 0 开始）
+func (file *File)VerifyPageChecksum(pageNo uint32) (ChecksumResult, error) {
+	errPrefix := "File::VerifyPageChecksum()"
+
+	page, err := file.ReadRawPage(pageNo)
+	if err != nil {
+		return ChecksumResult{PageNo: pageNo}, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	result, err := page.VerifyChecksum()
+	if err != nil {
+		return result, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return result, nil
+}
+
+// ReadVerifiedPage 读取页并校验检验和，检验和错误时返回 ErrChecksumMismatch
+func (file *File)ReadVerifiedPage(pageNo uint32) (*Page, error) {
+	errPrefix := "File::ReadVerifiedPage()"
+
+	page, err := file.ReadPage(pageNo)
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	result, err := page.VerifyChecksum()
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	if err := result.Err(); err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return page, nil
+}
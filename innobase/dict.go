@@ -0,0 +1,725 @@
+package innobase
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	dictHdrPageNo uint32 = 7 // 系统表空间中数据字典头所在的页
+	dictHdrOffset uint32 = 38 // 数据字典头在页中的起始位置
+
+	dictHdrOffsetRowId uint32 = 0 // 下一个 DB_ROW_ID，8 字节
+	dictHdrOffsetTableId uint32 = 8 // 下一个表 ID，8 字节
+	dictHdrOffsetIndexId uint32 = 16 // 下一个索引 ID，8 字节
+	dictHdrOffsetMaxSpaceId uint32 = 24 // 已分配的最大表空间 ID，4 字节
+	dictHdrOffsetTables uint32 = 32 // SYS_TABLES 聚簇索引根页的页号，4 字节
+	dictHdrOffsetTableIds uint32 = 36 // SYS_TABLES 上 ID 二级索引根页的页号，4 字节
+	dictHdrOffsetColumns uint32 = 40 // SYS_COLUMNS 聚簇索引根页的页号，4 字节
+	dictHdrOffsetIndexes uint32 = 44 // SYS_INDEXES 聚簇索引根页的页号，4 字节
+	dictHdrOffsetFields uint32 = 48 // SYS_FIELDS 聚簇索引根页的页号，4 字节
+
+	// 系统表的表 ID 和索引 ID 是固定的
+	dictTablesId uint64 = 1
+	dictColumnsId uint64 = 2
+	dictIndexesId uint64 = 3
+	dictFieldsId uint64 = 4
+	dictTableIdsId uint64 = 5
+
+	// SYS_TABLES：NAME、DB_TRX_ID、DB_ROLL_PTR、ID、N_COLS、TYPE、MIX_ID、MIX_LEN、CLUSTER_NAME、SPACE
+	sysTablesFieldName uint16 = 0
+	sysTablesFieldId uint16 = 3
+	sysTablesFieldNCols uint16 = 4
+	sysTablesFieldType uint16 = 5
+	sysTablesFieldMixLen uint16 = 7
+	sysTablesFieldSpace uint16 = 9
+
+	// SYS_COLUMNS：TABLE_ID、POS、DB_TRX_ID、DB_ROLL_PTR、NAME、MTYPE、PRTYPE、LEN、PREC
+	sysColumnsFieldTableId uint16 = 0
+	sysColumnsFieldPos uint16 = 1
+	sysColumnsFieldName uint16 = 4
+	sysColumnsFieldMType uint16 = 5
+	sysColumnsFieldPrType uint16 = 6
+	sysColumnsFieldLen uint16 = 7
+	sysColumnsFieldPrec uint16 = 8
+
+	// SYS_INDEXES：TABLE_ID、ID、DB_TRX_ID、DB_ROLL_PTR、NAME、N_FIELDS、TYPE、SPACE、PAGE_NO、MERGE_THRESHOLD（5.7 新增）
+	sysIndexesFieldTableId uint16 = 0
+	sysIndexesFieldId uint16 = 1
+	sysIndexesFieldName uint16 = 4
+	sysIndexesFieldNFields uint16 = 5
+	sysIndexesFieldType uint16 = 6
+	sysIndexesFieldSpace uint16 = 7
+	sysIndexesFieldPageNo uint16 = 8
+	sysIndexesFieldMergeThreshold uint16 = 9
+
+	// SYS_FIELDS：INDEX_ID、POS、DB_TRX_ID、DB_ROLL_PTR、COL_NAME
+	sysFieldsFieldIndexId uint16 = 0
+	sysFieldsFieldPos uint16 = 1
+	sysFieldsFieldColName uint16 = 4
+
+	dictNColsCompact uint32 = 0x80000000 // SYS_TABLES.N_COLS 的最高位，为 1 表示不是 REDUNDANT 行格式
+	dictTfMaskZipSsize uint32 = 0x1E // 表标志中的压缩页大小，不为 0 表示 COMPRESSED 行格式
+	dictTfMaskAtomicBlobs uint32 = 0x20 // 表标志中的 ATOMIC_BLOBS，DYNAMIC 和 COMPRESSED 行格式都有
+
+	dictClustered uint32 = 1 // SYS_INDEXES.TYPE 中的聚簇索引标志
+	dictUnique uint32 = 2
+)
+
+// dictMTypeMap 列的主类型（SYS_COLUMNS.MTYPE）
+var dictMTypeMap = map[uint32]string{
+	1: "DATA_VARCHAR",
+	2: "DATA_CHAR",
+	3: "DATA_FIXBINARY",
+	4: "DATA_BINARY",
+	5: "DATA_BLOB",
+	6: "DATA_INT",
+	7: "DATA_SYS_CHILD",
+	8: "DATA_SYS",
+	9: "DATA_FLOAT",
+	10: "DATA_DOUBLE",
+	11: "DATA_DECIMAL",
+	12: "DATA_VARMYSQL",
+	13: "DATA_MYSQL",
+	14: "DATA_GEOMETRY",
+	15: "DATA_POINT",
+	16: "DATA_VAR_POINT",
+}
+
+// DictHeader 数据字典头，记录 ID 分配情况和四个系统表的根页
+type DictHeader struct {
+	RowId uint64
+	TableId uint64
+	IndexId uint64
+	MaxSpaceId uint32
+	TablesRoot uint32
+	TableIdsRoot uint32
+	ColumnsRoot uint32
+	IndexesRoot uint32
+	FieldsRoot uint32
+}
+
+// DictColumn SYS_COLUMNS 中的一列
+type DictColumn struct {
+	Pos uint32 // 虚拟列的 POS 中还编码了它在虚拟列中的序号
+	Name string
+	TypeName string // 5.7 为 MTYPE 的名字，8.0 为 mysql.columns.type
+	MType uint32
+	PrType uint32 // 低 8 位为 MySQL 类型，还包含 NOT NULL、UNSIGNED 等标志
+	Len uint32
+	Prec uint32
+}
+
+// DictField SYS_FIELDS 中索引的一个字段
+type DictField struct {
+	Pos uint32
+	PrefixLen uint32 // 前缀索引的长度，0 表示整列
+	ColumnName string
+}
+
+// DictIndex SYS_INDEXES 中的一个索引
+type DictIndex struct {
+	Table *DictTable
+	Id uint64
+	Name string
+	NFields uint32
+	Type uint32 // 索引类型标志：1 聚簇索引、2 唯一索引、32 全文索引、64 空间索引等
+	SpaceId uint32
+	RootPageNo uint32
+	MergeThreshold uint32
+	Fields []DictField
+}
+
+// DictTable SYS_TABLES 中的一个表，Name 的格式为 db/table
+type DictTable struct {
+	Id uint64
+	Name string
+	SpaceId uint32
+	NCols uint32 // 非虚拟列的数量，不包括 DB_ROW_ID、DB_TRX_ID、DB_ROLL_PTR
+	NVirtualCols uint32
+	Compact bool // N_COLS 的最高位，为 false 表示 REDUNDANT 行格式
+	Flags uint32 // SYS_TABLES.TYPE
+	RowFormat string
+	Flags2 uint32 // SYS_TABLES.MIX_LEN
+	Columns []DictColumn
+	Indexes []*DictIndex
+}
+
+// DictTablespace 表空间 ID 与表空间名的对应关系，只有 8.0 的数据字典中有
+type DictTablespace struct {
+	SpaceId uint32
+	Name string
+	Engine string
+}
+
+// DataDictionary 数据字典：MySQL 5.7 及之前版本存储在系统表空间中，8.0 存储在 mysql.ibd 中。
+// 两者都按 InnoDB 的表 ID、索引 ID 和表空间 ID 组织
+type DataDictionary struct {
+	Header *DictHeader // 5.7 的数据字典头，8.0 为空
+	Tables []*DictTable // 按表名排序
+	Tablespaces []*DictTablespace // 按表空间 ID 排序
+
+	tables map[uint64]*DictTable
+	indexes map[uint64]*DictIndex
+	spaces map[uint32]*DictTablespace
+}
+
+func newDataDictionary() *DataDictionary {
+	return &DataDictionary{
+		tables: map[uint64]*DictTable{},
+		indexes: map[uint64]*DictIndex{},
+		spaces: map[uint32]*DictTablespace{},
+	}
+}
+
+// getDictRowFormat 根据 N_COLS 和表标志判断行格式
+func getDictRowFormat(compact bool, flags uint32) string {
+	switch {
+	case !compact:
+		return "Redundant"
+	case flags & dictTfMaskZipSsize != 0:
+		return "Compressed"
+	case flags & dictTfMaskAtomicBlobs != 0:
+		return "Dynamic"
+	default:
+		return "Compact"
+	}
+}
+
+func (index *DictIndex)IsClustered() bool {
+	return index.Type & dictClustered != 0
+}
+
+func (index *DictIndex)IsUnique() bool {
+	return index.Type & dictUnique != 0
+}
+
+// GetFullName 返回 db/table.index_name
+func (index *DictIndex)GetFullName() string {
+	if index.Table == nil {
+		return index.Name
+	}
+
+	return index.Table.Name + "." + index.Name
+}
+
+func (dictionary *DataDictionary)GetTable(tableId uint64) *DictTable {
+	return dictionary.tables[tableId]
+}
+
+func (dictionary *DataDictionary)GetIndex(indexId uint64) *DictIndex {
+	return dictionary.indexes[indexId]
+}
+
+func (dictionary *DataDictionary)GetTablespace(spaceId uint32) *DictTablespace {
+	return dictionary.spaces[spaceId]
+}
+
+// GetSpaceName 返回表空间名，没有表空间信息时（5.7）使用表空间中的表名，都没有时返回空字符串
+func (dictionary *DataDictionary)GetSpaceName(spaceId uint32) string {
+	if dictionary == nil {
+		return ""
+	}
+
+	if tablespace := dictionary.GetTablespace(spaceId); tablespace != nil {
+		return tablespace.Name
+	}
+
+	names := []string{}
+	for _, table := range dictionary.Tables {
+		if table.SpaceId == spaceId && len(table.Indexes) > 0 {
+			names = append(names, table.Name)
+		}
+	}
+
+	return strings.Join(names, ",")
+}
+
+// GetIndexName 返回索引的 db/table.index_name，索引不在数据字典中时返回空字符串
+func (dictionary *DataDictionary)GetIndexName(indexId uint64) string {
+	if dictionary == nil {
+		return ""
+	}
+
+	index := dictionary.GetIndex(indexId)
+	if index == nil {
+		return ""
+	}
+
+	return index.GetFullName()
+}
+
+// dictRecord 系统表中的一条 REDUNDANT 格式记录
+type dictRecord struct {
+	page *Page
+	header redundantRecordHeader
+}
+
+func (record *dictRecord)getField(i uint16) ([]byte, error) {
+	data, _, err := record.page.getRedundantField(record.header, i)
+
+	return data, err
+}
+
+func (record *dictRecord)getString(i uint16) (string, error) {
+	data, err := record.getField(i)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// getUint32 读取 4 字节的整数列，NULL 返回 0
+func (record *dictRecord)getUint32(i uint16) (uint32, error) {
+	data, err := record.getField(i)
+	if err != nil || data == nil {
+		return 0, err
+	}
+	if len(data) != int(size4) {
+		err := fmt.Errorf("field %d is %d bytes, expected %d", i, len(data), size4)
+		return 0, newPageError(record.page.pageNo, record.header.origin, "REC_FIELD", err)
+	}
+
+	return binary.BigEndian.Uint32(data), nil
+}
+
+// getUint64 读取 8 字节的整数列，NULL 返回 0
+func (record *dictRecord)getUint64(i uint16) (uint64, error) {
+	data, err := record.getField(i)
+	if err != nil || data == nil {
+		return 0, err
+	}
+	if len(data) != int(size8) {
+		err := fmt.Errorf("field %d is %d bytes, expected %d", i, len(data), size8)
+		return 0, newPageError(record.page.pageNo, record.header.origin, "REC_FIELD", err)
+	}
+
+	return binary.BigEndian.Uint64(data), nil
+}
+
+// ReadDictHeader 读取系统表空间第 7 页中的数据字典头
+func (file *File)ReadDictHeader() (DictHeader, error) {
+	errPrefix := "File::ReadDictHeader()"
+
+	page, err := file.ReadPage(dictHdrPageNo)
+	if err != nil {
+		return DictHeader{}, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	pageType, err := page.GetPageType()
+	if err != nil {
+		return DictHeader{}, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	if pageType != pageTypeSys {
+		err := fmt.Errorf("%w: page type %d (%s)", ErrNoDictionary, pageType, pageTypeMap[pageType])
+		return DictHeader{}, fmt.Errorf("%s: [%w]", errPrefix, newPageError(dictHdrPageNo, uint32(fileOffsetPageType), "FIL_PAGE_TYPE", err))
+	}
+
+	header := DictHeader{}
+	ids := []struct {
+		offset uint32
+		field string
+		value *uint64
+	}{
+		{dictHdrOffsetRowId, "DICT_HDR_ROW_ID", &header.RowId},
+		{dictHdrOffsetTableId, "DICT_HDR_TABLE_ID", &header.TableId},
+		{dictHdrOffsetIndexId, "DICT_HDR_INDEX_ID", &header.IndexId},
+	}
+	for _, f := range ids {
+		value, err := page.getUint64(dictHdrOffset + f.offset)
+		if err != nil {
+			return DictHeader{}, fmt.Errorf("%s: [%w]", errPrefix, withField(err, f.field))
+		}
+		*f.value = value
+	}
+
+	fields := []struct {
+		offset uint32
+		field string
+		value *uint32
+	}{
+		{dictHdrOffsetMaxSpaceId, "DICT_HDR_MAX_SPACE_ID", &header.MaxSpaceId},
+		{dictHdrOffsetTables, "DICT_HDR_TABLES", &header.TablesRoot},
+		{dictHdrOffsetTableIds, "DICT_HDR_TABLE_IDS", &header.TableIdsRoot},
+		{dictHdrOffsetColumns, "DICT_HDR_COLUMNS", &header.ColumnsRoot},
+		{dictHdrOffsetIndexes, "DICT_HDR_INDEXES", &header.IndexesRoot},
+		{dictHdrOffsetFields, "DICT_HDR_FIELDS", &header.FieldsRoot},
+	}
+	for _, f := range fields {
+		value, err := page.getUint32(dictHdrOffset + f.offset)
+		if err != nil {
+			return DictHeader{}, fmt.Errorf("%s: [%w]", errPrefix, withField(err, f.field))
+		}
+		*f.value = value
+	}
+
+	return header, nil
+}
+
+// walkDictIndex 遍历系统表聚簇索引中未标记删除的记录：从根页沿最左边的目录项下降到叶子节点，再沿 FIL_PAGE_NEXT 遍历
+func (file *File)walkDictIndex(rootPageNo uint32, visit func(record *dictRecord) error) error {
+	decoder := btreeDecoder{
+		pageType: pageTypeIndex,
+		childPageNo: func(page BTreePage) (uint32, bool, error) {
+			records, err := page.getRedundantRecords()
+			if err != nil || len(records) == 0 {
+				return 0, false, err
+			}
+
+			// 目录项记录的最后一个字段是子节点的页号
+			record := &dictRecord{page: page.filePage, header: records[0]}
+			childPageNo, err := record.getUint32(records[0].nFields - 1)
+			if err != nil {
+				return 0, false, err
+			}
+
+			return childPageNo, true, nil
+		},
+		visitLeaf: func(page BTreePage) error {
+			records, err := page.getRedundantRecords()
+			if err != nil {
+				return err
+			}
+
+			for _, header := range records {
+				if header.isDeleted() {
+					continue
+				}
+				if err := visit(&dictRecord{page: page.filePage, header: header}); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	}
+
+	return file.walkBTreeLeaves(rootPageNo, decoder)
+}
+
+func readSysTablesRecord(record *dictRecord) (*DictTable, error) {
+	table := &DictTable{}
+
+	name, err := record.getString(sysTablesFieldName)
+	if err != nil {
+		return nil, withField(err, "SYS_TABLES.NAME")
+	}
+	table.Name = name
+
+	id, err := record.getUint64(sysTablesFieldId)
+	if err != nil {
+		return nil, withField(err, "SYS_TABLES.ID")
+	}
+	table.Id = id
+
+	// N_COLS 的低 16 位为非虚拟列的数量，16~30 位为虚拟列的数量
+	nCols, err := record.getUint32(sysTablesFieldNCols)
+	if err != nil {
+		return nil, withField(err, "SYS_TABLES.N_COLS")
+	}
+	table.Compact = nCols & dictNColsCompact != 0
+	table.NCols = nCols & 0xFFFF
+	table.NVirtualCols = (nCols &^ dictNColsCompact) >> 16
+
+	fields := []struct {
+		i uint16
+		field string
+		value *uint32
+	}{
+		{sysTablesFieldType, "SYS_TABLES.TYPE", &table.Flags},
+		{sysTablesFieldMixLen, "SYS_TABLES.MIX_LEN", &table.Flags2},
+		{sysTablesFieldSpace, "SYS_TABLES.SPACE", &table.SpaceId},
+	}
+	for _, f := range fields {
+		value, err := record.getUint32(f.i)
+		if err != nil {
+			return nil, withField(err, f.field)
+		}
+		*f.value = value
+	}
+	table.RowFormat = getDictRowFormat(table.Compact, table.Flags)
+
+	return table, nil
+}
+
+func readSysColumnsRecord(record *dictRecord) (uint64, DictColumn, error) {
+	column := DictColumn{}
+
+	tableId, err := record.getUint64(sysColumnsFieldTableId)
+	if err != nil {
+		return 0, column, withField(err, "SYS_COLUMNS.TABLE_ID")
+	}
+
+	name, err := record.getString(sysColumnsFieldName)
+	if err != nil {
+		return 0, column, withField(err, "SYS_COLUMNS.NAME")
+	}
+	column.Name = name
+
+	fields := []struct {
+		i uint16
+		field string
+		value *uint32
+	}{
+		{sysColumnsFieldPos, "SYS_COLUMNS.POS", &column.Pos},
+		{sysColumnsFieldMType, "SYS_COLUMNS.MTYPE", &column.MType},
+		{sysColumnsFieldPrType, "SYS_COLUMNS.PRTYPE", &column.PrType},
+		{sysColumnsFieldLen, "SYS_COLUMNS.LEN", &column.Len},
+		{sysColumnsFieldPrec, "SYS_COLUMNS.PREC", &column.Prec},
+	}
+	for _, f := range fields {
+		value, err := record.getUint32(f.i)
+		if err != nil {
+			return 0, column, withField(err, f.field)
+		}
+		*f.value = value
+	}
+	column.TypeName = dictMTypeMap[column.MType]
+
+	return tableId, column, nil
+}
+
+func readSysIndexesRecord(record *dictRecord) (uint64, *DictIndex, error) {
+	index := &DictIndex{}
+
+	tableId, err := record.getUint64(sysIndexesFieldTableId)
+	if err != nil {
+		return 0, nil, withField(err, "SYS_INDEXES.TABLE_ID")
+	}
+
+	id, err := record.getUint64(sysIndexesFieldId)
+	if err != nil {
+		return 0, nil, withField(err, "SYS_INDEXES.ID")
+	}
+	index.Id = id
+
+	name, err := record.getString(sysIndexesFieldName)
+	if err != nil {
+		return 0, nil, withField(err, "SYS_INDEXES.NAME")
+	}
+	index.Name = name
+
+	fields := []struct {
+		i uint16
+		field string
+		value *uint32
+	}{
+		{sysIndexesFieldNFields, "SYS_INDEXES.N_FIELDS", &index.NFields},
+		{sysIndexesFieldType, "SYS_INDEXES.TYPE", &index.Type},
+		{sysIndexesFieldSpace, "SYS_INDEXES.SPACE", &index.SpaceId},
+		{sysIndexesFieldPageNo, "SYS_INDEXES.PAGE_NO", &index.RootPageNo},
+	}
+	for _, f := range fields {
+		value, err := record.getUint32(f.i)
+		if err != nil {
+			return 0, nil, withField(err, f.field)
+		}
+		*f.value = value
+	}
+
+	// 5.7 之前创建的索引没有 MERGE_THRESHOLD
+	if record.header.nFields > sysIndexesFieldMergeThreshold {
+		mergeThreshold, err := record.getUint32(sysIndexesFieldMergeThreshold)
+		if err != nil {
+			return 0, nil, withField(err, "SYS_INDEXES.MERGE_THRESHOLD")
+		}
+		index.MergeThreshold = mergeThreshold
+	}
+
+	return tableId, index, nil
+}
+
+// readSysFieldsRecord 读取 SYS_FIELDS 中的一个字段。索引中有前缀字段时，POS 的高 16 位为字段序号，
+// 低 16 位为前缀长度，与 dict_load_field_low() 相同，索引的第一个字段总是按这种方式解析
+func readSysFieldsRecord(record *dictRecord, firstField bool) (DictField, error) {
+	field := DictField{}
+
+	pos, err := record.getUint32(sysFieldsFieldPos)
+	if err != nil {
+		return field, withField(err, "SYS_FIELDS.POS")
+	}
+	if firstField || pos > 0xFFFF {
+		field.Pos = pos >> 16
+		field.PrefixLen = pos & 0xFFFF
+	} else {
+		field.Pos = pos
+	}
+
+	name, err := record.getString(sysFieldsFieldColName)
+	if err != nil {
+		return field, withField(err, "SYS_FIELDS.COL_NAME")
+	}
+	field.ColumnName = name
+
+	return field, nil
+}
+
+// addSystemTables 系统表的定义是固定的，不存储在 SYS_TABLES 中，这里只加入它们的索引，用于显示索引名
+func (dictionary *DataDictionary)addSystemTables() {
+	header := dictionary.Header
+	systemTables := []struct {
+		id uint64
+		name string
+		indexes []*DictIndex
+	}{
+		{dictTablesId, "SYS_TABLES", []*DictIndex{
+			{Id: dictTablesId, Name: "CLUST_IND", Type: dictClustered | dictUnique, RootPageNo: header.TablesRoot},
+			{Id: dictTableIdsId, Name: "ID_IND", Type: dictUnique, RootPageNo: header.TableIdsRoot},
+		}},
+		{dictColumnsId, "SYS_COLUMNS", []*DictIndex{
+			{Id: dictColumnsId, Name: "CLUST_IND", Type: dictClustered | dictUnique, RootPageNo: header.ColumnsRoot},
+		}},
+		{dictIndexesId, "SYS_INDEXES", []*DictIndex{
+			{Id: dictIndexesId, Name: "CLUST_IND", Type: dictClustered | dictUnique, RootPageNo: header.IndexesRoot},
+		}},
+		{dictFieldsId, "SYS_FIELDS", []*DictIndex{
+			{Id: dictFieldsId, Name: "CLUST_IND", Type: dictClustered | dictUnique, RootPageNo: header.FieldsRoot},
+		}},
+	}
+
+	for _, systemTable := range systemTables {
+		table := &DictTable{Id: systemTable.id, Name: systemTable.name, RowFormat: getDictRowFormat(false, 0), Indexes: systemTable.indexes}
+		for _, index := range table.Indexes {
+			index.Table = table
+			dictionary.indexes[index.Id] = index
+		}
+		dictionary.tables[table.Id] = table
+		dictionary.Tables = append(dictionary.Tables, table)
+	}
+}
+
+// ReadDataDictionary 读取 MySQL 5.7 及之前版本系统表空间中的数据字典：SYS_TABLES、SYS_COLUMNS、SYS_INDEXES、SYS_FIELDS，
+// 得到所有表、列、索引（包括根页和表空间 ID）和索引字段。系统表总是 REDUNDANT 行格式
+func (file *File)ReadDataDictionary() (*DataDictionary, error) {
+	errPrefix := "File::ReadDataDictionary()"
+
+	header, err := file.ReadDictHeader()
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	dictionary := newDataDictionary()
+	dictionary.Header = &header
+	dictionary.addSystemTables()
+
+	err = file.walkDictIndex(header.TablesRoot, func(record *dictRecord) error {
+		table, err := readSysTablesRecord(record)
+		if err != nil {
+			return err
+		}
+		dictionary.tables[table.Id] = table
+		dictionary.Tables = append(dictionary.Tables, table)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: [SYS_TABLES: %w]", errPrefix, err)
+	}
+
+	err = file.walkDictIndex(header.ColumnsRoot, func(record *dictRecord) error {
+		tableId, column, err := readSysColumnsRecord(record)
+		if err != nil {
+			return err
+		}
+		if table := dictionary.tables[tableId]; table != nil {
+			table.Columns = append(table.Columns, column)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: [SYS_COLUMNS: %w]", errPrefix, err)
+	}
+
+	err = file.walkDictIndex(header.IndexesRoot, func(record *dictRecord) error {
+		tableId, index, err := readSysIndexesRecord(record)
+		if err != nil {
+			return err
+		}
+		if table := dictionary.tables[tableId]; table != nil {
+			index.Table = table
+			table.Indexes = append(table.Indexes, index)
+		}
+		dictionary.indexes[index.Id] = index
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: [SYS_INDEXES: %w]", errPrefix, err)
+	}
+
+	err = file.walkDictIndex(header.FieldsRoot, func(record *dictRecord) error {
+		indexId, err := record.getUint64(sysFieldsFieldIndexId)
+		if err != nil {
+			return withField(err, "SYS_FIELDS.INDEX_ID")
+		}
+		index := dictionary.indexes[indexId]
+
+		field, err := readSysFieldsRecord(record, index != nil && len(index.Fields) == 0)
+		if err != nil {
+			return err
+		}
+		if index != nil {
+			index.Fields = append(index.Fields, field)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: [SYS_FIELDS: %w]", errPrefix, err)
+	}
+
+	sort.SliceStable(dictionary.Tables, func(i, j int) bool { return dictionary.Tables[i].Name < dictionary.Tables[j].Name })
+
+	return dictionary, nil
+}
+
+func printDataDictionary(path string, dictionary *DataDictionary) {
+	fmt.Printf("Data Dictionary (%s):\n", path)
+	if header := dictionary.Header; header != nil {
+		fmt.Printf("    next_row_id: %d\n", header.RowId)
+		fmt.Printf("    next_table_id: %d\n", header.TableId)
+		fmt.Printf("    next_index_id: %d\n", header.IndexId)
+		fmt.Printf("    max_space_id: %d\n", header.MaxSpaceId)
+	}
+	fmt.Printf("    tablespaces: %d\n", len(dictionary.Tablespaces))
+	fmt.Printf("    tables: %d\n", len(dictionary.Tables))
+	fmt.Println()
+
+	if len(dictionary.Tablespaces) > 0 {
+		fmt.Println("Tablespaces:")
+		for _, tablespace := range dictionary.Tablespaces {
+			fmt.Printf("    space %d: %s (%s)\n", tablespace.SpaceId, tablespace.Name, tablespace.Engine)
+		}
+		fmt.Println()
+	}
+
+	for _, table := range dictionary.Tables {
+		fmt.Printf("Table %s:\n", table.Name)
+		fmt.Printf("    id: %d, space: %d, row_format: %s, flags: %d, flags2: %d\n", table.Id, table.SpaceId, table.RowFormat, table.Flags, table.Flags2)
+
+		for _, column := range table.Columns {
+			fmt.Printf("    column %d %s: %s", column.Pos, column.Name, column.TypeName)
+			if column.MType != 0 {
+				fmt.Printf(", prtype %d, len %d", column.PrType, column.Len)
+			}
+			fmt.Println()
+		}
+
+		for _, index := range table.Indexes {
+			fmt.Printf("    index %d %s: space %d, root %d, type %d", index.Id, index.Name, index.SpaceId, index.RootPageNo, index.Type)
+			if len(index.Fields) > 0 {
+				fields := make([]string, 0, len(index.Fields))
+				for _, field := range index.Fields {
+					if field.PrefixLen > 0 {
+						fields = append(fields, fmt.Sprintf("%s(%d)", field.ColumnName, field.PrefixLen))
+					} else {
+						fields = append(fields, field.ColumnName)
+					}
+				}
+				fmt.Printf(", fields (%s)", strings.Join(fields, ", "))
+			}
+			fmt.Println()
+		}
+		fmt.Println()
+	}
+}
@@ -0,0 +1,449 @@
+package innobase
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	doublewriteFilePrefix = "#ib_" // 8.0.20 开始 doublewrite buffer 存储在单独的文件中：#ib_<页大小>_<文件序号>.dblwr
+	doublewriteFileSuffix = ".dblwr"
+)
+
+// 页副本与目标表空间中对应页的比较结果
+const (
+	DoublewriteTargetValid = "target_valid" // 目标页完整，不需要恢复
+	DoublewriteRestorable = "restorable" // 目标页损坏，副本完整，可以用副本恢复
+	DoublewriteUnrecoverable = "unrecoverable" // 目标页和副本都损坏
+	DoublewriteOutOfRange = "out_of_range" // 页号超出目标表空间的大小
+)
+
+var doublewriteStatuses = []string{
+	DoublewriteTargetValid,
+	DoublewriteRestorable,
+	DoublewriteUnrecoverable,
+	DoublewriteOutOfRange,
+}
+
+// DoublewritePage doublewrite buffer 中的一个页副本
+type DoublewritePage struct {
+	Source string // 副本所在的文件
+	Slot uint32 // 副本在文件中的位置：系统表空间中为 doublewrite 块中的页号，.dblwr 文件中为页的序号
+	SpaceId uint32 // 副本页头中的表空间 ID
+	PageNo uint32 // 副本页头中的页号
+	Lsn uint64
+	Page *Page // 副本的全部字节，压缩页只有前物理页大小个字节有效
+}
+
+// DoublewriteComparison 一个页副本与目标表空间中对应页的比较结果
+type DoublewriteComparison struct {
+	Copy DoublewritePage
+	Status string
+	CopyValid bool // 按目标表空间的页大小校验副本的检验和、页头页尾 Lsn
+	TargetValid bool
+	TargetTorn bool
+	TargetLsn uint64
+}
+
+// parseDoublewriteFileName 从 #ib_16384_0.dblwr 这样的文件名中解析页大小
+func parseDoublewriteFileName(name string) (uint32, bool) {
+	if !strings.HasPrefix(name, doublewriteFilePrefix) || !strings.HasSuffix(name, doublewriteFileSuffix) {
+		return 0, false
+	}
+
+	parts := strings.Split(strings.TrimSuffix(strings.TrimPrefix(name, doublewriteFilePrefix), doublewriteFileSuffix), "_")
+	if len(parts) != 2 {
+		return 0, false
+	}
+
+	pageSize, err := strconv.ParseUint(parts[0], 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	if _, err := strconv.ParseUint(parts[1], 10, 32); err != nil {
+		return 0, false
+	}
+
+	return uint32(pageSize), true
+}
+
+// IsDoublewriteFile 路径是否为 8.0.20 之后的 #ib_*.dblwr 文件
+func IsDoublewriteFile(path string) bool {
+	_, ok := parseDoublewriteFileName(filepath.Base(path))
+
+	return ok
+}
+
+// NewDoublewriteFile 打开 8.0.20 之后的 #ib_*.dblwr 文件，文件中没有 FSP 头，页大小从文件名中解析
+func NewDoublewriteFile(path string) (*File, error) {
+	errPrefix := "NewDoublewriteFile()"
+
+	pageSize, ok := parseDoublewriteFileName(filepath.Base(path))
+	if !ok {
+		return nil, fmt.Errorf("%s: [%s is not a doublewrite file]", errPrefix, path)
+	}
+
+	file := NewFile(path)
+	if err := file.SetPageSize(pageSize, 0); err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return file, nil
+}
+
+// getDoublewritePage 解析副本的页头，全 0 的页（还没有写入过）返回 false
+func getDoublewritePage(source string, slot uint32, page *Page) (DoublewritePage, bool, error) {
+	if page.IsEmpty() {
+		return DoublewritePage{}, false, nil
+	}
+
+	spaceId, err := page.GetSpaceId()
+	if err != nil {
+		return DoublewritePage{}, false, err
+	}
+
+	pageNo, err := page.GetPageNo()
+	if err != nil {
+		return DoublewritePage{}, false, err
+	}
+
+	lsn, err := page.GetLsn()
+	if err != nil {
+		return DoublewritePage{}, false, err
+	}
+
+	return DoublewritePage{
+		Source: source,
+		Slot: slot,
+		SpaceId: spaceId,
+		PageNo: pageNo,
+		Lsn: lsn,
+		Page: page,
+	}, true, nil
+}
+
+// ReadDoublewritePages 读取 doublewrite buffer 中的所有页副本，
+// file 为系统表空间时读取 TRX_SYS 页中记录的两个块，为 #ib_*.dblwr 文件时读取文件中的所有页
+func (file *File)ReadDoublewritePages() ([]DoublewritePage, error) {
+	errPrefix := "File::ReadDoublewritePages()"
+
+	var pageNos []uint32
+	if IsDoublewriteFile(file.GetPath()) {
+		pageCount, err := file.getPageCount()
+		if err != nil {
+			return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+		}
+		for pageNo := uint32(0); pageNo < pageCount; pageNo++ {
+			pageNos = append(pageNos, pageNo)
+		}
+	} else {
+		header, err := file.ReadTrxSysHeader()
+		if err != nil {
+			return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+		}
+
+		doublewrite := header.Doublewrite
+		if !doublewrite.IsCreated() {
+			return nil, fmt.Errorf("%s: [%w]", errPrefix, ErrNoDoublewrite)
+		}
+		for _, first := range []uint32{doublewrite.Block1, doublewrite.Block2} {
+			for pageNo := first; pageNo < first + doublewrite.BlockPages; pageNo++ {
+				pageNos = append(pageNos, pageNo)
+			}
+		}
+	}
+
+	copies := []DoublewritePage{}
+	for _, pageNo := range pageNos {
+		// 副本中存储的是原页的页号，与副本的位置不一致
+		page, err := file.ReadRawPage(pageNo)
+		if err != nil {
+			return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+		}
+		// 副本会与其他文件中的页比较、写入修复后的文件，复制数据使其不依赖 doublewrite 文件是否已经关闭
+		page = page.Clone()
+
+		pageCopy, ok, err := getDoublewritePage(file.GetPath(), pageNo, page)
+		if err != nil {
+			return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+		}
+		if ok {
+			copies = append(copies, pageCopy)
+		}
+	}
+
+	return copies, nil
+}
+
+// checkPageIntact 页的检验和正确，并且页头页尾的 Lsn 一致，返回页是否完整、是否为不完整写入的页。
+// 与 InnoDB 崩溃恢复相同，全 0 的页也按损坏处理
+func checkPageIntact(page *Page) (bool, bool, error) {
+	if page.IsEmpty() {
+		return false, false, nil
+	}
+
+	checksum, err := page.VerifyChecksum()
+	if err != nil {
+		return false, false, err
+	}
+
+	trailer, err := page.CheckTrailer()
+	if err != nil {
+		return false, false, err
+	}
+
+	return checksum.Valid && !trailer.Torn, trailer.Torn, nil
+}
+
+// getDoublewriteSpaceId 确定目标表空间的 ID，第 0 页完整时从第 0 页中读取。
+// 第 0 页损坏时与 InnoDB 崩溃恢复相同，使用 doublewrite 中完整的第 0 页副本：副本的表空间 ID 需要与损坏的第 0 页中
+// FIL_PAGE_SPACE_ID 或 FSP_SPACE_ID 之一相同，同时返回按副本中的 FSP_SPACE_FLAGS 计算的逻辑页大小和物理页大小，
+// 没有使用副本时页大小返回 0
+func (file *File)getDoublewriteSpaceId(copies []DoublewritePage) (uint32, uint32, uint32, error) {
+	errPrefix := "File::getDoublewriteSpaceId()"
+
+	page, readErr := file.ReadRawPage(0)
+	if readErr == nil {
+		valid, _, err := checkPageIntact(page)
+		if err != nil {
+			return 0, 0, 0, fmt.Errorf("%s: [%w]", errPrefix, err)
+		}
+		if valid {
+			spaceId, err := page.GetSpaceId()
+			if err != nil {
+				return 0, 0, 0, fmt.Errorf("%s: [%w]", errPrefix, err)
+			}
+			return spaceId, 0, 0, nil
+		}
+	}
+
+	// 第 0 页中的页大小可能已经损坏，直接按固定偏移量读取两个表空间 ID
+	if err := file.initSource(); err != nil {
+		return 0, 0, 0, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	buf := make([]byte, int(fspHeaderOffset) + int(fspOffsetSpaceId) + int(size4))
+	if _, err := file.source.ReadAt(buf, 0); err != nil {
+		return 0, 0, 0, fmt.Errorf("%s: [read page 0: %w]", errPrefix, err)
+	}
+	filSpaceId := binary.BigEndian.Uint32(buf[fileOffsetSpaceId:])
+	fspSpaceId := binary.BigEndian.Uint32(buf[fspHeaderOffset + fspOffsetSpaceId:])
+
+	var restore *Page
+	var restoreLsn uint64
+	var restoreLogicalPageSize, restorePhysicalPageSize uint32
+	for _, pageCopy := range copies {
+		if pageCopy.PageNo != 0 || (pageCopy.SpaceId != filSpaceId && pageCopy.SpaceId != fspSpaceId) {
+			continue
+		}
+		if restore != nil && pageCopy.Lsn <= restoreLsn {
+			continue
+		}
+
+		flags, err := pageCopy.Page.getUint32(uint32(fspHeaderOffset + fspOffsetSpaceFlags))
+		if err != nil {
+			continue
+		}
+		logicalPageSize, physicalPageSize, err := parseSpaceFlagsPageSize(flags)
+		if err != nil || pageCopy.Page.GetSize() < physicalPageSize {
+			continue
+		}
+
+		copyPage := NewPage(0, pageCopy.Page.GetData()[:physicalPageSize])
+		copyPage.compressed = physicalPageSize < logicalPageSize
+		if valid, _, err := checkPageIntact(copyPage); err != nil || !valid {
+			continue
+		}
+		restore = copyPage
+		restoreLsn = pageCopy.Lsn
+		restoreLogicalPageSize, restorePhysicalPageSize = logicalPageSize, physicalPageSize
+	}
+
+	if restore == nil {
+		if readErr != nil {
+			return 0, 0, 0, fmt.Errorf("%s: [%w]", errPrefix, readErr)
+		}
+		// 没有可用的副本，第 0 页会在比较结果中标记为无法恢复
+		spaceId, err := page.GetSpaceId()
+		if err != nil {
+			return 0, 0, 0, fmt.Errorf("%s: [%w]", errPrefix, err)
+		}
+		return spaceId, 0, 0, nil
+	}
+
+	spaceId, err := restore.GetSpaceId()
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return spaceId, restoreLogicalPageSize, restorePhysicalPageSize, nil
+}
+
+// CompareDoublewrite 把属于当前表空间的页副本与表空间中对应的页比较，
+// 与 InnoDB 崩溃恢复相同，只有目标页损坏时才使用副本，目标页完整时即使副本的 Lsn 更大也不使用。
+// 第 0 页损坏时表空间 ID 和页大小取自 doublewrite 中第 0 页的副本，第 0 页也可以恢复
+func (file *File)CompareDoublewrite(copies []DoublewritePage) ([]DoublewriteComparison, error) {
+	errPrefix := "File::CompareDoublewrite()"
+
+	spaceId, restoreLogicalPageSize, restorePhysicalPageSize, err := file.getDoublewriteSpaceId(copies)
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	// 第 0 页取自 doublewrite 副本时页大小以副本为准，之后的页数量、目标页的读取和修复后的文件都使用副本的页大小
+	if restorePhysicalPageSize != 0 && !file.pageSizeFixed {
+		file.setPageSize(restoreLogicalPageSize, restorePhysicalPageSize)
+	}
+
+	pageCount, err := file.getPageCount()
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	physicalPageSize, err := file.GetPhysicalPageSize()
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	comparisons := []DoublewriteComparison{}
+	for _, pageCopy := range copies {
+		if pageCopy.SpaceId != spaceId {
+			continue
+		}
+
+		comparison := DoublewriteComparison{Copy: pageCopy}
+		if pageCopy.PageNo >= pageCount {
+			comparison.Status = DoublewriteOutOfRange
+			comparisons = append(comparisons, comparison)
+			continue
+		}
+
+		// 压缩页在 doublewrite 中也占一个完整的页，只有前物理页大小个字节是页的内容
+		if pageCopy.Page.GetSize() < physicalPageSize {
+			err := fmt.Errorf("doublewrite page is %d bytes, expected at least %d", pageCopy.Page.GetSize(), physicalPageSize)
+			return nil, fmt.Errorf("%s: [%w]", errPrefix, newPageError(pageCopy.PageNo, 0, "", err))
+		}
+		copyPage := file.newPage(pageCopy.PageNo, pageCopy.Page.GetData()[:physicalPageSize])
+		comparison.CopyValid, _, err = checkPageIntact(copyPage)
+		if err != nil {
+			return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+		}
+
+		target, err := file.ReadRawPage(pageCopy.PageNo)
+		if err != nil {
+			return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+		}
+		comparison.TargetValid, comparison.TargetTorn, err = checkPageIntact(target)
+		if err != nil {
+			return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+		}
+		comparison.TargetLsn, err = target.GetLsn()
+		if err != nil {
+			return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+		}
+
+		switch {
+		case comparison.TargetValid:
+			comparison.Status = DoublewriteTargetValid
+		case comparison.CopyValid:
+			comparison.Status = DoublewriteRestorable
+		default:
+			comparison.Status = DoublewriteUnrecoverable
+		}
+		comparisons = append(comparisons, comparison)
+	}
+
+	return comparisons, nil
+}
+
+// getRestorePages 可以恢复的页，同一个页有多个完整的副本时使用 Lsn 最大的
+func getRestorePages(comparisons []DoublewriteComparison) map[uint32]DoublewritePage {
+	pages := map[uint32]DoublewritePage{}
+	for _, comparison := range comparisons {
+		if comparison.Status != DoublewriteRestorable {
+			continue
+		}
+
+		pageCopy := comparison.Copy
+		if current, exists := pages[pageCopy.PageNo]; !exists || pageCopy.Lsn > current.Lsn {
+			pages[pageCopy.PageNo] = pageCopy
+		}
+	}
+
+	return pages
+}
+
+// WriteRepairedCopy 把表空间复制到 repairedPath，其中损坏的页替换为 doublewrite 中完整的副本，返回替换的页号。
+// 原文件不会被修改，repairedPath 已经存在时返回错误（errors.Is(err, fs.ErrExist)）。先写入同一目录下的临时文件，
+// 完成后再链接为 repairedPath，最后删除临时文件，不会留下不完整的文件
+func (file *File)WriteRepairedCopy(comparisons []DoublewriteComparison, repairedPath string) (restored []uint32, err error) {
+	errPrefix := "File::WriteRepairedCopy()"
+
+	pageCount, err := file.getPageCount()
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	physicalPageSize, err := file.GetPhysicalPageSize()
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	output, err := ioutil.TempFile(filepath.Dir(repairedPath), filepath.Base(repairedPath) + ".tmp")
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	// 无论成功与否都删除临时文件：成功时 repairedPath 是临时文件的硬链接
+	defer func() {
+		if err != nil {
+			output.Close()
+		}
+		os.Remove(output.Name())
+	}()
+
+	if err := output.Chmod(0640); err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	restorePages := getRestorePages(comparisons)
+	for pageNo := uint32(0); pageNo < pageCount; pageNo++ {
+		var data []byte
+		if pageCopy, exists := restorePages[pageNo]; exists {
+			data = pageCopy.Page.GetData()[:physicalPageSize]
+		} else {
+			page, err := file.ReadRawPage(pageNo)
+			if err != nil {
+				return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+			}
+			data = page.GetData()
+		}
+
+		if _, err := output.Write(data); err != nil {
+			return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+		}
+	}
+
+	if err := output.Sync(); err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	if err := output.Close(); err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	// 用硬链接而不是重命名发布文件：repairedPath 已经存在（包括在写入期间被创建）时 Link 返回 EEXIST，不会覆盖
+	if err := os.Link(output.Name(), repairedPath); err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	restored = make([]uint32, 0, len(restorePages))
+	for pageNo := range restorePages {
+		restored = append(restored, pageNo)
+	}
+	sort.Slice(restored, func(i, j int) bool { return restored[i] < restored[j] })
+
+	return restored, nil
+}
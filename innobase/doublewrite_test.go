@@ -0,0 +1,395 @@
+package innobase
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testDoublewriteCopy doublewrite 中的一个页副本
+type testDoublewriteCopy struct {
+	pageNo uint32
+	spaceId uint32
+	lsn uint64
+	corrupt bool
+}
+
+const testDoublewriteSpaceId = 7
+
+// 目标表空间有 4 个页：第 0 页和第 2 页完整，第 1 页写入不完整，第 3 页检验和错误。
+// 第 1 页有两个完整的副本，第 3 页的副本也损坏，第 9 页超出表空间大小，最后一个副本属于其他表空间
+var testDoublewriteCopies = []testDoublewriteCopy{
+	{pageNo: 1, spaceId: testDoublewriteSpaceId, lsn: 300},
+	{pageNo: 1, spaceId: testDoublewriteSpaceId, lsn: 200},
+	{pageNo: 2, spaceId: testDoublewriteSpaceId, lsn: 500},
+	{pageNo: 3, spaceId: testDoublewriteSpaceId, lsn: 400, corrupt: true},
+	{pageNo: 9, spaceId: testDoublewriteSpaceId, lsn: 600},
+	{pageNo: 1, spaceId: testDoublewriteSpaceId + 1, lsn: 700},
+}
+
+// putTestIntactPage 写入页号、表空间 ID 和 Lsn，页头页尾的检验和使用 innodb_checksum_algorithm=none 的魔数，
+// 页中的内容由 Lsn 决定，用于区分同一个页的不同副本
+func putTestIntactPage(page []byte, pageNo uint32, spaceId uint32, lsn uint64) {
+	size := len(page)
+	binary.BigEndian.PutUint32(page[fileOffsetPageChecksum:], checksumNoneMagic)
+	binary.BigEndian.PutUint32(page[fileOffsetPageNo:], pageNo)
+	binary.BigEndian.PutUint64(page[fileOffsetPageLsn:], lsn)
+	binary.BigEndian.PutUint32(page[fileOffsetSpaceId:], spaceId)
+	copy(page[fileHeaderSize + 100:], testFill(byte(lsn), 64))
+	binary.BigEndian.PutUint32(page[size - int(fileTrailerSize):], checksumNoneMagic)
+	binary.BigEndian.PutUint32(page[size - int(fileTrailerSize) + 4:], uint32(lsn))
+}
+
+// putTestCorruptPage 写入检验和错误的页
+func putTestCorruptPage(page []byte, pageNo uint32, spaceId uint32, lsn uint64) {
+	putTestIntactPage(page, pageNo, spaceId, lsn)
+	binary.BigEndian.PutUint32(page[fileOffsetPageChecksum:], 1)
+}
+
+func putTestDoublewriteCopy(page []byte, pageCopy testDoublewriteCopy) {
+	if pageCopy.corrupt {
+		putTestCorruptPage(page, pageCopy.pageNo, pageCopy.spaceId, pageCopy.lsn)
+		return
+	}
+	putTestIntactPage(page, pageCopy.pageNo, pageCopy.spaceId, pageCopy.lsn)
+	if pageCopy.pageNo == 0 {
+		binary.BigEndian.PutUint32(page[fspHeaderOffset + fspOffsetSpaceId:], pageCopy.spaceId)
+	}
+}
+
+// newTestDoublewriteTargetData 目标表空间的数据，modify 在写入之后修改页中的数据
+func newTestDoublewriteTargetData(modify func(data []byte)) []byte {
+	data := newTestPagesData(4)
+	page := func(pageNo int) []byte {
+		return data[pageNo * testPageSize:(pageNo + 1) * testPageSize]
+	}
+
+	putTestIntactPage(page(0), 0, testDoublewriteSpaceId, 10)
+	binary.BigEndian.PutUint32(page(0)[fspHeaderOffset + fspOffsetSpaceId:], testDoublewriteSpaceId)
+
+	putTestIntactPage(page(1), 1, testDoublewriteSpaceId, 100)
+	binary.BigEndian.PutUint32(page(1)[testPageSize - 4:], 50)
+
+	putTestIntactPage(page(2), 2, testDoublewriteSpaceId, 50)
+	putTestCorruptPage(page(3), 3, testDoublewriteSpaceId, 30)
+
+	if modify != nil {
+		modify(data)
+	}
+
+	return data
+}
+
+// newTestDblwrFile 构造 8.0.20 之后的 #ib_16384_0.dblwr 文件，副本依次存储，最后留一个没有写入过的页
+func newTestDblwrFile(t *testing.T, copies []testDoublewriteCopy) *File {
+	data := make([]byte, (len(copies) + 1) * testPageSize)
+	for i, pageCopy := range copies {
+		putTestDoublewriteCopy(data[i * testPageSize:(i + 1) * testPageSize], pageCopy)
+	}
+
+	file := NewFileFromSource(NewBytesSource("#ib_16384_0.dblwr", data))
+	if err := file.SetPageSize(testPageSize, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return file
+}
+
+// newTestSystemDoublewriteFile 构造系统表空间，TRX_SYS 页中的 doublewrite 头指向第 64 页和第 128 页开始的两个块，
+// 副本依次存储在两个块中
+func newTestSystemDoublewriteFile(copies []testDoublewriteCopy) *File {
+	return newTestFile(192, func(data []byte) {
+		putTestPageType(data, trxSysPageNo, pageTypeTrxSys)
+		header := data[int(trxSysPageNo + 1) * testPageSize - int(trxSysDoublewriteEnd):]
+		binary.BigEndian.PutUint32(header[trxSysDoublewriteOffsetMagic:], trxSysDoublewriteMagicN)
+		binary.BigEndian.PutUint32(header[trxSysDoublewriteOffsetBlock1:], 64)
+		binary.BigEndian.PutUint32(header[trxSysDoublewriteOffsetBlock2:], 128)
+
+		// 没有写入过的副本全部为 0
+		for i := 64 * testPageSize; i < len(data); i++ {
+			data[i] = 0
+		}
+		for i, pageCopy := range copies {
+			slot := 64 + i
+			if i % 2 == 1 {
+				slot = 128 + i
+			}
+			putTestDoublewriteCopy(data[slot * testPageSize:(slot + 1) * testPageSize], pageCopy)
+		}
+	})
+}
+
+func TestReadDoublewritePages(t *testing.T) {
+	tests := []struct {
+		name string
+		newFile func(t *testing.T) *File
+		wantSlots []uint32 // 每个副本所在的位置，与 testDoublewriteCopies 的顺序相同
+	}{
+		{
+			name: "dblwr file",
+			newFile: func(t *testing.T) *File {
+				return newTestDblwrFile(t, testDoublewriteCopies)
+			},
+			wantSlots: []uint32{0, 1, 2, 3, 4, 5},
+		},
+		{
+			name: "system tablespace",
+			newFile: func(t *testing.T) *File {
+				return newTestSystemDoublewriteFile(testDoublewriteCopies)
+			},
+			wantSlots: []uint32{64, 129, 66, 131, 68, 133},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := tt.newFile(t)
+			defer file.Close()
+
+			copies, err := file.ReadDoublewritePages()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(copies) != len(tt.wantSlots) {
+				t.Fatalf("got %d copies, want %d", len(copies), len(tt.wantSlots))
+			}
+
+			want := map[uint32]testDoublewriteCopy{}
+			for i, slot := range tt.wantSlots {
+				want[slot] = testDoublewriteCopies[i]
+			}
+
+			for _, pageCopy := range copies {
+				expected, exists := want[pageCopy.Slot]
+				if !exists {
+					t.Fatalf("unexpected copy in slot %d", pageCopy.Slot)
+				}
+				if pageCopy.PageNo != expected.pageNo || pageCopy.SpaceId != expected.spaceId || pageCopy.Lsn != expected.lsn {
+					t.Fatalf("slot %d = page %d, space %d, lsn %d, want page %d, space %d, lsn %d", pageCopy.Slot,
+						pageCopy.PageNo, pageCopy.SpaceId, pageCopy.Lsn, expected.pageNo, expected.spaceId, expected.lsn)
+				}
+				if pageCopy.Source != file.GetPath() {
+					t.Fatalf("source = %q, want %q", pageCopy.Source, file.GetPath())
+				}
+			}
+		})
+	}
+}
+
+func TestCompareDoublewrite(t *testing.T) {
+	source := newTestDblwrFile(t, testDoublewriteCopies)
+	defer source.Close()
+	copies, err := source.ReadDoublewritePages()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	file := NewFileFromSource(NewBytesSource("test.ibd", newTestDoublewriteTargetData(nil)))
+	defer file.Close()
+
+	comparisons, err := file.CompareDoublewrite(copies)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 属于其他表空间的副本不参与比较
+	want := []struct {
+		pageNo uint32
+		lsn uint64
+		status string
+		copyValid bool
+		targetValid bool
+		targetTorn bool
+	}{
+		{1, 300, DoublewriteRestorable, true, false, true},
+		{1, 200, DoublewriteRestorable, true, false, true},
+		{2, 500, DoublewriteTargetValid, true, true, false},
+		{3, 400, DoublewriteUnrecoverable, false, false, false},
+		{9, 600, DoublewriteOutOfRange, false, false, false},
+	}
+	if len(comparisons) != len(want) {
+		t.Fatalf("got %d comparisons, want %d", len(comparisons), len(want))
+	}
+	for i, w := range want {
+		c := comparisons[i]
+		if c.Copy.PageNo != w.pageNo || c.Copy.Lsn != w.lsn {
+			t.Fatalf("comparison %d is page %d lsn %d, want page %d lsn %d", i, c.Copy.PageNo, c.Copy.Lsn, w.pageNo, w.lsn)
+		}
+		if c.Status != w.status || c.CopyValid != w.copyValid || c.TargetValid != w.targetValid || c.TargetTorn != w.targetTorn {
+			t.Fatalf("page %d lsn %d = %+v, want status %s, copy valid %t, target valid %t, target torn %t",
+				w.pageNo, w.lsn, c, w.status, w.copyValid, w.targetValid, w.targetTorn)
+		}
+	}
+
+	// 第 1 页有两个完整的副本，使用 Lsn 更大的一个
+	restorePages := getRestorePages(comparisons)
+	if len(restorePages) != 1 {
+		t.Fatalf("got %d restore pages, want 1", len(restorePages))
+	}
+	if pageCopy, exists := restorePages[1]; !exists || pageCopy.Lsn != 300 {
+		t.Fatalf("restore page 1 = %+v, want lsn 300", pageCopy)
+	}
+}
+
+func TestWriteRepairedCopy(t *testing.T) {
+	source := newTestDblwrFile(t, testDoublewriteCopies)
+	defer source.Close()
+	copies, err := source.ReadDoublewritePages()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	target := newTestDoublewriteTargetData(nil)
+	file := NewFileFromSource(NewBytesSource("test.ibd", target))
+	defer file.Close()
+
+	comparisons, err := file.CompareDoublewrite(copies)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	dir, err := ioutil.TempDir("", "doublewrite")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	repairedPath := filepath.Join(dir, "repaired.ibd")
+
+	restored, err := file.WriteRepairedCopy(comparisons, repairedPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(restored) != 1 || restored[0] != 1 {
+		t.Fatalf("restored = %v, want [1]", restored)
+	}
+
+	// 只有第 1 页替换为 Lsn 为 300 的副本，原数据不变
+	want := append([]byte{}, target...)
+	putTestIntactPage(want[testPageSize:2 * testPageSize], 1, testDoublewriteSpaceId, 300)
+	got, err := ioutil.ReadFile(repairedPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("repaired file differs from the expected %d bytes", len(want))
+	}
+	if !bytes.Equal(target, newTestDoublewriteTargetData(nil)) {
+		t.Fatalf("target data was modified")
+	}
+
+	// repairedPath 已经存在时不覆盖，也不留下临时文件
+	if _, err := file.WriteRepairedCopy(comparisons, repairedPath); !errors.Is(err, os.ErrExist) {
+		t.Fatalf("error = %v, want %v", err, os.ErrExist)
+	}
+	got, err = ioutil.ReadFile(repairedPath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("existing repaired file was modified")
+	}
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("got %d files in output directory, want 1", len(entries))
+	}
+}
+
+func TestGetDoublewriteSpaceId(t *testing.T) {
+	corruptPage0 := func(data []byte) {
+		binary.BigEndian.PutUint32(data[fileOffsetPageChecksum:], 1)
+	}
+
+	tests := []struct {
+		name string
+		modify func(data []byte)
+		copies []testDoublewriteCopy
+		wantSpaceId uint32
+		wantPageSize uint32
+		wantPage0Status string
+	}{
+		{
+			name: "page 0 intact",
+			copies: []testDoublewriteCopy{{pageNo: 0, spaceId: testDoublewriteSpaceId, lsn: 20}},
+			wantSpaceId: testDoublewriteSpaceId,
+			wantPage0Status: DoublewriteTargetValid,
+		},
+		{
+			name: "page 0 restored from copy",
+			modify: corruptPage0,
+			copies: []testDoublewriteCopy{{pageNo: 0, spaceId: testDoublewriteSpaceId, lsn: 20}},
+			wantSpaceId: testDoublewriteSpaceId,
+			wantPageSize: testPageSize,
+			wantPage0Status: DoublewriteRestorable,
+		},
+		{
+			name: "page 0 flags destroyed",
+			modify: func(data []byte) {
+				corruptPage0(data)
+				binary.BigEndian.PutUint32(data[fspHeaderOffset + fspOffsetSpaceFlags:], 0xFFFFFFFF)
+			},
+			copies: []testDoublewriteCopy{{pageNo: 0, spaceId: testDoublewriteSpaceId, lsn: 20}},
+			wantSpaceId: testDoublewriteSpaceId,
+			wantPageSize: testPageSize,
+			wantPage0Status: DoublewriteRestorable,
+		},
+		{
+			name: "copy of another space",
+			modify: corruptPage0,
+			copies: []testDoublewriteCopy{{pageNo: 0, spaceId: testDoublewriteSpaceId + 1, lsn: 20}},
+			wantSpaceId: testDoublewriteSpaceId,
+		},
+		{
+			name: "corrupt copy",
+			modify: corruptPage0,
+			copies: []testDoublewriteCopy{{pageNo: 0, spaceId: testDoublewriteSpaceId, lsn: 20, corrupt: true}},
+			wantSpaceId: testDoublewriteSpaceId,
+			wantPage0Status: DoublewriteUnrecoverable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := newTestDblwrFile(t, tt.copies)
+			defer source.Close()
+			copies, err := source.ReadDoublewritePages()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			file := NewFileFromSource(NewBytesSource("test.ibd", newTestDoublewriteTargetData(tt.modify)))
+			defer file.Close()
+
+			spaceId, logicalPageSize, physicalPageSize, err := file.getDoublewriteSpaceId(copies)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if spaceId != tt.wantSpaceId {
+				t.Fatalf("space id = %d, want %d", spaceId, tt.wantSpaceId)
+			}
+			if logicalPageSize != tt.wantPageSize || physicalPageSize != tt.wantPageSize {
+				t.Fatalf("page size = %d/%d, want %d", logicalPageSize, physicalPageSize, tt.wantPageSize)
+			}
+
+			comparisons, err := file.CompareDoublewrite(copies)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			status := ""
+			for _, comparison := range comparisons {
+				if comparison.Copy.PageNo == 0 {
+					status = comparison.Status
+				}
+			}
+			if status != tt.wantPage0Status {
+				t.Fatalf("page 0 status = %q, want %q", status, tt.wantPage0Status)
+			}
+		})
+	}
+}
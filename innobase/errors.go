@@ -0,0 +1,76 @@
+package innobase
+
+import (
+	"errors"
+	"fmt"
+)
+
+// 可以通过 errors.Is() 判断的错误类型，所有函数都用 %w 包装下层错误，
+// 所以 os 包的错误（如 fs.ErrNotExist、fs.ErrPermission）同样可以判断
+var (
+	ErrTruncatedPage = errors.New("truncated page") // 文件或页的数据不完整
+	ErrChecksumMismatch = errors.New("checksum mismatch") // 检验和错误
+	ErrTornPage = errors.New("torn page") // 页头与页尾的 Lsn 不一致
+	ErrNoTrailer = errors.New("page has no trailer") // 压缩页没有 FIL 页尾
+	ErrNotIndexPage = errors.New("not an index page") // 页类型不是 INDEX
+	ErrNotInodePage = errors.New("not an inode page") // 页类型不是 INODE
+	ErrNotTrxSysPage = errors.New("not a trx sys page") // 页类型不是 TRX_SYS
+	ErrNotRsegPage = errors.New("not a rollback segment page") // 回滚段头页的页类型不是 SYS
+	ErrNotUndoPage = errors.New("not an undo log page") // 页类型不是 UNDO_LOG
+	ErrNoDoublewrite = errors.New("doublewrite buffer not created") // TRX_SYS 页中的 doublewrite 魔数不正确
+	ErrInodeMagicMismatch = errors.New("inode magic mismatch") // 已使用的 inode 中的魔数错误
+	ErrInvalidPageNo = errors.New("invalid page no") // 页号超出表空间范围
+	ErrPageNoMismatch = errors.New("page no mismatch") // 页中存储的页号与页在文件中的位置不一致
+	ErrInvalidPageSize = errors.New("invalid page size") // 无法识别的页大小
+	ErrPageLevelMismatch = errors.New("page level mismatch") // B+ 树子节点的层级不是父节点的层级减 1，或者叶子节点链表中的页层级不为 0
+	ErrListCycle = errors.New("list cycle") // 文件链表中存在环
+	ErrListBrokenLink = errors.New("list broken link") // 文件链表节点的前后指针不一致
+	ErrListLengthMismatch = errors.New("list length mismatch") // 文件链表的节点数量与基节点记录的长度不一致
+	ErrCompressedPageUnsupported = errors.New("compressed page is not supported") // 不支持解析压缩页（ROW_FORMAT=COMPRESSED）中的记录
+	ErrNoDiskLayout = errors.New("source has no disk layout") // 数据来源不是磁盘上的普通文件（如 gzip、tar、内存），无法读取空洞信息
+	ErrNoSdi = errors.New("tablespace has no SDI") // 表空间中没有 SDI（MySQL 8.0 之前的版本，或者 FSP_FLAGS 中没有 SDI 标志）
+	ErrNoDictionary = errors.New("tablespace has no InnoDB data dictionary") // 系统表空间中没有 5.7 格式的数据字典（第 7 页不是数据字典头，或者不是系统表空间），或者 mysql.ibd 的 SDI 中没有数据字典表
+	ErrNoRollbackSegments = errors.New("tablespace has no rollback segment array") // 表空间中没有回滚段槽数组：既不是有 TRX_SYS 页的系统表空间，也不是有 RSEG_ARRAY 页的 8.0 undo 表空间
+	ErrEmptyPath = errors.New("path is empty")
+	ErrEmptyFile = errors.New("file is empty")
+)
+
+// PageError 与某个页相关的错误，记录页号、字段在页中的偏移量和字段名，
+// 可以通过 errors.As() 取出
+type PageError struct {
+	PageNo uint32
+	Offset uint32 // 字段在页中的偏移量
+	Field string // 字段名，使用 InnoDB 源码中的名称，如 FIL_PAGE_OFFSET
+	Err error
+}
+
+func newPageError(pageNo uint32, offset uint32, field string, err error) *PageError {
+	return &PageError{
+		PageNo: pageNo,
+		Offset: offset,
+		Field: field,
+		Err: err,
+	}
+}
+
+func (e *PageError)Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("page %d, offset %d: %s", e.PageNo, e.Offset, e.Err)
+	}
+
+	return fmt.Sprintf("page %d, offset %d, field %s: %s", e.PageNo, e.Offset, e.Field, e.Err)
+}
+
+func (e *PageError)Unwrap() error {
+	return e.Err
+}
+
+// withField 为还没有字段名的 PageError 补充字段名
+func withField(err error, field string) error {
+	var pageErr *PageError
+	if errors.As(err, &pageErr) && pageErr.Field == "" {
+		pageErr.Field = field
+	}
+
+	return err
+}
@@ -21,10 +21,81 @@ const (
 
 const (
 	fileHeaderSize uint8 = 38
+	fileTrailerSize uint8 = 8 // 页尾（FIL trailer），压缩页没有页尾
 )
 
+// 页尾中的字段相对于页尾起始位置（页大小 - fileTrailerSize）的偏移量
 const (
-	pageSize16 uint16 = 16384
+	fileTrailerOffsetChecksum uint8 = 0 // 旧格式的检验和，4 字节
+	fileTrailerOffsetLsn uint8 = 4 // 页的最新 Lsn 的低 32 位，4 字节
+)
+
+const (
+	pageSize1 uint32 = 1024
+	pageSize2 uint32 = 2048
+	pageSize4 uint32 = 4096
+	pageSize8 uint32 = 8192
+	pageSize16 uint32 = 16384
+	pageSize32 uint32 = 32768
+	pageSize64 uint32 = 65536
+)
+
+const (
+	fspHeaderOffset uint16 = uint16(fileHeaderSize) // FSP 头在第 0 页中的起始位置
+
+	fspOffsetSpaceId uint16 = 0 // 表空间 ID，4 字节
+	fspOffsetNotUsed uint16 = 4 // 未使用，4 字节
+	fspOffsetSize uint16 = 8 // 表空间的页数量，4 字节
+	fspOffsetFreeLimit uint16 = 12 // 尚未初始化的最小页号，4 字节
+	fspOffsetSpaceFlags uint16 = 16 // 表空间标志位，4 字节
+	fspOffsetFragNUsed uint16 = 20 // FREE_FRAG 链表中已使用的页数量，4 字节
+	fspOffsetFree uint16 = 24 // 空闲区链表的基节点，16 字节
+	fspOffsetFreeFrag uint16 = 40 // 有空闲页的碎片区链表的基节点，16 字节
+	fspOffsetFullFrag uint16 = 56 // 没有空闲页的碎片区链表的基节点，16 字节
+	fspOffsetSegId uint16 = 72 // 下一个段的 ID，8 字节
+	fspOffsetSegInodesFull uint16 = 80 // 没有空闲 inode 的 INODE 页链表的基节点，16 字节
+	fspOffsetSegInodesFree uint16 = 96 // 有空闲 inode 的 INODE 页链表的基节点，16 字节
+
+	fspHeaderSize uint16 = 112
+)
+
+const (
+	fspFlagsPostAntelopeWidth uint32 = 1 // 是否为 Antelope 之后的行格式，1 位
+	fspFlagsZipSsizeWidth uint32 = 4 // 压缩页大小，4 位
+	fspFlagsAtomicBlobsWidth uint32 = 1 // 是否支持 BLOB 外部存储，1 位
+	fspFlagsPageSsizeWidth uint32 = 4 // 页大小，4 位
+
+	fspFlagsPosZipSsize = fspFlagsPostAntelopeWidth
+	fspFlagsPosAtomicBlobs = fspFlagsPosZipSsize + fspFlagsZipSsizeWidth
+	fspFlagsPosPageSsize = fspFlagsPosAtomicBlobs + fspFlagsAtomicBlobsWidth
+
+	fspFlagsMaskZipSsize uint32 = (1 << fspFlagsZipSsizeWidth - 1) << fspFlagsPosZipSsize
+	fspFlagsMaskPageSsize uint32 = (1 << fspFlagsPageSsizeWidth - 1) << fspFlagsPosPageSsize
+
+	fspFlagsMaskPostAntelope uint32 = 1 // 是否为 Antelope 之后的行格式
+	fspFlagsMaskAtomicBlobs uint32 = 1 << fspFlagsPosAtomicBlobs // 是否支持 BLOB 外部存储
+	fspFlagsMaskDataDir uint32 = 1 << 10 // 是否通过 DATA DIRECTORY 指定了目录
+	fspFlagsMaskShared uint32 = 1 << 11 // 是否为通用表空间
+	fspFlagsMaskTemporary uint32 = 1 << 12 // 是否为临时表空间
+	fspFlagsMaskEncryption uint32 = 1 << 13 // 是否加密
+	fspFlagsMaskSdi uint32 = 1 << 14 // 是否包含 SDI（8.0）
+)
+
+var fspFlagsNameMap = map[uint32]string {
+	fspFlagsMaskPostAntelope: "POST_ANTELOPE",
+	fspFlagsMaskAtomicBlobs: "ATOMIC_BLOBS",
+	fspFlagsMaskDataDir: "DATA_DIR",
+	fspFlagsMaskShared: "SHARED",
+	fspFlagsMaskTemporary: "TEMPORARY",
+	fspFlagsMaskEncryption: "ENCRYPTION",
+	fspFlagsMaskSdi: "SDI",
+}
+
+const (
+	pageSsizeMin uint32 = 3 // 4K
+	pageSsizeMax uint32 = 7 // 64K
+	zipSsizeMax uint32 = 5 // 16K
+	ssizeBaseSize uint32 = 512 // ssize 为 1 时对应 1K，即 512 << 1
 )
 
 const (
@@ -57,6 +128,13 @@ const (
 	pageTypeEncrptyed uint16 = 15
 	pageTypeCompressedAndEncrypted uint16 = 16
 	pageTypeEncryptedRTree uint16 = 17
+	pageTypeSdiBlob uint16 = 18
+	pageTypeSdiZBlob uint16 = 19
+	pageTypeRsegArray uint16 = 21
+	pageTypeLobIndex uint16 = 22
+	pageTypeLobData uint16 = 23
+	pageTypeLobFirst uint16 = 24
+	pageTypeSdi uint16 = 17853
 	pageTypeRTree uint16 = 17854
 	pageTypeIndex uint16 = 17855
 )
@@ -79,21 +157,33 @@ var pageTypeMap = map[uint16]string {
 	pageTypeEncrptyed: "Encrypted Page",
 	pageTypeCompressedAndEncrypted: "Compressed And Encrypted Page",
 	pageTypeEncryptedRTree: "Encrypted RTree Page",
+	pageTypeSdiBlob: "Uncompressed SDI Blob Page",
+	pageTypeSdiZBlob: "Compressed SDI Blob Page",
+	pageTypeRsegArray: "Rollback Segment Array",
+	pageTypeLobIndex: "LOB Index Page",
+	pageTypeLobData: "LOB Data Page",
+	pageTypeLobFirst: "First LOB Page",
+	pageTypeSdi: "SDI Index Page",
 	pageTypeRTree: "RTree Page",
 	pageTypeIndex: "BTree Page",
 }
 
 type File struct {
 	path string
-	pageSize uint16
-	fileHandler *os.File
-	pageNo uint32
+	logicalPageSize uint32 // 页在内存中的大小，即 innodb_page_size
+	physicalPageSize uint32 // 页在磁盘上的大小，压缩表小于 logicalPageSize
+	pageSizeInited bool // 页大小是否已经确定
+	pageSizeFixed bool // 页大小是否由 SetPageSize() 手动指定
+	source Source // 数据来源，为空时根据 path 打开
+	sourceOwned bool // source 是否由 File 打开，由 File 打开的需要由 File 关闭
+	useMmap bool // 根据 path 打开文件时是否使用 mmap
+	pageNo uint32 // 当前页的 InnoDB 页号，从 0 开始
+	page *Page // 当前页的缓存
 }
 
 func NewFile(path string) *File {
 	f := &File{
-		pageSize: pageSize16,
-		pageNo: 1,
+		pageNo: 0,
 	}
 
 	// 初始化对象调用 SetPath() 的时候不会出错，所以不处理错误
@@ -102,6 +192,15 @@ func NewFile(path string) *File {
 	return f
 }
 
+// NewFileFromSource 从任意数据来源（内存、gzip 文件、tar 包成员等）读取表空间，
+// source 由调用方负责关闭
+func NewFileFromSource(source Source) *File {
+	return &File{
+		source: source,
+		pageNo: 0,
+	}
+}
+
 func (file *File)SetPath(path string) error {
 	errPrefix := "File::SetPath()"
 
@@ -110,19 +209,65 @@ func (file *File)SetPath(path string) error {
 		return nil
 	}
 
+	if err := file.resetSource(); err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
 	file.path = path
 
-	if file.fileHandler != nil {
-		err := file.fileHandler.Close()
-		if err != nil {
-			return fmt.Errorf("%s: [%s]", errPrefix, err)
-		}
-		file.fileHandler = nil
+	return nil
+}
+
+// SetSource 切换到新的数据来源，source 由调用方负责关闭
+func (file *File)SetSource(source Source) error {
+	errPrefix := "File::SetSource()"
+
+	if err := file.resetSource(); err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
 	}
+	file.path = ""
+	file.source = source
 
 	return nil
 }
 
+// SetUseMmap 根据 path 打开文件时使用 mmap 读取，读取页时不复制数据，
+// 只对之后打开的文件生效。读取的 Page 在 Close() 之后不能再使用
+func (file *File)SetUseMmap(useMmap bool) {
+	file.useMmap = useMmap
+}
+
+// Close 关闭由 File 根据 path 打开的文件，调用方传入的 source 不会被关闭
+func (file *File)Close() error {
+	errPrefix := "File::Close()"
+	if err := file.resetSource(); err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return nil
+}
+
+func (file *File)resetSource() error {
+	file.page = nil
+
+	// 手动指定的页大小对所有文件生效，否则切换文件后需要重新读取第 0 页
+	if !file.pageSizeFixed {
+		file.pageSizeInited = false
+		file.logicalPageSize = 0
+		file.physicalPageSize = 0
+	}
+
+	source := file.source
+	owned := file.sourceOwned
+	file.source = nil
+	file.sourceOwned = false
+	if source != nil && owned {
+		return source.Close()
+	}
+
+	return nil
+}
+
+// SetPageNo 设置当前页，pageNo 为 InnoDB 页号（从 0 开始）
 func (file *File)SetPageNo(pageNo uint32) error {
 	errPrefix := "File::SetPageNo"
 	if err := file.CheckPageNo(pageNo, errPrefix); err != nil {
@@ -134,29 +279,197 @@ func (file *File)SetPageNo(pageNo uint32) error {
 	return nil
 }
 
+// SetPageSize 手动指定页大小，用于第 0 页损坏、无法从 FSP_SPACE_FLAGS 中识别页大小的文件，
+// physicalPageSize 为 0 时表示非压缩表，与 logicalPageSize 相同
+func (file *File)SetPageSize(logicalPageSize uint32, physicalPageSize uint32) error {
+	errPrefix := "File::SetPageSize()"
+
+	if physicalPageSize == 0 {
+		physicalPageSize = logicalPageSize
+	}
+
+	if err := checkPageSize(logicalPageSize, physicalPageSize); err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	file.setPageSize(logicalPageSize, physicalPageSize)
+	file.pageSizeFixed = true
+
+	return nil
+}
+
+// setPageSize 修改页大小，同时清空 GetPage() 的缓存，缓存中的页是按之前的页大小读取的
+func (file *File)setPageSize(logicalPageSize uint32, physicalPageSize uint32) {
+	file.page = nil
+	file.logicalPageSize = logicalPageSize
+	file.physicalPageSize = physicalPageSize
+	file.pageSizeInited = true
+}
+
+// GetLogicalPageSize 读取页在内存中的大小（innodb_page_size）
+func (file *File)GetLogicalPageSize() (uint32, error) {
+	errPrefix := "File::GetLogicalPageSize()"
+	if err := file.initPageSize(); err != nil {
+		return 0, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return file.logicalPageSize, nil
+}
+
+// GetPhysicalPageSize 读取页在磁盘上的大小，压缩表（ROW_FORMAT=COMPRESSED）小于逻辑页大小
+func (file *File)GetPhysicalPageSize() (uint32, error) {
+	errPrefix := "File::GetPhysicalPageSize()"
+	if err := file.initPageSize(); err != nil {
+		return 0, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return file.physicalPageSize, nil
+}
+
+func (file *File)IsCompressed() (bool, error) {
+	errPrefix := "File::IsCompressed()"
+	if err := file.initPageSize(); err != nil {
+		return false, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return file.physicalPageSize < file.logicalPageSize, nil
+}
+
+// initPageSize 从第 0 页的 FSP_SPACE_FLAGS 中解析逻辑页大小和物理页大小，
+// FSP 头在第 0 页中的位置与页大小无关，所以可以在确定页大小之前读取
+func (file *File)initPageSize() error {
+	errPrefix := "File::initPageSize()"
+	if file.pageSizeInited {
+		return nil
+	}
+
+	if err := file.initSource(); err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	buf := make([]byte, size4)
+	offset := int64(fspHeaderOffset) + int64(fspOffsetSpaceFlags)
+	if _, err := file.source.ReadAt(buf, offset); err != nil {
+		return fmt.Errorf("%s: [read space flags: %w]", errPrefix, err)
+	}
+
+	logicalPageSize, physicalPageSize, err := parseSpaceFlagsPageSize(binary.BigEndian.Uint32(buf))
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	file.setPageSize(logicalPageSize, physicalPageSize)
+
+	return nil
+}
+
+// parseSpaceFlagsPageSize 根据 FSP_SPACE_FLAGS 计算逻辑页大小和物理页大小，
+// PAGE_SSIZE 为 0 表示默认的 16K，ZIP_SSIZE 为 0 表示非压缩表
+func parseSpaceFlagsPageSize(flags uint32) (uint32, uint32, error) {
+	pageSsize := (flags & fspFlagsMaskPageSsize) >> fspFlagsPosPageSsize
+	zipSsize := (flags & fspFlagsMaskZipSsize) >> fspFlagsPosZipSsize
+
+	logicalPageSize := pageSize16
+	if pageSsize != 0 {
+		if pageSsize < pageSsizeMin || pageSsize > pageSsizeMax {
+			return 0, 0, fmt.Errorf("%w: page ssize %d in space flags 0x%x", ErrInvalidPageSize, pageSsize, flags)
+		}
+		logicalPageSize = ssizeBaseSize << pageSsize
+	}
+
+	physicalPageSize := logicalPageSize
+	if zipSsize != 0 {
+		if zipSsize > zipSsizeMax {
+			return 0, 0, fmt.Errorf("%w: zip ssize %d in space flags 0x%x", ErrInvalidPageSize, zipSsize, flags)
+		}
+		physicalPageSize = ssizeBaseSize << zipSsize
+	}
+
+	if err := checkPageSize(logicalPageSize, physicalPageSize); err != nil {
+		return 0, 0, fmt.Errorf("%w in space flags 0x%x", err, flags)
+	}
+
+	return logicalPageSize, physicalPageSize, nil
+}
+
+func checkPageSize(logicalPageSize uint32, physicalPageSize uint32) error {
+	switch logicalPageSize {
+	case pageSize4, pageSize8, pageSize16, pageSize32, pageSize64:
+	default:
+		return fmt.Errorf("%w: logical page size %d", ErrInvalidPageSize, logicalPageSize)
+	}
+
+	switch physicalPageSize {
+	case pageSize1, pageSize2, pageSize4, pageSize8, pageSize16, pageSize32, pageSize64:
+	default:
+		return fmt.Errorf("%w: physical page size %d", ErrInvalidPageSize, physicalPageSize)
+	}
+
+	if physicalPageSize > logicalPageSize {
+		return fmt.Errorf("%w: physical page size %d is larger than logical page size %d", ErrInvalidPageSize, physicalPageSize, logicalPageSize)
+	}
+
+	// 压缩页最大为 16K，32K 和 64K 的实例不支持压缩表
+	if physicalPageSize < logicalPageSize && logicalPageSize > pageSize16 {
+		return fmt.Errorf("%w: compressed page size %d is not supported with logical page size %d", ErrInvalidPageSize, physicalPageSize, logicalPageSize)
+	}
+
+	return nil
+}
+
 func (file *File)GetPath() string {
+	if file.path == "" && file.source != nil {
+		return file.source.Name()
+	}
+
 	return file.path
 }
 
+func (file *File)GetSource() (Source, error) {
+	errPrefix := "File::GetSource()"
+	if err := file.initSource(); err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return file.source, nil
+}
+
+// GetFileHandler 读取磁盘文件的句柄，只有数据来源是普通文件时才有
 func (file *File)GetFileHandler() (*os.File, error) {
 	errPrefix := "File::GetFileHandler()"
-	err := file.initFileHandler()
+	err := file.initSource()
 	if err != nil {
-		return nil, fmt.Errorf("%s: [%s]", errPrefix, err)
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
 	}
 
-	return file.fileHandler, nil
+	source, ok := file.source.(*fileSource)
+	if !ok {
+		return nil, fmt.Errorf("%s: [source %s is not a plain file]", errPrefix, file.source.Name())
+	}
+
+	return source.fileHandler, nil
 }
 
-func (file *File)initFileHandler() error {
-	errPrefix := "File::initFileHandler()"
-	if file.fileHandler == nil {
-		fp, err := os.Open(file.path)
+func (file *File)initSource() error {
+	errPrefix := "File::initSource()"
+	if file.source == nil {
+		if file.path == "" {
+			return fmt.Errorf("%s: [%w]", errPrefix, ErrEmptyPath)
+		}
+
+		var source Source
+		var err error
+		if file.useMmap && !strings.HasSuffix(file.path, ".gz") {
+			source, err = NewMmapSource(file.path)
+		} else {
+			source, err = OpenSource(file.path)
+		}
 		if err != nil {
-			return fmt.Errorf("%s: [%s]", errPrefix, err)
+			return fmt.Errorf("%s: [%w]", errPrefix, err)
 		}
 
-		file.fileHandler = fp
+		file.source = source
+		file.sourceOwned = true
 	}
 
 	return nil
@@ -165,53 +478,66 @@ func (file *File)initFileHandler() error {
 func (file *File)getPageCount() (uint32, error) {
 	errPrefix := "File::getPageCount()"
 
-	_, err := file.isReadable()
-	if err != nil {
-		return 0, fmt.Errorf("%s: [%s]", errPrefix, err)
+	if err := file.initSource(); err != nil {
+		return 0, fmt.Errorf("%s: [%w]", errPrefix, err)
 	}
 
-	fileInfo, err := os.Stat(file.path)
-	if err != nil {
-		return 0, fmt.Errorf("%s: [%s]", errPrefix, err)
+	size := file.source.Size()
+	if size <= 0 {
+		return 0, fmt.Errorf("%s: [%w]", errPrefix, ErrEmptyFile)
 	}
 
-	size := fileInfo.Size()
-	if size <= 0 {
-		return 0, fmt.Errorf("%s: [file size is zero]", errPrefix)
+	if err := file.initPageSize(); err != nil {
+		return 0, fmt.Errorf("%s: [%w]", errPrefix, err)
 	}
 
-	pageCount := uint32(size / int64(file.pageSize))
+	pageCount := uint32(size / int64(file.physicalPageSize))
 
 	return pageCount, nil
 }
 
 func (file *File)GetSpaceId() (uint32, error)  {
-	errPrefix := "TableSpace::GetSpaceId()"
-	spaceId, err := file.getUint32Header(uint16(fileOffsetSpaceId))
+	errPrefix := "File::GetSpaceId()"
+	page, err := file.GetPage()
+	if err != nil {
+		return 0, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	spaceId, err := page.GetSpaceId()
 	if err != nil {
-		return 0, fmt.Errorf("%s: [%s]", errPrefix, err)
+		return 0, fmt.Errorf("%s: [%w]", errPrefix, err)
 	}
 
 	return spaceId, nil
 }
 
 func (file *File)GetPageNo() (uint32, error) {
-	errPrefix := "TableSpace::GetPageNo()"
+	errPrefix := "File::GetPageNo()"
 
-	filePageNo, err := file.getUint32Header(uint16(fileOffsetPageNo))
+	page, err := file.GetPage()
 	if err != nil {
-		return 0, fmt.Errorf("%s: [%s]", errPrefix, err)
+		return 0, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	filePageNo, err := page.GetPageNo()
+	if err != nil {
+		return 0, fmt.Errorf("%s: [%w]", errPrefix, err)
 	}
 
 	return filePageNo, nil
 }
 
 func (file *File)GetPageType() (uint16, error) {
-	errPrefix := "TableSpace::GetPageType()"
+	errPrefix := "File::GetPageType()"
 
-	pageType, err := file.getUint16Header(uint16(fileOffsetPageType))
+	page, err := file.GetPage()
 	if err != nil {
-		return 0, fmt.Errorf("%s: [%s]", errPrefix, err)
+		return 0, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	pageType, err := page.GetPageType()
+	if err != nil {
+		return 0, fmt.Errorf("%s: [%w]", errPrefix, err)
 	}
 
 	return pageType, nil
@@ -221,113 +547,97 @@ func (file *File)IsBTreePage(pageType uint16) bool {
 	return pageType == pageTypeIndex
 }
 
+// CheckPageNo 检查页号是否在表空间范围内，页号从 0 开始，最大为页数量 - 1
 func (file *File)CheckPageNo(pageNo uint32, errPrefix string) error {
-	if pageNo < 1 {
-		return fmt.Errorf("%s: [invalid page no %d]", errPrefix, pageNo)
+	pageCount, err := file.getPageCount()
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	if pageNo >= pageCount {
+		err := fmt.Errorf("%w: page count is %d", ErrInvalidPageNo, pageCount)
+		return fmt.Errorf("%s: [%w]", errPrefix, newPageError(pageNo, 0, "", err))
 	}
 
 	return nil
 }
 
-func (file *File)getUint16Header(fieldOffset uint16) (uint16, error) {
-	errPrefix := "File::getInt16Value()"
-	value, err := file.getUintHeader(fieldOffset, size2)
+// ReadPage 用一次 ReadAt 读取整个页，pageNo 为 InnoDB 页号（从 0 开始）。
+// 返回的 Page 只在 File 关闭或切换数据来源之前有效：使用 mmap 时 Page 直接引用映射区域，
+// 关闭之后再访问会导致进程崩溃，需要在关闭之后继续使用的页先用 Page.Clone() 复制。
+// 读取后检查页中存储的页号（FIL_PAGE_OFFSET），与读取位置不一致时返回 ErrPageNoMismatch，
+// 全 0 的页（未初始化）不检查
+func (file *File)ReadPage(pageNo uint32) (*Page, error) {
+	errPrefix := "File::ReadPage()"
+
+	page, err := file.ReadRawPage(pageNo)
 	if err != nil {
-		return 0, fmt.Errorf("%s: [%s]", errPrefix, err)
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
 	}
 
-	return value.(uint16), nil
-}
-
-func (file *File)getUint32Header(fieldOffset uint16) (uint32, error) {
-	errPrefix := "File::getInt32Value()"
-	value, err := file.getUintHeader(fieldOffset, size4)
-	if err != nil {
-		return 0, fmt.Errorf("%s: [%s]", errPrefix, err)
+	if err := page.CheckPageNo(); err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
 	}
 
-	return value.(uint32), nil
+	return page, nil
 }
 
-func (file *File)getUint64Header(fieldOffset uint16) (uint64, error) {
-	errPrefix := "File::getInt64Value()"
-	value, err := file.getUintHeader(fieldOffset, size8)
-	if err != nil {
-		return 0, fmt.Errorf("%s: [%s]", errPrefix, err)
-	}
+// ReadRawPage 读取页但不检查页号，用于检验和校验等需要读取错位页的场景，返回的 Page 的有效期与 ReadPage() 相同
+func (file *File)ReadRawPage(pageNo uint32) (*Page, error) {
+	errPrefix := "File::ReadRawPage()"
 
-	return value.(uint64), nil
-}
+	if err := file.initSource(); err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
 
-func (file *File)getUintHeader(fieldOffset uint16, size uint8) (interface{}, error) {
-	errPrefix := "File::getIntValue()"
-	if fieldOffset < 0 {
-		return 0, fmt.Errorf("%s: [invalid field offset %d]", errPrefix, fieldOffset)
+	if err := file.initPageSize(); err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
 	}
-	if size <= 0 {
-		return 0, fmt.Errorf("%s: [invalid size %d]", errPrefix, size)
-	}
-
-	if err := file.initFileHandler(); err != nil {
-		return 0, fmt.Errorf("%s: [%s]", errPrefix, err)
-	}
-
-	offset := int64(file.pageNo - 1) * int64(file.pageSize) + int64(fieldOffset)
-	if _, err := file.fileHandler.Seek(offset, io.SeekStart); err != nil {
-		return 0, fmt.Errorf("%s: [%s]", errPrefix, err)
-	}
-
-	var err error
-	var retValue interface{}
-	switch size {
-	case 1:
-		value := uint8(0)
-		err = binary.Read(file.fileHandler, binary.BigEndian, &value)
-		retValue = value
-	case 2:
-		value := uint16(0)
-		err = binary.Read(file.fileHandler, binary.BigEndian, &value)
-		retValue = value
-	case 4:
-		value := uint32(0)
-		err = binary.Read(file.fileHandler, binary.BigEndian, &value)
-		retValue = value
-	case 8:
-		value := uint64(0)
-		err = binary.Read(file.fileHandler, binary.BigEndian, &value)
-		retValue = value
-	default:
-		return 0, fmt.Errorf("%s: [unsupport size %d]", errPrefix, size)
+
+	offset := int64(pageNo) * int64(file.physicalPageSize)
+	if slicer, ok := file.source.(sliceSource); ok {
+		if data, ok := slicer.Slice(offset, int(file.physicalPageSize)); ok {
+			return file.newPage(pageNo, data), nil
+		}
 	}
 
-	if err != nil {
-		return 0, fmt.Errorf("%s: [%s]", errPrefix, err)
+	data := make([]byte, file.physicalPageSize)
+	n, err := file.source.ReadAt(data, offset)
+	// io.ReaderAt 在读满时也可能返回 io.EOF
+	if n < len(data) {
+		if err == io.EOF && n > 0 {
+			return nil, fmt.Errorf("%s: [%w]", errPrefix, newPageError(pageNo, uint32(n), "", ErrTruncatedPage))
+		}
+		if err == io.EOF {
+			return nil, fmt.Errorf("%s: [%w]", errPrefix, newPageError(pageNo, 0, "", ErrInvalidPageNo))
+		}
+		return nil, fmt.Errorf("%s: [read page %d: %w]", errPrefix, pageNo, err)
 	}
 
-	return retValue, nil
+	return file.newPage(pageNo, data), nil
 }
 
-func (file *File)isReadable() (bool, error) {
-	if file.path == "" {
-		return false, fmt.Errorf("getPageCount: path is empty")
-	}
+func (file *File)newPage(pageNo uint32, data []byte) *Page {
+	page := NewPage(pageNo, data)
+	page.compressed = file.physicalPageSize < file.logicalPageSize
 
-	_, err := os.Stat(file.path)
-	if err != nil {
-		return false, err
-	}
+	return page
+}
 
-	if os.IsNotExist(err) {
-		return false, err
-	}
+// GetPage 读取 SetPageNo() 指定的当前页，同一页只读取一次文件，返回的 Page 的有效期与 ReadPage() 相同
+func (file *File)GetPage() (*Page, error) {
+	errPrefix := "File::GetPage()"
 
-	if os.IsPermission(err) {
-		return false, err
+	pageNo := file.pageNo
+	if file.page != nil && file.page.GetPosition() == pageNo {
+		return file.page, nil
 	}
 
-	return true, nil
-}
+	page, err := file.ReadPage(pageNo)
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	file.page = page
 
-func (file *File)getPageHeaderAbsoluteOffset(fieldOffset uint16) int64 {
-	return int64(file.pageNo - 1) * int64(file.pageSize) + int64(fileHeaderSize) + int64(fieldOffset)
+	return page, nil
 }
\ No newline at end of file
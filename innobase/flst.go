@@ -0,0 +1,234 @@
+package innobase
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	fileNull uint32 = 0xFFFFFFFF // FIL_NULL，空的页号
+
+	fileAddressSize uint16 = 6 // 文件地址，页号 4 字节 + 页内偏移量 2 字节
+	flstBaseNodeSize uint16 = 16 // 链表基节点，长度 4 字节 + 首节点地址 6 字节 + 尾节点地址 6 字节
+	flstNodeSize uint16 = 12 // 链表节点，上一个节点地址 6 字节 + 下一个节点地址 6 字节
+)
+
+// 链表基节点中的字段偏移量
+const (
+	flstOffsetLen uint16 = 0 // 链表长度，4 字节
+	flstOffsetFirst uint16 = 4 // 首节点地址，6 字节
+	flstOffsetLast uint16 = 10 // 尾节点地址，6 字节
+)
+
+// 链表节点中的字段偏移量
+const (
+	flstOffsetPrev uint16 = 0 // 上一个节点地址，6 字节
+	flstOffsetNext uint16 = 6 // 下一个节点地址，6 字节
+)
+
+// FileAddress 表空间内的地址（fil_addr_t），页号为 FIL_NULL 表示空地址
+type FileAddress struct {
+	PageNo uint32
+	Offset uint16
+}
+
+func (addr FileAddress)IsNull() bool {
+	return addr.PageNo == fileNull
+}
+
+func (addr FileAddress)String() string {
+	if addr.IsNull() {
+		return "null"
+	}
+
+	return fmt.Sprintf("(%d, %d)", addr.PageNo, addr.Offset)
+}
+
+// ListBaseNode 文件链表的基节点（flst_base_node_t）
+type ListBaseNode struct {
+	Length uint32
+	First FileAddress
+	Last FileAddress
+}
+
+// ListNode 文件链表的节点（flst_node_t）
+type ListNode struct {
+	Prev FileAddress
+	Next FileAddress
+}
+
+func (page *Page)getFileAddress(offset uint32) (FileAddress, error) {
+	pageNo, err := page.getUint32(offset)
+	if err != nil {
+		return FileAddress{}, err
+	}
+
+	pageOffset, err := page.getUint16(offset + uint32(pageNoSize))
+	if err != nil {
+		return FileAddress{}, err
+	}
+
+	return FileAddress{
+		PageNo: pageNo,
+		Offset: pageOffset,
+	}, nil
+}
+
+func (page *Page)getListBaseNode(offset uint32) (ListBaseNode, error) {
+	length, err := page.getUint32(offset + uint32(flstOffsetLen))
+	if err != nil {
+		return ListBaseNode{}, err
+	}
+
+	first, err := page.getFileAddress(offset + uint32(flstOffsetFirst))
+	if err != nil {
+		return ListBaseNode{}, err
+	}
+
+	last, err := page.getFileAddress(offset + uint32(flstOffsetLast))
+	if err != nil {
+		return ListBaseNode{}, err
+	}
+
+	return ListBaseNode{
+		Length: length,
+		First: first,
+		Last: last,
+	}, nil
+}
+
+func (page *Page)getListNode(offset uint32) (ListNode, error) {
+	prev, err := page.getFileAddress(offset + uint32(flstOffsetPrev))
+	if err != nil {
+		return ListNode{}, err
+	}
+
+	next, err := page.getFileAddress(offset + uint32(flstOffsetNext))
+	if err != nil {
+		return ListNode{}, err
+	}
+
+	return ListNode{
+		Prev: prev,
+		Next: next,
+	}, nil
+}
+
+// ListDirection 遍历文件链表的方向
+type ListDirection uint8
+
+const (
+	ListDirectionForward ListDirection = 0 // 从 FIRST 开始沿 NEXT 遍历
+	ListDirectionBackward ListDirection = 1 // 从 LAST 开始沿 PREV 遍历
+)
+
+// ErrStopWalk 由遍历函数返回，提前结束遍历，WalkList() 不把它当作错误
+var ErrStopWalk = errors.New("stop walk")
+
+// ListVisitor 遍历文件链表时对每个节点调用，page 为节点所在的页，addr 为节点的地址，
+// 节点所属结构的其他字段可以根据 addr.Offset 从 page 中读取
+type ListVisitor func(page *Page, addr FileAddress, node ListNode) error
+
+// ReadListBaseNode 读取 addr 处的链表基节点
+func (file *File)ReadListBaseNode(addr FileAddress) (ListBaseNode, error) {
+	errPrefix := "File::ReadListBaseNode()"
+
+	page, err := file.ReadPage(addr.PageNo)
+	if err != nil {
+		return ListBaseNode{}, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	base, err := page.getListBaseNode(uint32(addr.Offset))
+	if err != nil {
+		return ListBaseNode{}, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "FLST_BASE_NODE"))
+	}
+
+	return base, nil
+}
+
+// WalkList 读取 baseAddr 处的链表基节点，并按 direction 遍历整个链表
+func (file *File)WalkList(baseAddr FileAddress, direction ListDirection, visit ListVisitor) error {
+	errPrefix := "File::WalkList()"
+
+	base, err := file.ReadListBaseNode(baseAddr)
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	if err := file.WalkListBase(base, direction, visit); err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return nil
+}
+
+// WalkListBase 从已经解析的基节点开始遍历链表，同时检查：
+// 节点地址重复出现（环）、反向指针与实际遍历顺序不一致、
+// 最后一个节点与基节点记录的不一致、节点数量与基节点记录的长度不一致
+func (file *File)WalkListBase(base ListBaseNode, direction ListDirection, visit ListVisitor) error {
+	errPrefix := "File::WalkListBase()"
+
+	addr := base.First
+	expectedEnd := base.Last
+	if direction == ListDirectionBackward {
+		addr = base.Last
+		expectedEnd = base.First
+	}
+
+	visited := map[FileAddress]bool{}
+	prevAddr := FileAddress{PageNo: fileNull}
+	count := uint32(0)
+
+	// 同一个链表的节点经常在同一个页中（如第 0 页中的区描述符），缓存最近读取的页
+	var page *Page
+	for !addr.IsNull() {
+		if visited[addr] {
+			return fmt.Errorf("%s: [%w]", errPrefix, newPageError(addr.PageNo, uint32(addr.Offset), "FLST_NODE",
+				fmt.Errorf("%w: node %s is visited twice after %d nodes", ErrListCycle, addr, count)))
+		}
+		visited[addr] = true
+
+		if page == nil || page.GetPosition() != addr.PageNo {
+			var err error
+			page, err = file.ReadPage(addr.PageNo)
+			if err != nil {
+				return fmt.Errorf("%s: [%w]", errPrefix, err)
+			}
+		}
+
+		node, err := page.getListNode(uint32(addr.Offset))
+		if err != nil {
+			return fmt.Errorf("%s: [%w]", errPrefix, withField(err, "FLST_NODE"))
+		}
+
+		back, next := node.Prev, node.Next
+		if direction == ListDirectionBackward {
+			back, next = node.Next, node.Prev
+		}
+		if back != prevAddr && !(back.IsNull() && prevAddr.IsNull()) {
+			return fmt.Errorf("%s: [%w]", errPrefix, newPageError(addr.PageNo, uint32(addr.Offset), "FLST_NODE",
+				fmt.Errorf("%w: node %s points back to %s, expected %s", ErrListBrokenLink, addr, back, prevAddr)))
+		}
+
+		count++
+		if err := visit(page, addr, node); err != nil {
+			if errors.Is(err, ErrStopWalk) {
+				return nil
+			}
+			return fmt.Errorf("%s: [%w]", errPrefix, err)
+		}
+
+		prevAddr = addr
+		addr = next
+	}
+
+	if count > 0 && prevAddr != expectedEnd {
+		return fmt.Errorf("%s: [%w: list ends at %s, base node records %s]", errPrefix, ErrListBrokenLink, prevAddr, expectedEnd)
+	}
+
+	if count != base.Length {
+		return fmt.Errorf("%s: [%w: walked %d nodes, base node records %d]", errPrefix, ErrListLengthMismatch, count, base.Length)
+	}
+
+	return nil
+}
@@ -0,0 +1,173 @@
+package innobase
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// testListNode 测试链表中的一个节点，prev、next 为空地址时写入 FIL_NULL
+type testListNode struct {
+	addr FileAddress
+	prev FileAddress
+	next FileAddress
+}
+
+func testAddr(pageNo uint32, offset uint16) FileAddress {
+	return FileAddress{PageNo: pageNo, Offset: offset}
+}
+
+var testNullAddr = FileAddress{PageNo: fileNull}
+
+func putTestFileAddress(data []byte, offset int, addr FileAddress) {
+	binary.BigEndian.PutUint32(data[offset:], addr.PageNo)
+	binary.BigEndian.PutUint16(data[offset + 4:], addr.Offset)
+}
+
+// newTestListFile 构造 4 个页的表空间，并按 nodes 写入链表节点
+func newTestListFile(nodes []testListNode) *File {
+	return newTestFile(4, func(data []byte) {
+		for _, node := range nodes {
+			offset := int(node.addr.PageNo) * testPageSize + int(node.addr.Offset)
+			putTestFileAddress(data, offset + int(flstOffsetPrev), node.prev)
+			putTestFileAddress(data, offset + int(flstOffsetNext), node.next)
+		}
+	})
+}
+
+// 三个节点的链表：(1, 100) -> (2, 200) -> (3, 300)
+var testListNodes = []testListNode{
+	{testAddr(1, 100), testNullAddr, testAddr(2, 200)},
+	{testAddr(2, 200), testAddr(1, 100), testAddr(3, 300)},
+	{testAddr(3, 300), testAddr(2, 200), testNullAddr},
+}
+
+var testListBase = ListBaseNode{Length: 3, First: testAddr(1, 100), Last: testAddr(3, 300)}
+
+func TestWalkListBase(t *testing.T) {
+	tests := []struct {
+		name string
+		nodes []testListNode
+		base ListBaseNode
+		direction ListDirection
+		stopAt int // 访问第 stopAt 个节点时返回 ErrStopWalk，0 表示不提前结束
+		wrapStop bool // 返回包装过的 ErrStopWalk
+		want []FileAddress
+		wantErr error
+	}{
+		{
+			name: "forward",
+			nodes: testListNodes,
+			base: testListBase,
+			direction: ListDirectionForward,
+			want: []FileAddress{testAddr(1, 100), testAddr(2, 200), testAddr(3, 300)},
+		},
+		{
+			name: "backward",
+			nodes: testListNodes,
+			base: testListBase,
+			direction: ListDirectionBackward,
+			want: []FileAddress{testAddr(3, 300), testAddr(2, 200), testAddr(1, 100)},
+		},
+		{
+			name: "empty",
+			base: ListBaseNode{First: testNullAddr, Last: testNullAddr},
+			direction: ListDirectionForward,
+		},
+		{
+			name: "cycle",
+			nodes: []testListNode{
+				{testAddr(1, 100), testNullAddr, testAddr(2, 200)},
+				{testAddr(2, 200), testAddr(1, 100), testAddr(1, 100)},
+			},
+			base: ListBaseNode{Length: 2, First: testAddr(1, 100), Last: testAddr(2, 200)},
+			direction: ListDirectionForward,
+			want: []FileAddress{testAddr(1, 100), testAddr(2, 200)},
+			wantErr: ErrListCycle,
+		},
+		{
+			name: "broken prev link",
+			nodes: []testListNode{
+				{testAddr(1, 100), testNullAddr, testAddr(2, 200)},
+				{testAddr(2, 200), testAddr(3, 300), testAddr(3, 300)},
+				{testAddr(3, 300), testAddr(2, 200), testNullAddr},
+			},
+			base: testListBase,
+			direction: ListDirectionForward,
+			want: []FileAddress{testAddr(1, 100)},
+			wantErr: ErrListBrokenLink,
+		},
+		{
+			name: "last node mismatch",
+			nodes: testListNodes,
+			base: ListBaseNode{Length: 3, First: testAddr(1, 100), Last: testAddr(2, 200)},
+			direction: ListDirectionForward,
+			want: []FileAddress{testAddr(1, 100), testAddr(2, 200), testAddr(3, 300)},
+			wantErr: ErrListBrokenLink,
+		},
+		{
+			name: "length mismatch",
+			nodes: testListNodes,
+			base: ListBaseNode{Length: 4, First: testAddr(1, 100), Last: testAddr(3, 300)},
+			direction: ListDirectionForward,
+			want: []FileAddress{testAddr(1, 100), testAddr(2, 200), testAddr(3, 300)},
+			wantErr: ErrListLengthMismatch,
+		},
+		{
+			name: "stop",
+			nodes: testListNodes,
+			base: testListBase,
+			direction: ListDirectionForward,
+			stopAt: 2,
+			want: []FileAddress{testAddr(1, 100), testAddr(2, 200)},
+		},
+		{
+			name: "wrapped stop",
+			nodes: testListNodes,
+			base: testListBase,
+			direction: ListDirectionBackward,
+			stopAt: 1,
+			wrapStop: true,
+			want: []FileAddress{testAddr(3, 300)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := newTestListFile(tt.nodes)
+			defer file.Close()
+
+			visited := []FileAddress{}
+			err := file.WalkListBase(tt.base, tt.direction, func(page *Page, addr FileAddress, node ListNode) error {
+				if page.GetPosition() != addr.PageNo {
+					t.Errorf("visitor got page %d for node %s", page.GetPosition(), addr)
+				}
+				visited = append(visited, addr)
+				if len(visited) == tt.stopAt {
+					if tt.wrapStop {
+						return fmt.Errorf("done: %w", ErrStopWalk)
+					}
+					return ErrStopWalk
+				}
+				return nil
+			})
+
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+
+			if len(visited) != len(tt.want) {
+				t.Fatalf("visited %v, want %v", visited, tt.want)
+			}
+			for i := range tt.want {
+				if visited[i] != tt.want[i] {
+					t.Fatalf("visited %v, want %v", visited, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package innobase
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	extentSizeBytes uint32 = 1 << 20 // 页大小不超过 16K 时，一个区为 1M
+	extentMinPages uint32 = 64 // 页大小为 32K、64K 时，一个区固定为 64 个页
+)
+
+// FSPHeader 第 0 页中的 FSP 头，描述整个表空间的空间分配情况
+type FSPHeader struct {
+	SpaceId uint32
+	Size uint32 // 表空间的页数量（FSP_SIZE）
+	FreeLimit uint32 // 尚未初始化的最小页号，大于等于该页号的区还没有加入 FREE 链表
+	SpaceFlags uint32
+	FragNUsed uint32 // FREE_FRAG 链表中的区已使用的页数量
+	Free ListBaseNode // 空闲区链表
+	FreeFrag ListBaseNode // 有空闲页的碎片区链表
+	FullFrag ListBaseNode // 没有空闲页的碎片区链表
+	NextSegmentId uint64 // 下一个段的 ID
+	SegInodesFull ListBaseNode // 没有空闲 inode 的 INODE 页链表
+	SegInodesFree ListBaseNode // 有空闲 inode 的 INODE 页链表
+}
+
+// GetFSPHeader 从第 0 页中解析 FSP 头
+func (page *Page)GetFSPHeader() (FSPHeader, error) {
+	errPrefix := "Page::GetFSPHeader()"
+
+	header := FSPHeader{}
+	base := uint32(fspHeaderOffset)
+
+	uint32Fields := []struct {
+		offset uint16
+		field string
+		value *uint32
+	}{
+		{fspOffsetSpaceId, "FSP_SPACE_ID", &header.SpaceId},
+		{fspOffsetSize, "FSP_SIZE", &header.Size},
+		{fspOffsetFreeLimit, "FSP_FREE_LIMIT", &header.FreeLimit},
+		{fspOffsetSpaceFlags, "FSP_SPACE_FLAGS", &header.SpaceFlags},
+		{fspOffsetFragNUsed, "FSP_FRAG_N_USED", &header.FragNUsed},
+	}
+	for _, f := range uint32Fields {
+		value, err := page.getUint32(base + uint32(f.offset))
+		if err != nil {
+			return header, fmt.Errorf("%s: [%w]", errPrefix, withField(err, f.field))
+		}
+		*f.value = value
+	}
+
+	listFields := []struct {
+		offset uint16
+		field string
+		value *ListBaseNode
+	}{
+		{fspOffsetFree, "FSP_FREE", &header.Free},
+		{fspOffsetFreeFrag, "FSP_FREE_FRAG", &header.FreeFrag},
+		{fspOffsetFullFrag, "FSP_FULL_FRAG", &header.FullFrag},
+		{fspOffsetSegInodesFull, "FSP_SEG_INODES_FULL", &header.SegInodesFull},
+		{fspOffsetSegInodesFree, "FSP_SEG_INODES_FREE", &header.SegInodesFree},
+	}
+	for _, f := range listFields {
+		value, err := page.getListBaseNode(base + uint32(f.offset))
+		if err != nil {
+			return header, fmt.Errorf("%s: [%w]", errPrefix, withField(err, f.field))
+		}
+		*f.value = value
+	}
+
+	segId, err := page.getUint64(base + uint32(fspOffsetSegId))
+	if err != nil {
+		return header, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "FSP_SEG_ID"))
+	}
+	header.NextSegmentId = segId
+
+	return header, nil
+}
+
+// ReadFSPHeader 读取第 0 页并解析 FSP 头
+func (file *File)ReadFSPHeader() (FSPHeader, error) {
+	errPrefix := "File::ReadFSPHeader()"
+
+	page, err := file.ReadPage(0)
+	if err != nil {
+		return FSPHeader{}, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	header, err := page.GetFSPHeader()
+	if err != nil {
+		return header, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return header, nil
+}
+
+// GetFlagNames 列出 FSP_SPACE_FLAGS 中已设置的标志位
+func (header *FSPHeader)GetFlagNames() []string {
+	masks := make([]uint32, 0, len(fspFlagsNameMap))
+	for mask := range fspFlagsNameMap {
+		masks = append(masks, mask)
+	}
+	sort.Slice(masks, func(i, j int) bool { return masks[i] < masks[j] })
+
+	names := []string{}
+	for _, mask := range masks {
+		if header.SpaceFlags & mask != 0 {
+			names = append(names, fspFlagsNameMap[mask])
+		}
+	}
+
+	return names
+}
+
+// GetUsedPages 估算已使用的页数量：FREE_LIMIT 之前的页，
+// 减去 FREE 链表中的空闲区，再减去 FREE_FRAG 链表中的区里没有使用的页，
+// 段中已分配但尚未使用的页也算作已使用
+func (header *FSPHeader)GetUsedPages(extentPages uint32) uint32 {
+	used := int64(header.FreeLimit)
+	used -= int64(header.Free.Length) * int64(extentPages)
+	used -= int64(header.FreeFrag.Length) * int64(extentPages) - int64(header.FragNUsed)
+	if used < 0 {
+		return 0
+	}
+
+	return uint32(used)
+}
+
+// getExtentPages 一个区包含的页数量，只与逻辑页大小有关
+func getExtentPages(logicalPageSize uint32) uint32 {
+	if logicalPageSize > pageSize16 {
+		return extentMinPages
+	}
+
+	return extentSizeBytes / logicalPageSize
+}
+
+// GetExtentPages 一个区包含的页数量
+func (file *File)GetExtentPages() (uint32, error) {
+	errPrefix := "File::GetExtentPages()"
+
+	logicalPageSize, err := file.GetLogicalPageSize()
+	if err != nil {
+		return 0, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return getExtentPages(logicalPageSize), nil
+}
+
+func printFSPHeader(header FSPHeader, physicalPageSize uint32, extentPages uint32) {
+	toMB := func(pages uint32) float64 {
+		return float64(pages) * float64(physicalPageSize) / float64(1 << 20)
+	}
+
+	usedPages := header.GetUsedPages(extentPages)
+	flagNames := strings.Join(header.GetFlagNames(), ",")
+
+	fmt.Println("FSP Header:")
+	fmt.Printf("    space_id: %d\n", header.SpaceId)
+	fmt.Printf("    size: %d pages\n", header.Size)
+	fmt.Printf("    free_limit: %d\n", header.FreeLimit)
+	fmt.Printf("    space_flags: 0x%x [%s]\n", header.SpaceFlags, flagNames)
+	fmt.Printf("    frag_n_used: %d\n", header.FragNUsed)
+	fmt.Printf("    next_segment_id: %d\n", header.NextSegmentId)
+	fmt.Printf("    free: %d extents, first = %s, last = %s\n", header.Free.Length, header.Free.First, header.Free.Last)
+	fmt.Printf("    free_frag: %d extents, first = %s, last = %s\n", header.FreeFrag.Length, header.FreeFrag.First, header.FreeFrag.Last)
+	fmt.Printf("    full_frag: %d extents, first = %s, last = %s\n", header.FullFrag.Length, header.FullFrag.First, header.FullFrag.Last)
+	fmt.Printf("    seg_inodes_full: %d pages, first = %s, last = %s\n", header.SegInodesFull.Length, header.SegInodesFull.First, header.SegInodesFull.Last)
+	fmt.Printf("    seg_inodes_free: %d pages, first = %s, last = %s\n", header.SegInodesFree.Length, header.SegInodesFree.First, header.SegInodesFree.Last)
+	fmt.Printf("    allocated: %d pages (%.2f MB)\n", header.Size, toMB(header.Size))
+	fmt.Printf("    used: %d pages (%.2f MB)\n", usedPages, toMB(usedPages))
+	fmt.Println()
+}
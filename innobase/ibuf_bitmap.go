@@ -0,0 +1,168 @@
+package innobase
+
+import "fmt"
+
+const (
+	ibufBitmapPageOffset uint32 = 1 // 第一个 Change Buffer 位图页的页号，之后每隔物理页大小个页一个
+	ibufBitmapOffset uint16 = 38 // 位图在页中的起始位置
+
+	ibufBitsPerPage uint32 = 4 // 每个页 4 位
+	ibufBitmapFree uint32 = 0 // 页的空闲空间等级，2 位
+	ibufBitmapBuffered uint32 = 2 // Change Buffer 中有该页的待合并操作
+	ibufBitmapIBuf uint32 = 3 // 页属于 Change Buffer 本身（只在系统表空间中出现）
+
+	ibufPageSizePerFreeSpace uint32 = 32 // 空闲空间等级的单位：页大小的 1/32
+)
+
+// IBufBitmapEntry 一个页在 Change Buffer 位图中的 4 位
+type IBufBitmapEntry struct {
+	PageNo uint32
+	FreeSpace uint8 // 空闲空间等级 0~3，等级 n 表示页中至少有 n/32 页大小的空闲空间（3 表示至少 4/32）
+	Buffered bool // 有待合并到该页的 Change Buffer 操作
+	IBuf bool // 页属于 Change Buffer 的 B+ 树或空闲链表
+}
+
+// getIBufBitmapPageNo 包含 pageNo 对应位图的位图页的页号
+func getIBufBitmapPageNo(pageNo uint32, physicalPageSize uint32) uint32 {
+	return pageNo - pageNo % physicalPageSize + ibufBitmapPageOffset
+}
+
+// getIBufBit 读取 pageNo 的第 bit 位，位图中按字节从低位到高位排列
+func (page *Page)getIBufBit(pageNo uint32, physicalPageSize uint32, bit uint32) (bool, error) {
+	bitOffset := (pageNo % physicalPageSize) * ibufBitsPerPage + bit
+	offset := uint32(ibufBitmapOffset) + bitOffset / 8
+
+	value, err := page.getUint8(offset)
+	if err != nil {
+		return false, withField(err, "IBUF_BITMAP")
+	}
+
+	return (value >> (bitOffset % 8)) & 1 == 1, nil
+}
+
+// GetIBufBitmapEntry 从位图页中读取 pageNo 的状态，pageNo 必须在该位图页覆盖的范围内
+func (page *Page)GetIBufBitmapEntry(pageNo uint32, physicalPageSize uint32) (IBufBitmapEntry, error) {
+	errPrefix := "Page::GetIBufBitmapEntry()"
+
+	if getIBufBitmapPageNo(pageNo, physicalPageSize) != page.pageNo {
+		err := fmt.Errorf("page %d is not covered by bitmap page %d", pageNo, page.pageNo)
+		return IBufBitmapEntry{}, fmt.Errorf("%s: [%w]", errPrefix, newPageError(page.pageNo, uint32(ibufBitmapOffset), "IBUF_BITMAP", err))
+	}
+
+	bits := [ibufBitsPerPage]bool{}
+	for bit := uint32(0); bit < ibufBitsPerPage; bit++ {
+		value, err := page.getIBufBit(pageNo, physicalPageSize, bit)
+		if err != nil {
+			return IBufBitmapEntry{}, fmt.Errorf("%s: [%w]", errPrefix, err)
+		}
+		bits[bit] = value
+	}
+
+	// 空闲空间等级的第一位是高位
+	entry := IBufBitmapEntry{
+		PageNo: pageNo,
+		Buffered: bits[ibufBitmapBuffered],
+		IBuf: bits[ibufBitmapIBuf],
+	}
+	if bits[ibufBitmapFree] {
+		entry.FreeSpace += 2
+	}
+	if bits[ibufBitmapFree + 1] {
+		entry.FreeSpace += 1
+	}
+
+	return entry, nil
+}
+
+// GetFreeSpaceBytes 空闲空间等级对应的最少空闲字节数，按物理页大小计算，压缩表空间中为压缩页的大小
+func (entry *IBufBitmapEntry)GetFreeSpaceBytes(physicalPageSize uint32) uint32 {
+	// 等级 3 对应 4/32 页大小，与 ibuf_index_page_calc_free_from_bits() 一致
+	units := uint32(entry.FreeSpace)
+	if units == 3 {
+		units = 4
+	}
+
+	return units * physicalPageSize / ibufPageSizePerFreeSpace
+}
+
+// ReadIBufBitmap 读取所有位图页，返回表空间中每个页的状态。
+// 还没有初始化的位图页（页类型为 0）覆盖的页全部按空闲处理
+func (file *File)ReadIBufBitmap() ([]IBufBitmapEntry, error) {
+	errPrefix := "File::ReadIBufBitmap()"
+
+	pageCount, err := file.getPageCount()
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	physicalPageSize, err := file.GetPhysicalPageSize()
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	entries := make([]IBufBitmapEntry, 0, pageCount)
+	for first := uint32(0); first < pageCount; first += physicalPageSize {
+		last := first + physicalPageSize
+		if last > pageCount || last < first {
+			last = pageCount
+		}
+
+		bitmapPageNo := first + ibufBitmapPageOffset
+		var bitmapPage *Page
+		if bitmapPageNo < pageCount {
+			page, err := file.ReadPage(bitmapPageNo)
+			if err != nil {
+				return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+			}
+
+			pageType, err := page.GetPageType()
+			if err != nil {
+				return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+			}
+
+			switch pageType {
+			case pageTypeIBufBitmap:
+				bitmapPage = page
+			case pageTypeAllocated:
+				// 位图页还没有初始化
+			default:
+				err := fmt.Errorf("expected change buffer bitmap page, found page type %d (%s)", pageType, pageTypeMap[pageType])
+				return nil, fmt.Errorf("%s: [%w]", errPrefix, newPageError(bitmapPageNo, uint32(fileOffsetPageType), "FIL_PAGE_TYPE", err))
+			}
+		}
+
+		for pageNo := first; pageNo < last; pageNo++ {
+			if bitmapPage == nil {
+				entries = append(entries, IBufBitmapEntry{PageNo: pageNo})
+				continue
+			}
+
+			entry, err := bitmapPage.GetIBufBitmapEntry(pageNo, physicalPageSize)
+			if err != nil {
+				return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+			}
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, nil
+}
+
+// ReadBufferedPages 返回 Change Buffer 中还有待合并操作的页
+func (file *File)ReadBufferedPages() ([]IBufBitmapEntry, error) {
+	errPrefix := "File::ReadBufferedPages()"
+
+	entries, err := file.ReadIBufBitmap()
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	buffered := []IBufBitmapEntry{}
+	for _, entry := range entries {
+		if entry.Buffered {
+			buffered = append(buffered, entry)
+		}
+	}
+
+	return buffered, nil
+}
@@ -0,0 +1,104 @@
+package innobase
+
+import (
+	"testing"
+)
+
+func TestGetIBufBitmapEntry(t *testing.T) {
+	// 每个页 4 位，偶数序号的页在字节的低 4 位，奇数序号的页在高 4 位，
+	// 序号 1 和 2 的页分别在第 0 个字节的末尾和第 1 个字节的开头
+	bitWants := []IBufBitmapEntry{
+		{FreeSpace: 2},
+		{FreeSpace: 1},
+		{Buffered: true},
+		{IBuf: true},
+	}
+
+	tests := []struct {
+		name string
+		physicalPageSize uint32
+		bitmapPageNo uint32
+	}{
+		{name: "16K", physicalPageSize: testPageSize, bitmapPageNo: 1},
+		{name: "16K second bitmap page", physicalPageSize: testPageSize, bitmapPageNo: testPageSize + 1},
+		{name: "compressed 8K", physicalPageSize: testZipPageSize, bitmapPageNo: 1},
+		{name: "compressed 8K second bitmap page", physicalPageSize: testZipPageSize, bitmapPageNo: testZipPageSize + 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first := tt.bitmapPageNo - ibufBitmapPageOffset
+			for _, pageIndex := range []uint32{0, 1, 2, 3, tt.physicalPageSize - 1} {
+				for bit, want := range bitWants {
+					data := make([]byte, tt.physicalPageSize)
+					bitOffset := pageIndex * ibufBitsPerPage + uint32(bit)
+					data[uint32(ibufBitmapOffset) + bitOffset / 8] = 1 << (bitOffset % 8)
+					page := NewPage(tt.bitmapPageNo, data)
+
+					entry, err := page.GetIBufBitmapEntry(first + pageIndex, tt.physicalPageSize)
+					if err != nil {
+						t.Fatalf("unexpected error: %v", err)
+					}
+					want.PageNo = first + pageIndex
+					if entry != want {
+						t.Fatalf("page index %d bit %d: entry = %+v, want %+v", pageIndex, bit, entry, want)
+					}
+
+					// 相邻的页（包括另一个字节中的页）不受影响
+					for _, neighbor := range []uint32{pageIndex - 1, pageIndex + 1} {
+						if neighbor >= tt.physicalPageSize {
+							continue
+						}
+						entry, err := page.GetIBufBitmapEntry(first + neighbor, tt.physicalPageSize)
+						if err != nil {
+							t.Fatalf("unexpected error: %v", err)
+						}
+						if entry != (IBufBitmapEntry{PageNo: first + neighbor}) {
+							t.Fatalf("page index %d bit %d: neighbor %d = %+v, want empty", pageIndex, bit, neighbor, entry)
+						}
+					}
+				}
+			}
+
+			// 所有位都设置时等级为 3
+			data := make([]byte, tt.physicalPageSize)
+			data[ibufBitmapOffset] = 0xF0
+			entry, err := NewPage(tt.bitmapPageNo, data).GetIBufBitmapEntry(first + 1, tt.physicalPageSize)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if want := (IBufBitmapEntry{PageNo: first + 1, FreeSpace: 3, Buffered: true, IBuf: true}); entry != want {
+				t.Fatalf("entry = %+v, want %+v", entry, want)
+			}
+
+			// 不在该位图页覆盖范围内的页
+			if _, err := NewPage(tt.bitmapPageNo, data).GetIBufBitmapEntry(first + tt.physicalPageSize, tt.physicalPageSize); err == nil {
+				t.Fatalf("expected an error for a page outside the bitmap page")
+			}
+		})
+	}
+}
+
+func TestGetFreeSpaceBytes(t *testing.T) {
+	tests := []struct {
+		freeSpace uint8
+		physicalPageSize uint32
+		want uint32
+	}{
+		{0, testPageSize, 0},
+		{1, testPageSize, 512},
+		{2, testPageSize, 1024},
+		{3, testPageSize, 2048},
+		{0, testZipPageSize, 0},
+		{1, testZipPageSize, 256},
+		{2, testZipPageSize, 512},
+		{3, testZipPageSize, 1024},
+	}
+
+	for _, tt := range tests {
+		entry := IBufBitmapEntry{FreeSpace: tt.freeSpace}
+		if got := entry.GetFreeSpaceBytes(tt.physicalPageSize); got != tt.want {
+			t.Fatalf("GetFreeSpaceBytes(%d) with free space %d = %d, want %d", tt.physicalPageSize, tt.freeSpace, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,445 @@
+package innobase
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+const (
+	fsegInodePageNode uint16 = 38 // INODE 页链表节点，链接到 FSP_SEG_INODES_FULL 或 FSP_SEG_INODES_FREE，12 字节
+	fsegArrOffset uint16 = 50 // 第一个 inode 在页中的偏移量
+
+	fsegOffsetId uint16 = 0 // 段 ID，为 0 表示 inode 未使用，8 字节
+	fsegOffsetNotFullNUsed uint16 = 8 // NOT_FULL 链表中的区已使用的页数量，4 字节
+	fsegOffsetFree uint16 = 12 // 段中空闲区链表的基节点，16 字节
+	fsegOffsetNotFull uint16 = 28 // 段中部分使用的区链表的基节点，16 字节
+	fsegOffsetFull uint16 = 44 // 段中已用满的区链表的基节点，16 字节
+	fsegOffsetMagicN uint16 = 60 // 魔数，4 字节
+	fsegOffsetFragArr uint16 = 64 // 碎片页数组，每个元素 4 字节，个数为区大小的一半（16K 页为 32 个）
+
+	fsegMagicN uint32 = 97937874 // FSEG_MAGIC_N_VALUE
+	fsegFragSlotSize uint32 = 4
+)
+
+// Inode INODE 页中的一个段描述符（fseg inode），记录段拥有的区链表和碎片页
+type Inode struct {
+	Address FileAddress // inode 在表空间中的地址
+	SegmentId uint64 // 段 ID，为 0 表示 inode 未使用
+	NotFullNUsed uint32 // NOT_FULL 链表中的区已使用的页数量
+	Free ListBaseNode // 段中的空闲区
+	NotFull ListBaseNode // 段中部分使用的区
+	Full ListBaseNode // 段中已用满的区
+	Magic uint32
+	FragPages []uint32 // 碎片页数组，未使用的槽为 FIL_NULL
+}
+
+// FsegHeader 段头（FSEG_HEADER），指向段的 inode，10 字节：inode 所在的表空间 ID、页号、页内偏移量
+type FsegHeader struct {
+	SpaceId uint32
+	Inode FileAddress
+}
+
+// IndexSegments 一个索引的两个段：叶子节点段和非叶子节点段
+type IndexSegments struct {
+	IndexId uint64
+	RootPageNo uint32
+	Leaf Inode // PAGE_BTR_SEG_LEAF 指向的段
+	NonLeaf Inode // PAGE_BTR_SEG_TOP 指向的段
+}
+
+// getFsegHeader 解析 offset 处的段头
+func (page *Page)getFsegHeader(offset uint32) (FsegHeader, error) {
+	spaceId, err := page.getUint32(offset)
+	if err != nil {
+		return FsegHeader{}, withField(err, "FSEG_HDR_SPACE")
+	}
+
+	inode, err := page.getFileAddress(offset + uint32(spaceIdSize))
+	if err != nil {
+		return FsegHeader{}, withField(err, "FSEG_HDR_PAGE_NO")
+	}
+
+	return FsegHeader{SpaceId: spaceId, Inode: inode}, nil
+}
+
+func getFragSlots(extentPages uint32) uint32 {
+	return extentPages / 2
+}
+
+func getInodeSize(extentPages uint32) uint32 {
+	return uint32(fsegOffsetFragArr) + getFragSlots(extentPages) * fsegFragSlotSize
+}
+
+func (inode *Inode)IsUsed() bool {
+	return inode.SegmentId != 0
+}
+
+// GetFragPages 返回碎片页数组中已使用的页号
+func (inode *Inode)GetFragPages() []uint32 {
+	pages := make([]uint32, 0, len(inode.FragPages))
+	for _, pageNo := range inode.FragPages {
+		if pageNo != fileNull {
+			pages = append(pages, pageNo)
+		}
+	}
+
+	return pages
+}
+
+// GetExtentCount 段拥有的区的数量（不含碎片页）
+func (inode *Inode)GetExtentCount() uint32 {
+	return inode.Free.Length + inode.NotFull.Length + inode.Full.Length
+}
+
+// GetAllocatedPages 分配给段的页数量：区中的所有页和碎片页
+func (inode *Inode)GetAllocatedPages(extentPages uint32) uint32 {
+	return inode.GetExtentCount() * extentPages + uint32(len(inode.GetFragPages()))
+}
+
+// GetUsedPages 段中已使用的页数量：FULL 区中的所有页、NOT_FULL 区中已使用的页和碎片页
+func (inode *Inode)GetUsedPages(extentPages uint32) uint32 {
+	return inode.Full.Length * extentPages + inode.NotFullNUsed + uint32(len(inode.GetFragPages()))
+}
+
+// getInode 解析页中 offset 处的 inode，已使用的 inode 会检查魔数
+func (page *Page)getInode(offset uint32, extentPages uint32) (Inode, error) {
+	inode := Inode{
+		Address: FileAddress{PageNo: page.pageNo, Offset: uint16(offset)},
+	}
+
+	segmentId, err := page.getUint64(offset + uint32(fsegOffsetId))
+	if err != nil {
+		return inode, withField(err, "FSEG_ID")
+	}
+	inode.SegmentId = segmentId
+
+	notFullNUsed, err := page.getUint32(offset + uint32(fsegOffsetNotFullNUsed))
+	if err != nil {
+		return inode, withField(err, "FSEG_NOT_FULL_N_USED")
+	}
+	inode.NotFullNUsed = notFullNUsed
+
+	listFields := []struct {
+		offset uint16
+		field string
+		value *ListBaseNode
+	}{
+		{fsegOffsetFree, "FSEG_FREE", &inode.Free},
+		{fsegOffsetNotFull, "FSEG_NOT_FULL", &inode.NotFull},
+		{fsegOffsetFull, "FSEG_FULL", &inode.Full},
+	}
+	for _, f := range listFields {
+		value, err := page.getListBaseNode(offset + uint32(f.offset))
+		if err != nil {
+			return inode, withField(err, f.field)
+		}
+		*f.value = value
+	}
+
+	magic, err := page.getUint32(offset + uint32(fsegOffsetMagicN))
+	if err != nil {
+		return inode, withField(err, "FSEG_MAGIC_N")
+	}
+	inode.Magic = magic
+
+	if inode.IsUsed() && magic != fsegMagicN {
+		err := fmt.Errorf("%w: magic %d, expected %d", ErrInodeMagicMismatch, magic, fsegMagicN)
+		return inode, newPageError(page.pageNo, offset + uint32(fsegOffsetMagicN), "FSEG_MAGIC_N", err)
+	}
+
+	slots := getFragSlots(extentPages)
+	inode.FragPages = make([]uint32, slots)
+	for i := uint32(0); i < slots; i++ {
+		pageNo, err := page.getUint32(offset + uint32(fsegOffsetFragArr) + i * fsegFragSlotSize)
+		if err != nil {
+			return inode, withField(err, "FSEG_FRAG_ARR")
+		}
+		inode.FragPages[i] = pageNo
+	}
+
+	return inode, nil
+}
+
+// GetInodes 解析 INODE 页中的所有 inode，包括未使用的
+func (page *Page)GetInodes(extentPages uint32) ([]Inode, error) {
+	errPrefix := "Page::GetInodes()"
+
+	inodeSize := getInodeSize(extentPages)
+	end := page.GetSize() - uint32(fileTrailerSize)
+	if end < uint32(fsegArrOffset) {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, newPageError(page.pageNo, uint32(fsegArrOffset), "FSEG_ARR", ErrTruncatedPage))
+	}
+
+	count := (end - uint32(fsegArrOffset)) / inodeSize
+	inodes := make([]Inode, 0, count)
+	for i := uint32(0); i < count; i++ {
+		inode, err := page.getInode(uint32(fsegArrOffset) + i * inodeSize, extentPages)
+		if err != nil {
+			return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+		}
+		inodes = append(inodes, inode)
+	}
+
+	return inodes, nil
+}
+
+// ReadInodePage 读取 INODE 页，页类型不是 INODE 时返回 ErrNotInodePage
+func (file *File)ReadInodePage(pageNo uint32) (*Page, error) {
+	errPrefix := "File::ReadInodePage()"
+
+	page, err := file.ReadPage(pageNo)
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	pageType, err := page.GetPageType()
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	if pageType != pageTypeInode {
+		err := fmt.Errorf("%w: page type %d (%s)", ErrNotInodePage, pageType, pageTypeMap[pageType])
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, newPageError(pageNo, uint32(fileOffsetPageType), "FIL_PAGE_TYPE", err))
+	}
+
+	return page, nil
+}
+
+// ReadInode 读取 addr 处的 inode，addr 通常来自段头（FSEG_HEADER）
+func (file *File)ReadInode(addr FileAddress) (Inode, error) {
+	errPrefix := "File::ReadInode()"
+
+	extentPages, err := file.GetExtentPages()
+	if err != nil {
+		return Inode{}, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	page, err := file.ReadInodePage(addr.PageNo)
+	if err != nil {
+		return Inode{}, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	offset := uint32(addr.Offset)
+	inodeSize := getInodeSize(extentPages)
+	if offset < uint32(fsegArrOffset) || (offset - uint32(fsegArrOffset)) % inodeSize != 0 {
+		err := fmt.Errorf("offset %d is not an inode", offset)
+		return Inode{}, fmt.Errorf("%s: [%w]", errPrefix, newPageError(addr.PageNo, offset, "FSEG_HEADER", err))
+	}
+
+	inode, err := page.getInode(offset, extentPages)
+	if err != nil {
+		return Inode{}, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return inode, nil
+}
+
+// ReadInodes 遍历 FSP_SEG_INODES_FULL 和 FSP_SEG_INODES_FREE 链表，返回表空间中所有已使用的 inode
+func (file *File)ReadInodes() ([]Inode, error) {
+	errPrefix := "File::ReadInodes()"
+
+	header, err := file.ReadFSPHeader()
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	extentPages, err := file.GetExtentPages()
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	var inodes []Inode
+	visit := func(page *Page, addr FileAddress, node ListNode) error {
+		if addr.Offset != fsegInodePageNode {
+			return newPageError(addr.PageNo, uint32(addr.Offset), "FSEG_INODE_PAGE_NODE", fmt.Errorf("unexpected list node offset"))
+		}
+
+		pageInodes, err := page.GetInodes(extentPages)
+		if err != nil {
+			return err
+		}
+		for _, inode := range pageInodes {
+			if inode.IsUsed() {
+				inodes = append(inodes, inode)
+			}
+		}
+
+		return nil
+	}
+
+	for _, base := range []ListBaseNode{header.SegInodesFull, header.SegInodesFree} {
+		if err := file.WalkListBase(base, ListDirectionForward, visit); err != nil {
+			return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+		}
+	}
+
+	sort.Slice(inodes, func(i, j int) bool {
+		return inodes[i].SegmentId < inodes[j].SegmentId
+	})
+
+	return inodes, nil
+}
+
+// ReadIndexSegments 从索引（包括 SDI 索引）的根页中读取叶子节点段和非叶子节点段
+func (file *File)ReadIndexSegments(rootPageNo uint32) (IndexSegments, error) {
+	errPrefix := "File::ReadIndexSegments()"
+
+	// SDI 索引的根页与 INDEX 页的格式相同
+	root, err := file.readBTreePage(rootPageNo, pageTypeIndex, pageTypeSdi)
+	if err != nil {
+		return IndexSegments{}, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	indexId, err := root.GetIndexId()
+	if err != nil {
+		return IndexSegments{}, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	segments := IndexSegments{
+		IndexId: indexId,
+		RootPageNo: rootPageNo,
+	}
+
+	_, leafPageNo, leafOffset, err := root.GetBtrSegLeaf()
+	if err != nil {
+		return IndexSegments{}, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	segments.Leaf, err = file.ReadInode(FileAddress{PageNo: leafPageNo, Offset: leafOffset})
+	if err != nil {
+		return IndexSegments{}, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "PAGE_BTR_SEG_LEAF"))
+	}
+
+	_, topPageNo, topOffset, err := root.GetBtrSegTop()
+	if err != nil {
+		return IndexSegments{}, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	segments.NonLeaf, err = file.ReadInode(FileAddress{PageNo: topPageNo, Offset: topOffset})
+	if err != nil {
+		return IndexSegments{}, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "PAGE_BTR_SEG_TOP"))
+	}
+
+	return segments, nil
+}
+
+// indexRootCandidate 一个索引中没有前后页、层级最高的页，层级相同时取页号较小的，
+// 与扫描顺序无关，并发扫描的结果合并后与顺序扫描相同
+type indexRootCandidate struct {
+	pageNo uint32
+	level uint16
+}
+
+func (candidate indexRootCandidate)isBetterThan(other indexRootCandidate) bool {
+	return candidate.level > other.level || (candidate.level == other.level && candidate.pageNo < other.pageNo)
+}
+
+// indexRootVisitor 扫描时记录每个索引的根页候选
+type indexRootVisitor struct {
+	candidates map[uint64]indexRootCandidate
+}
+
+func newIndexRootVisitor() *indexRootVisitor {
+	return &indexRootVisitor{
+		candidates: map[uint64]indexRootCandidate{},
+	}
+}
+
+func (visitor *indexRootVisitor)add(indexId uint64, candidate indexRootCandidate) {
+	if current, ok := visitor.candidates[indexId]; !ok || candidate.isBetterThan(current) {
+		visitor.candidates[indexId] = candidate
+	}
+}
+
+func (visitor *indexRootVisitor)visitPage(filePage *Page) error {
+	errPrefix := "indexRootVisitor::visitPage()"
+
+	pageType, err := filePage.GetPageType()
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	if pageType != pageTypeIndex {
+		return nil
+	}
+
+	prevPageNo, err := filePage.GetPrevPageNo()
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	nextPageNo, err := filePage.GetNextPageNo()
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	if prevPageNo != fileNull || nextPageNo != fileNull {
+		return nil
+	}
+
+	// Change Buffer 的根页中没有段头，段头指向第 0 页
+	page := NewBTreePage(filePage)
+	_, leafPageNo, _, err := page.GetBtrSegLeaf()
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	if leafPageNo == 0 {
+		return nil
+	}
+
+	indexId, err := page.GetIndexId()
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	level, err := page.GetPageLevel()
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	visitor.add(indexId, indexRootCandidate{pageNo: filePage.GetPosition(), level: level})
+
+	return nil
+}
+
+// FindIndexRoots 用 workers 个协程扫描表空间，查找每个索引的根页：根页没有前后页，
+// 并且是同一个索引中这类页里层级最高的（叶子节点只有一个页时，它和根页都没有前后页）。
+// 表空间有 SDI 时，SDI 索引的根页从第 0 页中读取
+func (file *File)FindIndexRoots(workers int) ([]uint32, error) {
+	errPrefix := "File::FindIndexRoots()"
+
+	visitors, err := scanPages(file, workers, func() pageVisitor {
+		return newIndexRootVisitor()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	merged := newIndexRootVisitor()
+	for _, visitor := range visitors {
+		for indexId, candidate := range visitor.(*indexRootVisitor).candidates {
+			merged.add(indexId, candidate)
+		}
+	}
+
+	roots := make([]uint32, 0, len(merged.candidates) + 1)
+	for _, candidate := range merged.candidates {
+		roots = append(roots, candidate.pageNo)
+	}
+
+	sdiRootPageNo, err := file.GetSdiRootPageNo()
+	switch {
+	case err == nil:
+		roots = append(roots, sdiRootPageNo)
+	case !errors.Is(err, ErrNoSdi):
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	sort.Slice(roots, func(i, j int) bool {
+		return roots[i] < roots[j]
+	})
+
+	return roots, nil
+}
+
+func printInode(name string, inode Inode, extentPages uint32) {
+	fmt.Printf("    %s: segment = %d, inode = %s\n", name, inode.SegmentId, inode.Address)
+	fmt.Printf("        free extents: %d, not full extents: %d (used pages %d), full extents: %d\n",
+		inode.Free.Length, inode.NotFull.Length, inode.NotFullNUsed, inode.Full.Length)
+	fmt.Printf("        fragment pages: %v\n", inode.GetFragPages())
+	fmt.Printf("        allocated pages: %d, used pages: %d\n", inode.GetAllocatedPages(extentPages), inode.GetUsedPages(extentPages))
+}
@@ -0,0 +1,82 @@
+package innobase
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+// putTestIndexPage 写入 INDEX 页的前后页、层级、索引 ID 和叶子节点段头中 inode 所在的页号
+func putTestIndexPage(data []byte, pageNo uint32, pageType uint16, indexId uint64, level uint16, prev uint32, next uint32, inodePageNo uint32) {
+	page := data[int(pageNo) * testPageSize:]
+	putTestPageType(data, pageNo, pageType)
+	binary.BigEndian.PutUint32(page[fileOffsetPagePrev:], prev)
+	binary.BigEndian.PutUint32(page[fileOffsetPageNext:], next)
+	binary.BigEndian.PutUint16(page[pageOffsetPageLevel:], level)
+	binary.BigEndian.PutUint64(page[pageOffsetIndexId:], indexId)
+	binary.BigEndian.PutUint32(page[pageOffsetSegLeaf + uint16(spaceIdSize):], inodePageNo)
+}
+
+func TestFindIndexRoots(t *testing.T) {
+	build := func(data []byte) {
+		// 索引 10：根页 5（层级 1），叶子节点 6、7；索引 11 只有一个页 8；
+		// 索引 12 有两个层级相同、没有前后页的页，取页号较小的 9
+		putTestIndexPage(data, 5, pageTypeIndex, 10, 1, fileNull, fileNull, 2)
+		putTestIndexPage(data, 6, pageTypeIndex, 10, 0, fileNull, 7, 2)
+		putTestIndexPage(data, 7, pageTypeIndex, 10, 0, 6, fileNull, 2)
+		putTestIndexPage(data, 8, pageTypeIndex, 11, 0, fileNull, fileNull, 2)
+		putTestIndexPage(data, 9, pageTypeIndex, 12, 0, fileNull, fileNull, 2)
+		putTestIndexPage(data, 10, pageTypeIndex, 12, 0, fileNull, fileNull, 2)
+		// Change Buffer 的根页，段头指向第 0 页
+		putTestIndexPage(data, 11, pageTypeIndex, 13, 0, fileNull, fileNull, 0)
+		// SDI 根页不在扫描结果中，只从第 0 页中读取
+		putTestIndexPage(data, 3, pageTypeSdi, 14, 0, fileNull, fileNull, 2)
+	}
+	withSdi := func(data []byte) {
+		build(data)
+		binary.BigEndian.PutUint32(data[fspHeaderOffset + fspOffsetSpaceFlags:], fspFlagsMaskSdi)
+		offset := getSdiOffset(testPageSize, 64)
+		binary.BigEndian.PutUint32(data[offset + sdiOffsetVersion:], sdiVersion)
+		binary.BigEndian.PutUint32(data[offset + sdiOffsetRootPageNo:], 3)
+	}
+
+	tests := []struct {
+		name string
+		build func(data []byte)
+		workers int
+		want []uint32
+	}{
+		{name: "without SDI", build: build, workers: 1, want: []uint32{5, 8, 9}},
+		{name: "with SDI", build: withSdi, workers: 1, want: []uint32{3, 5, 8, 9}},
+		{name: "with SDI and workers", build: withSdi, workers: 4, want: []uint32{3, 5, 8, 9}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := newTestFile(12, tt.build)
+			defer file.Close()
+
+			roots, err := file.FindIndexRoots(tt.workers)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(roots, tt.want) {
+				t.Fatalf("roots = %v, want %v", roots, tt.want)
+			}
+		})
+	}
+}
+
+func TestIndexRootVisitorMerge(t *testing.T) {
+	// 合并顺序不影响结果：层级高的优先，层级相同时页号小的优先
+	candidates := []indexRootCandidate{{pageNo: 20, level: 1}, {pageNo: 9, level: 0}, {pageNo: 15, level: 1}}
+	for _, order := range [][]int{{0, 1, 2}, {2, 1, 0}, {1, 2, 0}} {
+		visitor := newIndexRootVisitor()
+		for _, i := range order {
+			visitor.add(1, candidates[i])
+		}
+		if got := visitor.candidates[1]; got.pageNo != 15 {
+			t.Fatalf("order %v: root = %d, want 15", order, got.pageNo)
+		}
+	}
+}
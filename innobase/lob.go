@@ -0,0 +1,143 @@
+package innobase
+
+import (
+	"fmt"
+)
+
+// MySQL 8.0 新格式 LOB（lob::first_page_t、lob::index_entry_t、lob::data_page_t），DYNAMIC、COMPACT 表的外部存储字段使用这种格式。
+// 第一个页中依次为页头、索引项数组和数据，索引项组成文件链表，每一项记录一段数据所在的页，
+// 第一个页中放不下的索引项存储在 LOB_INDEX 页中
+const (
+	lobFirstOffset uint32 = 38 // 第一个页的页头在页中的起始位置
+
+	lobFirstOffsetDataLen uint32 = 16 // 第一个页中数据的长度，4 字节，之前为版本、标志、LOB 版本和最后修改的事务信息
+	lobFirstOffsetIndexList uint32 = 26 // 索引项链表的基节点，16 字节，之后为空闲索引项链表的基节点
+	lobFirstHeaderSize uint32 = 58 // 页头之后是索引项数组，数组之后是第一个页中的数据
+
+	lobIndexEntrySize uint32 = 60 // 索引项：前后节点地址 12、旧版本链表 16、事务信息 20、页号 4、数据长度 4、LOB 版本 4
+	lobIndexEntryOffsetPageNo uint32 = 48 // 这一段数据所在的页号，4 字节
+
+	lobDataOffset uint32 = 38 // LOB_DATA 页的页头在页中的起始位置
+	lobDataOffsetDataLen uint32 = 1 // 页中数据的长度，4 字节，之前为 1 字节的版本
+	lobDataHeaderSize uint32 = 11 // 页头之后是数据
+)
+
+// getLobFirstIndexEntries 第一个页中索引项数组的大小，由物理页大小决定（lob::first_page_t::get_n_index_entries()）
+func getLobFirstIndexEntries(physicalPageSize uint32) uint32 {
+	switch physicalPageSize {
+	case 4096:
+		return 2
+	case 8192:
+		return 5
+	case 32768:
+		return 20
+	case 65536:
+		return 40
+	}
+
+	return 10
+}
+
+// readLobField 沿第一个页中的索引项链表读取新格式 LOB 的数据。链表由 WalkListBase() 检查环和长度，
+// 所以损坏的文件不会使读取陷入死循环；length 来自磁盘，不按它预先分配内存
+func (file *File)readLobField(firstPage *Page, length uint32) ([]byte, error) {
+	errPrefix := "File::readLobField()"
+
+	firstPageNo := firstPage.GetPosition()
+	base, err := firstPage.getListBaseNode(lobFirstOffset + lobFirstOffsetIndexList)
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "OFFSET_INDEX_LIST"))
+	}
+
+	data := []byte{}
+	err = file.WalkListBase(base, ListDirectionForward, func(page *Page, addr FileAddress, node ListNode) error {
+		pageType, err := page.GetPageType()
+		if err != nil {
+			return err
+		}
+		if pageType != pageTypeLobFirst && pageType != pageTypeLobIndex {
+			err := fmt.Errorf("LOB index entry %s is in page type %d (%s)", addr, pageType, pageTypeMap[pageType])
+			return newPageError(addr.PageNo, uint32(fileOffsetPageType), "FIL_PAGE_TYPE", err)
+		}
+
+		pageNo, err := page.getUint32(uint32(addr.Offset) + lobIndexEntryOffsetPageNo)
+		if err != nil {
+			return withField(err, "LOB_INDEX_ENTRY_PAGE_NO")
+		}
+
+		var part []byte
+		if pageNo == firstPageNo {
+			part, err = file.getLobFirstPageData(firstPage)
+		} else {
+			part, err = file.getLobDataPageData(pageNo)
+		}
+		if err != nil {
+			return err
+		}
+		data = append(data, part...)
+
+		if uint32(len(data)) >= length {
+			return ErrStopWalk
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	if uint32(len(data)) < length {
+		return nil, fmt.Errorf("%s: [LOB index list ends after %d of %d bytes]", errPrefix, len(data), length)
+	}
+
+	return data[:length], nil
+}
+
+// getLobFirstPageData 第一个页中索引项数组之后的数据
+func (file *File)getLobFirstPageData(page *Page) ([]byte, error) {
+	physicalPageSize, err := file.GetPhysicalPageSize()
+	if err != nil {
+		return nil, err
+	}
+
+	dataLen, err := page.getUint32(lobFirstOffset + lobFirstOffsetDataLen)
+	if err != nil {
+		return nil, withField(err, "OFFSET_DATA_LEN")
+	}
+
+	dataOffset := lobFirstOffset + lobFirstHeaderSize + getLobFirstIndexEntries(physicalPageSize) * lobIndexEntrySize
+	data, err := page.getBytes(dataOffset, dataLen)
+	if err != nil {
+		return nil, withField(err, "LOB_PAGE_DATA")
+	}
+
+	return data, nil
+}
+
+// getLobDataPageData LOB_DATA 页中的数据
+func (file *File)getLobDataPageData(pageNo uint32) ([]byte, error) {
+	page, err := file.ReadPage(pageNo)
+	if err != nil {
+		return nil, err
+	}
+
+	pageType, err := page.GetPageType()
+	if err != nil {
+		return nil, err
+	}
+	if pageType != pageTypeLobData {
+		err := fmt.Errorf("page type %d (%s) is not a LOB data page", pageType, pageTypeMap[pageType])
+		return nil, newPageError(pageNo, uint32(fileOffsetPageType), "FIL_PAGE_TYPE", err)
+	}
+
+	dataLen, err := page.getUint32(lobDataOffset + lobDataOffsetDataLen)
+	if err != nil {
+		return nil, withField(err, "OFFSET_DATA_LEN")
+	}
+
+	data, err := page.getBytes(lobDataOffset + lobDataHeaderSize, dataLen)
+	if err != nil {
+		return nil, withField(err, "LOB_PAGE_DATA")
+	}
+
+	return data, nil
+}
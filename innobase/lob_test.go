@@ -0,0 +1,142 @@
+package innobase
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// testLobEntry LOB 索引链表中的一项，指向一段数据所在的页
+type testLobEntry struct {
+	addr FileAddress
+	prev FileAddress
+	next FileAddress
+	pageNo uint32
+}
+
+// putTestLobEntry 写入一个索引项：前后节点地址和数据所在的页号
+func putTestLobEntry(data []byte, entry testLobEntry) {
+	offset := int(entry.addr.PageNo) * testPageSize + int(entry.addr.Offset)
+	putTestFileAddress(data, offset + int(flstOffsetPrev), entry.prev)
+	putTestFileAddress(data, offset + int(flstOffsetNext), entry.next)
+	binary.BigEndian.PutUint32(data[offset + int(lobIndexEntryOffsetPageNo):], entry.pageNo)
+}
+
+// 第 1 页为 LOB 的第一个页，前两个索引项在第 1 页中，第三个在 LOB_INDEX 页（第 2 页）中，
+// 三段数据依次在第 1、3、4 页中
+var (
+	testLobFirstEntry = testAddr(1, uint16(lobFirstOffset + lobFirstHeaderSize))
+	testLobSecondEntry = testAddr(1, uint16(lobFirstOffset + lobFirstHeaderSize + lobIndexEntrySize))
+	testLobThirdEntry = testAddr(2, 39)
+	testLobData = testFill(1, 100 + 200 + 50)
+)
+
+// newTestLobFile 构造由 3 个索引项组成的 LOB，modify 在写入之后修改页中的数据
+func newTestLobFile(modify func(data []byte)) *File {
+	return newTestFile(5, func(data []byte) {
+		first := data[testPageSize:]
+		putTestPageType(data, 1, pageTypeLobFirst)
+		binary.BigEndian.PutUint32(first[lobFirstOffset + lobFirstOffsetDataLen:], 100)
+		putTestFileAddress(first, int(lobFirstOffset + lobFirstOffsetIndexList + uint32(flstOffsetFirst)), testLobFirstEntry)
+		putTestFileAddress(first, int(lobFirstOffset + lobFirstOffsetIndexList + uint32(flstOffsetLast)), testLobThirdEntry)
+		binary.BigEndian.PutUint32(first[lobFirstOffset + lobFirstOffsetIndexList + uint32(flstOffsetLen):], 3)
+		copy(first[lobFirstOffset + lobFirstHeaderSize + 10 * lobIndexEntrySize:], testLobData[:100])
+
+		putTestPageType(data, 2, pageTypeLobIndex)
+		putTestLobEntry(data, testLobEntry{testLobFirstEntry, testNullAddr, testLobSecondEntry, 1})
+		putTestLobEntry(data, testLobEntry{testLobSecondEntry, testLobFirstEntry, testLobThirdEntry, 3})
+		putTestLobEntry(data, testLobEntry{testLobThirdEntry, testLobSecondEntry, testNullAddr, 4})
+
+		for _, part := range []struct {
+			pageNo uint32
+			data []byte
+		}{{3, testLobData[100:300]}, {4, testLobData[300:]}} {
+			page := data[int(part.pageNo) * testPageSize:]
+			putTestPageType(data, part.pageNo, pageTypeLobData)
+			binary.BigEndian.PutUint32(page[lobDataOffset + lobDataOffsetDataLen:], uint32(len(part.data)))
+			copy(page[lobDataOffset + lobDataHeaderSize:], part.data)
+		}
+
+		if modify != nil {
+			modify(data)
+		}
+	})
+}
+
+func TestReadLobField(t *testing.T) {
+	tests := []struct {
+		name string
+		modify func(data []byte)
+		length uint32
+		want []byte
+		wantErr bool
+		wantErrIs error
+	}{
+		{
+			name: "whole chain",
+			length: uint32(len(testLobData)),
+			want: testLobData,
+		},
+		{
+			name: "shorter than chain",
+			length: 150,
+			want: testLobData[:150],
+		},
+		{
+			name: "longer than chain",
+			length: uint32(len(testLobData)) + 1,
+			wantErr: true,
+		},
+		{
+			name: "cycle",
+			modify: func(data []byte) {
+				putTestLobEntry(data, testLobEntry{testLobThirdEntry, testLobSecondEntry, testLobFirstEntry, 4})
+			},
+			length: 0xFFFFFFFF,
+			wantErr: true,
+			wantErrIs: ErrListCycle,
+		},
+		{
+			name: "not a LOB data page",
+			modify: func(data []byte) {
+				putTestPageType(data, 3, pageTypeBlob)
+			},
+			length: uint32(len(testLobData)),
+			wantErr: true,
+		},
+		{
+			name: "index entry outside LOB index pages",
+			modify: func(data []byte) {
+				putTestPageType(data, 2, pageTypeAllocated)
+			},
+			length: uint32(len(testLobData)),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := newTestLobFile(tt.modify)
+			defer file.Close()
+
+			// 通过 readExternalField() 按第一个页的类型选择新格式 LOB
+			data, err := file.readExternalField(newTestExternalRef(1, 1, tt.length))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %d bytes", len(data))
+				}
+				if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErrIs)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !bytes.Equal(data, tt.want) {
+				t.Fatalf("got %d bytes, want %d", len(data), len(tt.want))
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+//go:build linux
+// +build linux
+
+package innobase
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"syscall"
+)
+
+// mmapSource 把整个文件映射到内存，读取页时直接返回映射区域的切片，不复制数据。
+// 映射区域是只读的，Close() 之后从中读取的 Page 不能再使用
+type mmapSource struct {
+	path string
+	data []byte
+}
+
+// NewMmapSource 以只读方式映射文件，文件超出地址空间（32 位系统上大于 2G）、
+// 文件为空或映射失败时，退回到普通的 ReadAt 读取
+func NewMmapSource(path string) (Source, error) {
+	errPrefix := "NewMmapSource()"
+
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, ErrEmptyPath)
+	}
+
+	fp, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	defer fp.Close()
+
+	fileInfo, err := fp.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	size := fileInfo.Size()
+	if size <= 0 || size > math.MaxInt {
+		return newFallbackSource(path, errPrefix)
+	}
+
+	// 映射建立后关闭文件句柄不影响映射区域
+	data, err := syscall.Mmap(int(fp.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return newFallbackSource(path, errPrefix)
+	}
+
+	return &mmapSource{
+		path: path,
+		data: data,
+	}, nil
+}
+
+func newFallbackSource(path string, errPrefix string) (Source, error) {
+	source, err := NewFileSource(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return source, nil
+}
+
+func (source *mmapSource)ReadAt(p []byte, offset int64) (int, error) {
+	// 空文件不会使用 mmap，data 为空说明已经关闭
+	if source.data == nil {
+		return 0, fmt.Errorf("mmapSource::ReadAt(): [%s: %w]", source.path, os.ErrClosed)
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf("mmapSource::ReadAt(): [negative offset %d]", offset)
+	}
+	if offset >= int64(len(source.data)) {
+		return 0, io.EOF
+	}
+
+	n := copy(p, source.data[offset:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+
+	return n, nil
+}
+
+// Slice 返回映射区域中的一段，不复制数据
+func (source *mmapSource)Slice(offset int64, size int) ([]byte, bool) {
+	if offset < 0 || offset + int64(size) > int64(len(source.data)) {
+		return nil, false
+	}
+
+	end := offset + int64(size)
+
+	return source.data[offset:end:end], true
+}
+
+func (source *mmapSource)Name() string {
+	return source.path
+}
+
+func (source *mmapSource)Size() int64 {
+	return int64(len(source.data))
+}
+
+func (source *mmapSource)Close() error {
+	if source.data == nil {
+		return nil
+	}
+
+	data := source.data
+	source.data = nil
+
+	return syscall.Munmap(data)
+}
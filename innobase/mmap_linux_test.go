@@ -0,0 +1,149 @@
+//go:build linux
+// +build linux
+
+package innobase
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTestFile 把 data 写入临时目录中的文件，返回文件路径
+func writeTestFile(t *testing.T, name string, data []byte) string {
+	dir, err := ioutil.TempDir("", "innobase")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, data, 0640); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return path
+}
+
+func TestMmapReadPage(t *testing.T) {
+	data := newTestPagesData(5)
+	for pageNo := uint32(1); pageNo < 5; pageNo++ {
+		copy(data[pageNo * testPageSize + 100:], testFill(byte(pageNo), 200))
+	}
+	path := writeTestFile(t, "test.ibd", data)
+
+	plain := NewFile(path)
+	defer plain.Close()
+	mapped := NewFile(path)
+	mapped.SetUseMmap(true)
+	defer mapped.Close()
+
+	source, err := mapped.GetSource()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := source.(*mmapSource); !ok {
+		t.Fatalf("source is %T, want *mmapSource", source)
+	}
+
+	for pageNo := uint32(0); pageNo < 5; pageNo++ {
+		want, err := plain.ReadPage(pageNo)
+		if err != nil {
+			t.Fatalf("page %d: unexpected error: %v", pageNo, err)
+		}
+		got, err := mapped.ReadPage(pageNo)
+		if err != nil {
+			t.Fatalf("page %d: unexpected error: %v", pageNo, err)
+		}
+		if !bytes.Equal(got.GetData(), want.GetData()) {
+			t.Fatalf("page %d differs between mmap and plain reads", pageNo)
+		}
+	}
+
+	if _, err := mapped.ReadPage(5); err == nil {
+		t.Fatalf("expected an error reading past the end of the file")
+	}
+}
+
+func TestMmapSourceSlice(t *testing.T) {
+	data := testFill(0, 1000)
+	path := writeTestFile(t, "test.ibd", data)
+
+	source, err := NewMmapSource(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	mapped, ok := source.(*mmapSource)
+	if !ok {
+		t.Fatalf("source is %T, want *mmapSource", source)
+	}
+
+	tests := []struct {
+		name string
+		offset int64
+		size int
+		wantOk bool
+	}{
+		{name: "whole file", offset: 0, size: 1000, wantOk: true},
+		{name: "middle", offset: 100, size: 200, wantOk: true},
+		{name: "empty at end", offset: 1000, size: 0, wantOk: true},
+		{name: "past end", offset: 900, size: 101},
+		{name: "offset past end", offset: 1001, size: 0},
+		{name: "negative offset", offset: -1, size: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slice, ok := mapped.Slice(tt.offset, tt.size)
+			if ok != tt.wantOk {
+				t.Fatalf("ok = %t, want %t", ok, tt.wantOk)
+			}
+			if !ok {
+				return
+			}
+			if !bytes.Equal(slice, data[tt.offset:tt.offset + int64(tt.size)]) {
+				t.Fatalf("slice differs from the file data")
+			}
+			// 容量等于长度，追加数据时不会写入映射区域
+			if cap(slice) != tt.size {
+				t.Fatalf("cap = %d, want %d", cap(slice), tt.size)
+			}
+		})
+	}
+
+	if err := source.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := source.ReadAt(make([]byte, 10), 0); !errors.Is(err, os.ErrClosed) {
+		t.Fatalf("error = %v, want %v", err, os.ErrClosed)
+	}
+	if _, ok := mapped.Slice(0, 1); ok {
+		t.Fatalf("slice of a closed source should be out of range")
+	}
+}
+
+func TestNewMmapSourceFallback(t *testing.T) {
+	// 空文件不能映射，退回到普通的 ReadAt 读取
+	path := writeTestFile(t, "empty.ibd", nil)
+	source, err := NewMmapSource(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer source.Close()
+	if _, ok := source.(*fileSource); !ok {
+		t.Fatalf("source is %T, want *fileSource", source)
+	}
+	if source.Size() != 0 {
+		t.Fatalf("size = %d, want 0", source.Size())
+	}
+
+	if _, err := newFallbackSource(filepath.Join(filepath.Dir(path), "missing.ibd"), "test"); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("error = %v, want %v", err, os.ErrNotExist)
+	}
+	if _, err := NewMmapSource(" "); !errors.Is(err, ErrEmptyPath) {
+		t.Fatalf("error = %v, want %v", err, ErrEmptyPath)
+	}
+}
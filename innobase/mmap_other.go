@@ -0,0 +1,18 @@
+//go:build !linux
+// +build !linux
+
+package innobase
+
+import "fmt"
+
+// NewMmapSource 只在 Linux 上使用 mmap，其他平台使用普通的 ReadAt 读取
+func NewMmapSource(path string) (Source, error) {
+	errPrefix := "NewMmapSource()"
+
+	source, err := NewFileSource(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return source, nil
+}
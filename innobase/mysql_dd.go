@@ -0,0 +1,723 @@
+package innobase
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// dd::enum_column_types，SDI 中列的 type
+const (
+	ddTypeDecimal = 1
+	ddTypeTiny = 2
+	ddTypeShort = 3
+	ddTypeLong = 4
+	ddTypeFloat = 5
+	ddTypeDouble = 6
+	ddTypeTimestamp = 8
+	ddTypeLongLong = 9
+	ddTypeInt24 = 10
+	ddTypeDate = 11
+	ddTypeTime = 12
+	ddTypeDatetime = 13
+	ddTypeYear = 14
+	ddTypeNewDate = 15
+	ddTypeVarchar = 16
+	ddTypeBit = 17
+	ddTypeTimestamp2 = 18
+	ddTypeDatetime2 = 19
+	ddTypeTime2 = 20
+	ddTypeNewDecimal = 21
+	ddTypeEnum = 22
+	ddTypeSet = 23
+	ddTypeTinyBlob = 24
+	ddTypeMediumBlob = 25
+	ddTypeLongBlob = 26
+	ddTypeBlob = 27
+	ddTypeVarString = 28
+	ddTypeString = 29
+	ddTypeGeometry = 30
+	ddTypeJson = 31
+
+	ddColumnHiddenSe = 2 // SDI 中列的 hidden：InnoDB 内部列 DB_ROW_ID、DB_TRX_ID、DB_ROLL_PTR
+	ddIndexTypePrimary = 1
+	ddSchema = "mysql"
+	ddPartitionSeparator = "#p#" // 分区在 InnoDB 中的表名：db/table#p#partition
+	ddSubPartitionSeparator = "#sp#" // 子分区在 InnoDB 中的表名：db/table#p#partition#sp#subpartition
+
+	dictFts uint32 = 32 // SYS_INDEXES.TYPE 中的全文索引标志
+	dictSpatial uint32 = 64
+)
+
+// mysqlDdTableNames 需要读取的数据字典表
+var mysqlDdTableNames = []string{"schemata", "tablespaces", "tables", "columns", "indexes", "table_partitions", "index_partitions"}
+
+// ddDecimalDigitBytes DECIMAL 中不足 9 位的数字占用的字节数
+var ddDecimalDigitBytes = []uint32{0, 1, 1, 2, 2, 3, 3, 4, 4, 4}
+
+// mysqlDdTable mysql.ibd 中的一个数据字典表，字段的物理格式由 SDI 中的列和主键得到
+type mysqlDdTable struct {
+	name string
+	rootPageNo uint32
+	keyFields []recordField // 目录项记录中的字段：主键列和子节点的页号
+	fields []recordField // 叶子节点记录中的字段：主键列、DB_TRX_ID、DB_ROLL_PTR 和其余的列
+	columns []*SdiColumn // 与 fields 一一对应
+	positions map[string]int // 列名在 fields 中的位置
+	nNullable uint32 // 聚簇索引中可为 NULL 的字段数，决定叶子节点和目录项记录的 NULL 位图大小
+}
+
+// mysqlDdRow 数据字典表中的一行
+type mysqlDdRow struct {
+	file *File // 读取外部存储的字段
+	table *mysqlDdTable
+	page *Page
+	origin uint32
+	values []recordValue
+}
+
+// getDdFieldLength 列在 COMPACT、DYNAMIC 记录中的长度，变长字段返回 0，第二个返回值表示是否为大字段
+func getDdFieldLength(column *SdiColumn) (uint32, bool, error) {
+	if column.Hidden == ddColumnHiddenSe {
+		return column.CharLength, false, nil
+	}
+
+	fracBytes := (column.DatetimePrecision + 1) / 2
+	switch column.Type {
+	case ddTypeTiny, ddTypeYear:
+		return 1, false, nil
+	case ddTypeShort:
+		return 2, false, nil
+	case ddTypeInt24, ddTypeDate, ddTypeNewDate, ddTypeTime:
+		return 3, false, nil
+	case ddTypeLong, ddTypeFloat, ddTypeTimestamp:
+		return 4, false, nil
+	case ddTypeLongLong, ddTypeDouble, ddTypeDatetime:
+		return 8, false, nil
+	case ddTypeTime2:
+		return 3 + fracBytes, false, nil
+	case ddTypeTimestamp2:
+		return 4 + fracBytes, false, nil
+	case ddTypeDatetime2:
+		return 5 + fracBytes, false, nil
+	case ddTypeBit:
+		return (column.NumericPrecision + 7) / 8, false, nil
+	case ddTypeEnum:
+		if len(column.Elements) < 256 {
+			return 1, false, nil
+		}
+		return 2, false, nil
+	case ddTypeSet:
+		length := uint32(len(column.Elements) + 7) / 8
+		if length > 4 {
+			length = 8
+		}
+		return length, false, nil
+	case ddTypeNewDecimal:
+		intDigits := column.NumericPrecision - column.NumericScale
+		fracDigits := column.NumericScale
+		return intDigits / 9 * 4 + ddDecimalDigitBytes[intDigits % 9] + fracDigits / 9 * 4 + ddDecimalDigitBytes[fracDigits % 9], false, nil
+	case ddTypeString:
+		// 单字节字符集的 CHAR 和 BINARY 是定长字段，多字节字符集的 CHAR 按变长字段存储
+		if length, ok := getDdTypeLength(column.ColumnTypeUtf8); ok && length == column.CharLength {
+			return length, false, nil
+		}
+		return 0, column.CharLength > 255, nil
+	case ddTypeVarchar, ddTypeVarString:
+		return 0, column.CharLength > 255, nil
+	case ddTypeTinyBlob, ddTypeMediumBlob, ddTypeLongBlob, ddTypeBlob, ddTypeGeometry, ddTypeJson:
+		return 0, true, nil
+	}
+
+	return 0, false, fmt.Errorf("column %s has unsupported type %d (%s)", column.Name, column.Type, column.ColumnTypeUtf8)
+}
+
+// getDdTypeLength 从 char(32)、binary(16) 这样的类型中解析长度
+func getDdTypeLength(columnType string) (uint32, bool) {
+	start := strings.IndexByte(columnType, '(')
+	end := strings.IndexByte(columnType, ')')
+	if start < 0 || end < start {
+		return 0, false
+	}
+
+	length, err := strconv.ParseUint(columnType[start + 1:end], 10, 32)
+	if err != nil {
+		return 0, false
+	}
+
+	return uint32(length), true
+}
+
+// newMysqlDdTable 根据 SDI 中的表定义得到聚簇索引的根页和记录格式
+func newMysqlDdTable(sdiTable *SdiTable) (*mysqlDdTable, error) {
+	table := &mysqlDdTable{
+		name: sdiTable.Name,
+		positions: map[string]int{},
+	}
+
+	var primary *SdiIndex
+	for i := range sdiTable.Indexes {
+		if sdiTable.Indexes[i].Type == ddIndexTypePrimary {
+			primary = &sdiTable.Indexes[i]
+			break
+		}
+	}
+	if primary == nil {
+		return nil, fmt.Errorf("table %s has no primary key", sdiTable.Name)
+	}
+
+	root, err := strconv.ParseUint(ParseSePrivateData(primary.SePrivateData)["root"], 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("table %s: invalid se_private_data %q: %w", sdiTable.Name, primary.SePrivateData, err)
+	}
+	table.rootPageNo = uint32(root)
+
+	// 聚簇索引的元素包括所有的列，不属于主键的列为隐藏元素
+	for _, element := range primary.Elements {
+		if element.ColumnOpx < 0 || element.ColumnOpx >= len(sdiTable.Columns) {
+			return nil, fmt.Errorf("table %s: column_opx %d out of range", sdiTable.Name, element.ColumnOpx)
+		}
+		column := &sdiTable.Columns[element.ColumnOpx]
+
+		fixedLength, bigField, err := getDdFieldLength(column)
+		if err != nil {
+			return nil, fmt.Errorf("table %s: %w", sdiTable.Name, err)
+		}
+		field := recordField{
+			name: column.Name,
+			fixedLength: fixedLength,
+			nullable: column.IsNullable,
+			bigField: bigField,
+		}
+
+		if !element.Hidden {
+			table.keyFields = append(table.keyFields, field)
+		}
+		if field.nullable {
+			table.nNullable++
+		}
+		table.positions[column.Name] = len(table.fields)
+		table.fields = append(table.fields, field)
+		table.columns = append(table.columns, column)
+	}
+	table.keyFields = append(table.keyFields, recordField{name: "CHILD_PAGE_NO", fixedLength: uint32(size4)})
+
+	return table, nil
+}
+
+// walk 遍历聚簇索引中的所有行：从根页沿最左边的目录项下降到叶子节点，再沿 FIL_PAGE_NEXT 遍历叶子节点，跳过已标记删除的行
+func (table *mysqlDdTable)walk(file *File, visit func(row *mysqlDdRow) error) error {
+	decoder := btreeDecoder{
+		pageType: pageTypeIndex,
+		childPageNo: func(page BTreePage) (uint32, bool, error) {
+			records, err := page.getCompactRecords()
+			if err != nil || len(records) == 0 {
+				return 0, false, err
+			}
+
+			values, err := page.filePage.getCompactFields(records[0], table.keyFields, table.nNullable)
+			if err != nil {
+				return 0, false, err
+			}
+
+			return binary.BigEndian.Uint32(values[len(values) - 1].data), true, nil
+		},
+		visitLeaf: func(page BTreePage) error {
+			records, err := page.getCompactRecords()
+			if err != nil {
+				return err
+			}
+
+			for _, header := range records {
+				if header.isDeleted() || header.status != recordStatusOrdinary {
+					continue
+				}
+
+				values, err := page.filePage.getCompactFields(header, table.fields, table.nNullable)
+				if err != nil {
+					return err
+				}
+				row := &mysqlDdRow{file: file, table: table, page: page.filePage, origin: header.origin, values: values}
+				if err := visit(row); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	}
+
+	return file.walkBTreeLeaves(table.rootPageNo, decoder)
+}
+
+func (row *mysqlDdRow)getValue(name string) (recordValue, *SdiColumn, error) {
+	position, ok := row.table.positions[name]
+	if !ok {
+		err := fmt.Errorf("table %s has no column %s", row.table.name, name)
+		return recordValue{}, nil, newPageError(row.page.pageNo, row.origin, name, err)
+	}
+
+	return row.values[position], row.table.columns[position], nil
+}
+
+// isDdSignedType 有符号的整数列，ENUM、SET 等类型没有 unsigned 属性，但按无符号整数存储
+func isDdSignedType(column *SdiColumn) bool {
+	switch column.Type {
+	case ddTypeTiny, ddTypeShort, ddTypeInt24, ddTypeLong, ddTypeLongLong:
+		return !column.IsUnsigned
+	}
+
+	return false
+}
+
+// getUint64 读取整数列，有符号整数的符号位是反转存储的，NULL 返回 0
+func (row *mysqlDdRow)getUint64(name string) (uint64, error) {
+	value, column, err := row.getValue(name)
+	if err != nil || value.null {
+		return 0, err
+	}
+	if len(value.data) == 0 || len(value.data) > int(size8) {
+		err := fmt.Errorf("integer field is %d bytes", len(value.data))
+		return 0, newPageError(row.page.pageNo, row.origin, name, err)
+	}
+
+	result := uint64(0)
+	for _, b := range value.data {
+		result = result << 8 | uint64(b)
+	}
+	if isDdSignedType(column) {
+		bits := uint(len(value.data)) * 8
+		result ^= 1 << (bits - 1)
+		// 符号扩展
+		result = uint64(int64(result << (64 - bits)) >> (64 - bits))
+	}
+
+	return result, nil
+}
+
+// getString 读取字符串列，NULL 返回空字符串。外部存储的字段（如较长的 se_private_data）从新格式 LOB 页中读取，
+// 读取失败时返回错误，而不是把这一行当作空值忽略
+func (row *mysqlDdRow)getString(name string) (string, error) {
+	value, _, err := row.getValue(name)
+	if err != nil || value.null {
+		return "", err
+	}
+	if !value.external {
+		return string(value.data), nil
+	}
+
+	length := uint32(len(value.data))
+	if length < externalFieldRefSize {
+		err := fmt.Errorf("external field has only %d local bytes", length)
+		return "", newPageError(row.page.pageNo, row.origin, name, err)
+	}
+
+	external, err := row.file.readExternalField(value.data[length - externalFieldRefSize:])
+	if err != nil {
+		return "", fmt.Errorf("column %s: %w", name, err)
+	}
+
+	return string(value.data[:length - externalFieldRefSize]) + string(external), nil
+}
+
+// getEnum 读取 ENUM 列，存储的是从 1 开始的序号
+func (row *mysqlDdRow)getEnum(name string) (string, error) {
+	index, err := row.getUint64(name)
+	if err != nil || index == 0 {
+		return "", err
+	}
+
+	_, column, _ := row.getValue(name)
+	for _, element := range column.Elements {
+		if uint64(element.Index) == index {
+			return getDdElementName(element.Name), nil
+		}
+	}
+
+	err = fmt.Errorf("enum value %d is not defined", index)
+	return "", newPageError(row.page.pageNo, row.origin, name, err)
+}
+
+// getDdElementName SDI 中 ENUM、SET 的取值为 base64 编码
+func getDdElementName(name string) string {
+	decoded, err := base64.StdEncoding.DecodeString(name)
+	if err != nil {
+		return name
+	}
+
+	return string(decoded)
+}
+
+// readMysqlDdTables 从 SDI 中找到数据字典表的定义
+func (file *File)readMysqlDdTables() (map[string]*mysqlDdTable, error) {
+	records, err := file.ReadSdi()
+	if err != nil {
+		return nil, err
+	}
+
+	tables := map[string]*mysqlDdTable{}
+	for _, record := range records {
+		if record.Type != SdiTypeTable {
+			continue
+		}
+
+		object, err := record.GetObject()
+		if err != nil {
+			return nil, err
+		}
+		sdiTable, err := object.GetTable()
+		if err != nil {
+			return nil, err
+		}
+		if sdiTable.SchemaRef != ddSchema {
+			continue
+		}
+
+		for _, name := range mysqlDdTableNames {
+			if sdiTable.Name != name {
+				continue
+			}
+
+			table, err := newMysqlDdTable(sdiTable)
+			if err != nil {
+				return nil, err
+			}
+			tables[name] = table
+		}
+	}
+
+	for _, name := range mysqlDdTableNames {
+		if tables[name] == nil {
+			return nil, fmt.Errorf("%w: SDI has no %s.%s", ErrNoDictionary, ddSchema, name)
+		}
+	}
+
+	return tables, nil
+}
+
+// newMysqlDdIndex 根据 se_private_data 中的 id、root、space_id 创建索引，没有 InnoDB 索引 ID 时（分区表本身的索引、其他存储引擎）返回 nil
+func newMysqlDdIndex(table *DictTable, name string, indexType uint32, sePrivateData string) *DictIndex {
+	properties := ParseSePrivateData(sePrivateData)
+	id, err := strconv.ParseUint(properties["id"], 10, 64)
+	if err != nil {
+		return nil
+	}
+
+	index := &DictIndex{Table: table, Id: id, Name: name, Type: indexType}
+	if spaceId, err := strconv.ParseUint(properties["space_id"], 10, 32); err == nil {
+		index.SpaceId = uint32(spaceId)
+	}
+	if rootPageNo, err := strconv.ParseUint(properties["root"], 10, 32); err == nil {
+		index.RootPageNo = uint32(rootPageNo)
+	}
+
+	return index
+}
+
+// addMysqlDdIndex 把索引加入所属的表，表（或分区）的所有索引都在同一个表空间中，表空间 ID 取自第一个索引
+func (dictionary *DataDictionary)addMysqlDdIndex(index *DictIndex) {
+	table := index.Table
+	if len(table.Indexes) == 0 {
+		table.SpaceId = index.SpaceId
+	}
+	table.Indexes = append(table.Indexes, index)
+	dictionary.indexes[index.Id] = index
+}
+
+// getDdIndexType 把 mysql.indexes.type 转换为 SYS_INDEXES.TYPE 中的标志
+func getDdIndexType(indexType string) uint32 {
+	switch indexType {
+	case "PRIMARY":
+		return dictClustered | dictUnique
+	case "UNIQUE":
+		return dictUnique
+	case "FULLTEXT":
+		return dictFts
+	case "SPATIAL":
+		return dictSpatial
+	}
+
+	return 0
+}
+
+// ReadMysqlDictionary 读取 8.0 mysql.ibd 中的数据字典表 schemata、tablespaces、tables、columns、indexes、
+// table_partitions 和 index_partitions，表的记录格式从 mysql.ibd 的 SDI 中得到。表 ID 为 InnoDB 的表 ID（se_private_id），
+// 表名的格式为 db/table；分区表的每个分区在 InnoDB 中是一个单独的表，表名的格式为 db/table#p#partition
+func (file *File)ReadMysqlDictionary() (*DataDictionary, error) {
+	errPrefix := "File::ReadMysqlDictionary()"
+
+	ddTables, err := file.readMysqlDdTables()
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	dictionary := newDataDictionary()
+
+	schemata := map[uint64]string{}
+	err = ddTables["schemata"].walk(file, func(row *mysqlDdRow) error {
+		id, err := row.getUint64("id")
+		if err != nil {
+			return err
+		}
+		name, err := row.getString("name")
+		if err != nil {
+			return err
+		}
+		schemata[id] = name
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%s.schemata: %w]", errPrefix, ddSchema, err)
+	}
+
+	err = ddTables["tablespaces"].walk(file, func(row *mysqlDdRow) error {
+		name, err := row.getString("name")
+		if err != nil {
+			return err
+		}
+		engine, err := row.getString("engine")
+		if err != nil {
+			return err
+		}
+		sePrivateData, err := row.getString("se_private_data")
+		if err != nil {
+			return err
+		}
+
+		// 只有 InnoDB 表空间的 se_private_data 中有表空间 ID
+		spaceId, err := strconv.ParseUint(ParseSePrivateData(sePrivateData)["id"], 10, 32)
+		if err != nil {
+			return nil
+		}
+		tablespace := &DictTablespace{SpaceId: uint32(spaceId), Name: name, Engine: engine}
+		dictionary.spaces[tablespace.SpaceId] = tablespace
+		dictionary.Tablespaces = append(dictionary.Tablespaces, tablespace)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%s.tablespaces: %w]", errPrefix, ddSchema, err)
+	}
+
+	// mysql.columns、mysql.indexes、mysql.table_partitions 中的 table_id 是 mysql.tables.id，不是 InnoDB 的表 ID。
+	// 分区表本身没有 se_private_id，只用于生成分区的表名、列和索引名
+	tables := map[uint64]*DictTable{}
+	err = ddTables["tables"].walk(file, func(row *mysqlDdRow) error {
+		id, err := row.getUint64("id")
+		if err != nil {
+			return err
+		}
+		seId, err := row.getUint64("se_private_id")
+		if err != nil {
+			return err
+		}
+		schemaId, err := row.getUint64("schema_id")
+		if err != nil {
+			return err
+		}
+		name, err := row.getString("name")
+		if err != nil {
+			return err
+		}
+		rowFormat, err := row.getEnum("row_format")
+		if err != nil {
+			return err
+		}
+
+		table := &DictTable{
+			Id: seId,
+			Name: schemata[schemaId] + "/" + name,
+			Compact: rowFormat != "Redundant",
+			RowFormat: rowFormat,
+		}
+		tables[id] = table
+		if seId != 0 {
+			dictionary.tables[table.Id] = table
+			dictionary.Tables = append(dictionary.Tables, table)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%s.tables: %w]", errPrefix, ddSchema, err)
+	}
+
+	err = ddTables["columns"].walk(file, func(row *mysqlDdRow) error {
+		tableId, err := row.getUint64("table_id")
+		if err != nil {
+			return err
+		}
+		table := tables[tableId]
+		if table == nil {
+			return nil
+		}
+
+		hidden, err := row.getEnum("hidden")
+		if err != nil || hidden == "SE" {
+			return err
+		}
+
+		column := DictColumn{}
+		position, err := row.getUint64("ordinal_position")
+		if err != nil {
+			return err
+		}
+		column.Pos = uint32(position) - 1
+		if column.Name, err = row.getString("name"); err != nil {
+			return err
+		}
+		if column.TypeName, err = row.getString("column_type_utf8"); err != nil {
+			return err
+		}
+		table.Columns = append(table.Columns, column)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%s.columns: %w]", errPrefix, ddSchema, err)
+	}
+	for _, table := range tables {
+		sort.SliceStable(table.Columns, func(i, j int) bool { return table.Columns[i].Pos < table.Columns[j].Pos })
+		table.NCols = uint32(len(table.Columns))
+	}
+
+	// 分区表的索引在 mysql.indexes 中没有 InnoDB 索引 ID，分区的索引从 mysql.index_partitions 中按 index_id 查找索引名
+	ddIndexes := map[uint64]*DictIndex{}
+	err = ddTables["indexes"].walk(file, func(row *mysqlDdRow) error {
+		tableId, err := row.getUint64("table_id")
+		if err != nil {
+			return err
+		}
+		table := tables[tableId]
+		if table == nil {
+			return nil
+		}
+
+		id, err := row.getUint64("id")
+		if err != nil {
+			return err
+		}
+		name, err := row.getString("name")
+		if err != nil {
+			return err
+		}
+		indexType, err := row.getEnum("type")
+		if err != nil {
+			return err
+		}
+		ddIndexes[id] = &DictIndex{Name: name, Type: getDdIndexType(indexType)}
+
+		if table.Id == 0 {
+			return nil
+		}
+		sePrivateData, err := row.getString("se_private_data")
+		if err != nil {
+			return err
+		}
+		if index := newMysqlDdIndex(table, name, getDdIndexType(indexType), sePrivateData); index != nil {
+			dictionary.addMysqlDdIndex(index)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%s.indexes: %w]", errPrefix, ddSchema, err)
+	}
+
+	type ddPartition struct {
+		id uint64
+		tableId uint64
+		parentId uint64 // 子分区所属的分区，为 0 表示不是子分区
+		name string
+		seId uint64 // 有子分区的分区没有 se_private_id
+	}
+	partitions := []ddPartition{}
+	partitionNames := map[uint64]string{}
+	err = ddTables["table_partitions"].walk(file, func(row *mysqlDdRow) error {
+		var err error
+		partition := ddPartition{}
+		if partition.id, err = row.getUint64("id"); err != nil {
+			return err
+		}
+		if partition.tableId, err = row.getUint64("table_id"); err != nil {
+			return err
+		}
+		if partition.parentId, err = row.getUint64("parent_partition_id"); err != nil {
+			return err
+		}
+		if partition.seId, err = row.getUint64("se_private_id"); err != nil {
+			return err
+		}
+		if partition.name, err = row.getString("name"); err != nil {
+			return err
+		}
+		partitionNames[partition.id] = partition.name
+		partitions = append(partitions, partition)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%s.table_partitions: %w]", errPrefix, ddSchema, err)
+	}
+
+	partitionTables := map[uint64]*DictTable{}
+	for _, partition := range partitions {
+		table := tables[partition.tableId]
+		if table == nil || partition.seId == 0 {
+			continue
+		}
+
+		name := table.Name + ddPartitionSeparator
+		if partition.parentId != 0 {
+			name += partitionNames[partition.parentId] + ddSubPartitionSeparator
+		}
+		name += partition.name
+
+		partitionTable := &DictTable{
+			Id: partition.seId,
+			Name: name,
+			NCols: table.NCols,
+			Compact: table.Compact,
+			RowFormat: table.RowFormat,
+			Columns: append([]DictColumn(nil), table.Columns...),
+		}
+		partitionTables[partition.id] = partitionTable
+		dictionary.tables[partitionTable.Id] = partitionTable
+		dictionary.Tables = append(dictionary.Tables, partitionTable)
+	}
+
+	err = ddTables["index_partitions"].walk(file, func(row *mysqlDdRow) error {
+		partitionId, err := row.getUint64("partition_id")
+		if err != nil {
+			return err
+		}
+		table := partitionTables[partitionId]
+		if table == nil {
+			return nil
+		}
+
+		indexId, err := row.getUint64("index_id")
+		if err != nil {
+			return err
+		}
+		ddIndex := ddIndexes[indexId]
+		if ddIndex == nil {
+			err := fmt.Errorf("partition %d references unknown index %d", partitionId, indexId)
+			return newPageError(row.page.pageNo, row.origin, "index_id", err)
+		}
+
+		sePrivateData, err := row.getString("se_private_data")
+		if err != nil {
+			return err
+		}
+		if index := newMysqlDdIndex(table, ddIndex.Name, ddIndex.Type, sePrivateData); index != nil {
+			dictionary.addMysqlDdIndex(index)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%s.index_partitions: %w]", errPrefix, ddSchema, err)
+	}
+
+	sort.SliceStable(dictionary.Tables, func(i, j int) bool { return dictionary.Tables[i].Name < dictionary.Tables[j].Name })
+	sort.Slice(dictionary.Tablespaces, func(i, j int) bool { return dictionary.Tablespaces[i].SpaceId < dictionary.Tablespaces[j].SpaceId })
+
+	return dictionary, nil
+}
@@ -0,0 +1,420 @@
+package innobase
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestGetDdFieldLength(t *testing.T) {
+	tests := []struct {
+		name string
+		column string // SDI 中列的 JSON
+		wantLength uint32
+		wantBig bool
+		wantErr bool
+	}{
+		// mysql.tables 中的列
+		{
+			name: "bigint unsigned",
+			column: `{"name":"id","type":9,"is_nullable":false,"is_zerofill":false,"is_unsigned":true,"is_auto_increment":true,"hidden":1,"ordinal_position":1,"char_length":20,"numeric_precision":20,"numeric_scale":0,"datetime_precision":0,"column_type_utf8":"bigint unsigned","elements":[],"collation_id":33}`,
+			wantLength: 8,
+		},
+		{
+			name: "varchar(64) utf8mb3",
+			column: `{"name":"name","type":16,"is_nullable":false,"is_zerofill":false,"is_unsigned":false,"is_auto_increment":false,"hidden":1,"ordinal_position":3,"char_length":192,"numeric_precision":0,"numeric_scale":0,"datetime_precision":0,"column_type_utf8":"varchar(64)","elements":[],"collation_id":33}`,
+			wantLength: 0,
+		},
+		{
+			name: "enum with 3 elements",
+			column: `{"name":"type","type":22,"is_nullable":false,"is_zerofill":false,"is_unsigned":false,"is_auto_increment":false,"hidden":1,"ordinal_position":4,"char_length":33,"numeric_precision":0,"numeric_scale":0,"datetime_precision":0,"column_type_utf8":"enum('BASE TABLE','VIEW','SYSTEM VIEW')","elements":[{"name":"QkFTRSBUQUJMRQ==","index":1},{"name":"VklFVw==","index":2},{"name":"U1lTVEVNIFZJRVc=","index":3}],"collation_id":33}`,
+			wantLength: 1,
+		},
+		{
+			name: "nullable enum",
+			column: `{"name":"row_format","type":22,"is_nullable":true,"is_zerofill":false,"is_unsigned":false,"is_auto_increment":false,"hidden":1,"ordinal_position":7,"char_length":30,"numeric_precision":0,"numeric_scale":0,"datetime_precision":0,"column_type_utf8":"enum('Fixed','Dynamic','Compressed','Redundant','Compact','Paged')","elements":[{"name":"Rml4ZWQ=","index":1},{"name":"RHluYW1pYw==","index":2},{"name":"Q29tcHJlc3NlZA==","index":3},{"name":"UmVkdW5kYW50","index":4},{"name":"Q29tcGFjdA==","index":5},{"name":"UGFnZWQ=","index":6}],"collation_id":33}`,
+			wantLength: 1,
+		},
+		{
+			name: "int unsigned",
+			column: `{"name":"mysql_version_id","type":4,"is_nullable":false,"is_zerofill":false,"is_unsigned":true,"is_auto_increment":false,"hidden":1,"ordinal_position":6,"char_length":10,"numeric_precision":10,"numeric_scale":0,"datetime_precision":0,"column_type_utf8":"int unsigned","elements":[],"collation_id":33}`,
+			wantLength: 4,
+		},
+		{
+			name: "varchar(2048) utf8mb3",
+			column: `{"name":"comment","type":16,"is_nullable":false,"is_zerofill":false,"is_unsigned":false,"is_auto_increment":false,"hidden":1,"ordinal_position":9,"char_length":6144,"numeric_precision":0,"numeric_scale":0,"datetime_precision":0,"column_type_utf8":"varchar(2048)","elements":[],"collation_id":33}`,
+			wantBig: true,
+		},
+		{
+			name: "mediumtext",
+			column: `{"name":"se_private_data","type":25,"is_nullable":true,"is_zerofill":false,"is_unsigned":false,"is_auto_increment":false,"hidden":1,"ordinal_position":12,"char_length":16777215,"numeric_precision":0,"numeric_scale":0,"datetime_precision":0,"column_type_utf8":"mediumtext","elements":[],"collation_id":33}`,
+			wantBig: true,
+		},
+		{
+			name: "timestamp",
+			column: `{"name":"created","type":18,"is_nullable":false,"is_zerofill":false,"is_unsigned":false,"is_auto_increment":false,"hidden":1,"ordinal_position":22,"char_length":19,"numeric_precision":0,"numeric_scale":0,"datetime_precision":0,"column_type_utf8":"timestamp","elements":[],"collation_id":8}`,
+			wantLength: 4,
+		},
+		{
+			name: "longblob",
+			column: `{"name":"view_definition","type":26,"is_nullable":true,"is_zerofill":false,"is_unsigned":false,"is_auto_increment":false,"hidden":1,"ordinal_position":24,"char_length":4294967295,"numeric_precision":0,"numeric_scale":0,"datetime_precision":0,"column_type_utf8":"longblob","elements":[],"collation_id":63}`,
+			wantBig: true,
+		},
+		{
+			name: "varchar(288) utf8mb3",
+			column: `{"name":"view_definer","type":16,"is_nullable":true,"is_zerofill":false,"is_unsigned":false,"is_auto_increment":false,"hidden":1,"ordinal_position":30,"char_length":864,"numeric_precision":0,"numeric_scale":0,"datetime_precision":0,"column_type_utf8":"varchar(288)","elements":[],"collation_id":33}`,
+			wantBig: true,
+		},
+		{
+			name: "json",
+			column: `{"name":"engine_attribute","type":31,"is_nullable":true,"is_zerofill":false,"is_unsigned":false,"is_auto_increment":false,"hidden":1,"ordinal_position":35,"char_length":4294967295,"numeric_precision":0,"numeric_scale":0,"datetime_precision":0,"column_type_utf8":"json","elements":[],"collation_id":63}`,
+			wantBig: true,
+		},
+		{
+			name: "DB_TRX_ID",
+			column: `{"name":"DB_TRX_ID","type":10,"is_nullable":false,"is_zerofill":false,"is_unsigned":false,"is_auto_increment":false,"hidden":2,"ordinal_position":37,"char_length":6,"numeric_precision":0,"numeric_scale":0,"datetime_precision":0,"column_type_utf8":"","elements":[],"collation_id":63}`,
+			wantLength: 6,
+		},
+		{
+			name: "DB_ROLL_PTR",
+			column: `{"name":"DB_ROLL_PTR","type":9,"is_nullable":false,"is_zerofill":false,"is_unsigned":false,"is_auto_increment":false,"hidden":2,"ordinal_position":38,"char_length":7,"numeric_precision":0,"numeric_scale":0,"datetime_precision":0,"column_type_utf8":"","elements":[],"collation_id":63}`,
+			wantLength: 7,
+		},
+		// 其他类型
+		{
+			name: "decimal(10,2)",
+			column: `{"name":"price","type":21,"is_nullable":false,"is_unsigned":false,"hidden":1,"ordinal_position":1,"char_length":12,"numeric_precision":10,"numeric_scale":2,"datetime_precision":0,"column_type_utf8":"decimal(10,2)","elements":[],"collation_id":63}`,
+			wantLength: 5,
+		},
+		{
+			name: "decimal(65,30)",
+			column: `{"name":"amount","type":21,"is_nullable":false,"is_unsigned":false,"hidden":1,"ordinal_position":1,"char_length":67,"numeric_precision":65,"numeric_scale":30,"datetime_precision":0,"column_type_utf8":"decimal(65,30)","elements":[],"collation_id":63}`,
+			wantLength: 30,
+		},
+		{
+			name: "decimal(18,9)",
+			column: `{"name":"rate","type":21,"is_nullable":false,"is_unsigned":false,"hidden":1,"ordinal_position":1,"char_length":20,"numeric_precision":18,"numeric_scale":9,"datetime_precision":0,"column_type_utf8":"decimal(18,9)","elements":[],"collation_id":63}`,
+			wantLength: 8,
+		},
+		{
+			name: "set with 9 elements",
+			column: `{"name":"flags","type":23,"is_nullable":false,"is_unsigned":false,"hidden":1,"ordinal_position":1,"char_length":17,"numeric_precision":0,"numeric_scale":0,"datetime_precision":0,"column_type_utf8":"set('a','b','c','d','e','f','g','h','i')","elements":[{"name":"YQ==","index":1},{"name":"Yg==","index":2},{"name":"Yw==","index":3},{"name":"ZA==","index":4},{"name":"ZQ==","index":5},{"name":"Zg==","index":6},{"name":"Zw==","index":7},{"name":"aA==","index":8},{"name":"aQ==","index":9}],"collation_id":255}`,
+			wantLength: 2,
+		},
+		{
+			name: "char(32) latin1",
+			column: `{"name":"code","type":29,"is_nullable":false,"is_unsigned":false,"hidden":1,"ordinal_position":1,"char_length":32,"numeric_precision":0,"numeric_scale":0,"datetime_precision":0,"column_type_utf8":"char(32)","elements":[],"collation_id":8}`,
+			wantLength: 32,
+		},
+		{
+			name: "binary(16)",
+			column: `{"name":"uuid","type":29,"is_nullable":false,"is_unsigned":false,"hidden":1,"ordinal_position":1,"char_length":16,"numeric_precision":0,"numeric_scale":0,"datetime_precision":0,"column_type_utf8":"binary(16)","elements":[],"collation_id":63}`,
+			wantLength: 16,
+		},
+		{
+			name: "char(32) utf8mb4 is variable",
+			column: `{"name":"code","type":29,"is_nullable":false,"is_unsigned":false,"hidden":1,"ordinal_position":1,"char_length":128,"numeric_precision":0,"numeric_scale":0,"datetime_precision":0,"column_type_utf8":"char(32)","elements":[],"collation_id":255}`,
+			wantLength: 0,
+		},
+		{
+			name: "char(100) utf8mb4 is big",
+			column: `{"name":"code","type":29,"is_nullable":false,"is_unsigned":false,"hidden":1,"ordinal_position":1,"char_length":400,"numeric_precision":0,"numeric_scale":0,"datetime_precision":0,"column_type_utf8":"char(100)","elements":[],"collation_id":255}`,
+			wantBig: true,
+		},
+		{
+			name: "datetime(6)",
+			column: `{"name":"updated","type":19,"is_nullable":true,"is_unsigned":false,"hidden":1,"ordinal_position":1,"char_length":26,"numeric_precision":0,"numeric_scale":0,"datetime_precision":6,"column_type_utf8":"datetime(6)","elements":[],"collation_id":8}`,
+			wantLength: 8,
+		},
+		{
+			name: "time(3)",
+			column: `{"name":"elapsed","type":20,"is_nullable":true,"is_unsigned":false,"hidden":1,"ordinal_position":1,"char_length":14,"numeric_precision":0,"numeric_scale":0,"datetime_precision":3,"column_type_utf8":"time(3)","elements":[],"collation_id":8}`,
+			wantLength: 5,
+		},
+		{
+			name: "bit(10)",
+			column: `{"name":"mask","type":17,"is_nullable":false,"is_unsigned":false,"hidden":1,"ordinal_position":1,"char_length":10,"numeric_precision":10,"numeric_scale":0,"datetime_precision":0,"column_type_utf8":"bit(10)","elements":[],"collation_id":63}`,
+			wantLength: 2,
+		},
+		{
+			name: "unsupported type",
+			column: `{"name":"nothing","type":7,"is_nullable":true,"hidden":1,"ordinal_position":1,"char_length":0,"column_type_utf8":"null","elements":[],"collation_id":63}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			column := &SdiColumn{}
+			if err := json.Unmarshal([]byte(tt.column), column); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+
+			length, big, err := getDdFieldLength(column)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got (%d, %v)", length, big)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if length != tt.wantLength || big != tt.wantBig {
+				t.Fatalf("got (%d, %v), want (%d, %v)", length, big, tt.wantLength, tt.wantBig)
+			}
+		})
+	}
+}
+
+// mysql.indexes 的 SDI（8.0.22 之后的版本），只保留用到的属性；第一个索引不是主键，检查按类型查找主键
+const testMysqlIndexesSdi = `{
+	"name": "indexes",
+	"schema_ref": "mysql",
+	"hidden": 2,
+	"engine": "InnoDB",
+	"row_format": 2,
+	"se_private_id": 8,
+	"columns": [
+		{"name":"id","type":9,"is_nullable":false,"is_unsigned":true,"hidden":1,"ordinal_position":1,"char_length":20,"numeric_precision":20,"column_type_utf8":"bigint unsigned","elements":[],"collation_id":33},
+		{"name":"table_id","type":9,"is_nullable":false,"is_unsigned":true,"hidden":1,"ordinal_position":2,"char_length":20,"numeric_precision":20,"column_type_utf8":"bigint unsigned","elements":[],"collation_id":33},
+		{"name":"name","type":16,"is_nullable":false,"is_unsigned":false,"hidden":1,"ordinal_position":3,"char_length":192,"column_type_utf8":"varchar(64)","elements":[],"collation_id":33},
+		{"name":"type","type":22,"is_nullable":false,"is_unsigned":false,"hidden":1,"ordinal_position":4,"char_length":24,"column_type_utf8":"enum('PRIMARY','UNIQUE','MULTIPLE','FULLTEXT','SPATIAL')","elements":[{"name":"UFJJTUFSWQ==","index":1},{"name":"VU5JUVVF","index":2},{"name":"TVVMVElQTEU=","index":3},{"name":"RlVMTFRFWFQ=","index":4},{"name":"U1BBVElBTA==","index":5}],"collation_id":33},
+		{"name":"algorithm","type":22,"is_nullable":false,"is_unsigned":false,"hidden":1,"ordinal_position":5,"char_length":33,"column_type_utf8":"enum('SE_SPECIFIC','BTREE','RTREE','HASH','FULLTEXT')","elements":[{"name":"U0VfU1BFQ0lGSUM=","index":1},{"name":"QlRSRUU=","index":2},{"name":"UlRSRUU=","index":3},{"name":"SEFTSA==","index":4},{"name":"RlVMTFRFWFQ=","index":5}],"collation_id":33},
+		{"name":"is_algorithm_explicit","type":2,"is_nullable":false,"is_unsigned":false,"hidden":1,"ordinal_position":6,"char_length":1,"numeric_precision":3,"column_type_utf8":"tinyint(1)","elements":[],"collation_id":33},
+		{"name":"is_visible","type":2,"is_nullable":false,"is_unsigned":false,"hidden":1,"ordinal_position":7,"char_length":1,"numeric_precision":3,"column_type_utf8":"tinyint(1)","elements":[],"collation_id":33},
+		{"name":"is_generated","type":2,"is_nullable":false,"is_unsigned":false,"hidden":1,"ordinal_position":8,"char_length":1,"numeric_precision":3,"column_type_utf8":"tinyint(1)","elements":[],"collation_id":33},
+		{"name":"hidden","type":2,"is_nullable":false,"is_unsigned":false,"hidden":1,"ordinal_position":9,"char_length":1,"numeric_precision":3,"column_type_utf8":"tinyint(1)","elements":[],"collation_id":33},
+		{"name":"ordinal_position","type":4,"is_nullable":false,"is_unsigned":true,"hidden":1,"ordinal_position":10,"char_length":10,"numeric_precision":10,"column_type_utf8":"int unsigned","elements":[],"collation_id":33},
+		{"name":"comment","type":16,"is_nullable":false,"is_unsigned":false,"hidden":1,"ordinal_position":11,"char_length":6144,"column_type_utf8":"varchar(2048)","elements":[],"collation_id":33},
+		{"name":"options","type":25,"is_nullable":true,"is_unsigned":false,"hidden":1,"ordinal_position":12,"char_length":16777215,"column_type_utf8":"mediumtext","elements":[],"collation_id":33},
+		{"name":"se_private_data","type":25,"is_nullable":true,"is_unsigned":false,"hidden":1,"ordinal_position":13,"char_length":16777215,"column_type_utf8":"mediumtext","elements":[],"collation_id":33},
+		{"name":"tablespace_id","type":9,"is_nullable":true,"is_unsigned":true,"hidden":1,"ordinal_position":14,"char_length":20,"numeric_precision":20,"column_type_utf8":"bigint unsigned","elements":[],"collation_id":33},
+		{"name":"engine","type":16,"is_nullable":false,"is_unsigned":false,"hidden":1,"ordinal_position":15,"char_length":192,"column_type_utf8":"varchar(64)","elements":[],"collation_id":33},
+		{"name":"engine_attribute","type":31,"is_nullable":true,"is_unsigned":false,"hidden":1,"ordinal_position":16,"char_length":4294967295,"column_type_utf8":"json","elements":[],"collation_id":63},
+		{"name":"secondary_engine_attribute","type":31,"is_nullable":true,"is_unsigned":false,"hidden":1,"ordinal_position":17,"char_length":4294967295,"column_type_utf8":"json","elements":[],"collation_id":63},
+		{"name":"DB_TRX_ID","type":10,"is_nullable":false,"is_unsigned":false,"hidden":2,"ordinal_position":18,"char_length":6,"column_type_utf8":"","elements":[],"collation_id":63},
+		{"name":"DB_ROLL_PTR","type":9,"is_nullable":false,"is_unsigned":false,"hidden":2,"ordinal_position":19,"char_length":7,"column_type_utf8":"","elements":[],"collation_id":63}
+	],
+	"indexes": [
+		{
+			"name": "table_id",
+			"hidden": false,
+			"ordinal_position": 2,
+			"type": 2,
+			"algorithm": 2,
+			"se_private_data": "id=57;root=71;space_id=4294967294;table_id=8;trx_id=0;",
+			"elements": [
+				{"ordinal_position":1,"length":8,"order":2,"hidden":false,"column_opx":1},
+				{"ordinal_position":2,"length":192,"order":2,"hidden":false,"column_opx":2},
+				{"ordinal_position":3,"length":8,"order":2,"hidden":true,"column_opx":0}
+			]
+		},
+		{
+			"name": "PRIMARY",
+			"hidden": false,
+			"ordinal_position": 1,
+			"type": 1,
+			"algorithm": 2,
+			"se_private_data": "id=56;root=70;space_id=4294967294;table_id=8;trx_id=0;",
+			"elements": [
+				{"ordinal_position":1,"length":8,"order":2,"hidden":false,"column_opx":0},
+				{"ordinal_position":2,"length":4294967295,"order":2,"hidden":true,"column_opx":17},
+				{"ordinal_position":3,"length":4294967295,"order":2,"hidden":true,"column_opx":18},
+				{"ordinal_position":4,"length":4294967295,"order":2,"hidden":true,"column_opx":1},
+				{"ordinal_position":5,"length":4294967295,"order":2,"hidden":true,"column_opx":2},
+				{"ordinal_position":6,"length":4294967295,"order":2,"hidden":true,"column_opx":3},
+				{"ordinal_position":7,"length":4294967295,"order":2,"hidden":true,"column_opx":4},
+				{"ordinal_position":8,"length":4294967295,"order":2,"hidden":true,"column_opx":5},
+				{"ordinal_position":9,"length":4294967295,"order":2,"hidden":true,"column_opx":6},
+				{"ordinal_position":10,"length":4294967295,"order":2,"hidden":true,"column_opx":7},
+				{"ordinal_position":11,"length":4294967295,"order":2,"hidden":true,"column_opx":8},
+				{"ordinal_position":12,"length":4294967295,"order":2,"hidden":true,"column_opx":9},
+				{"ordinal_position":13,"length":4294967295,"order":2,"hidden":true,"column_opx":10},
+				{"ordinal_position":14,"length":4294967295,"order":2,"hidden":true,"column_opx":11},
+				{"ordinal_position":15,"length":4294967295,"order":2,"hidden":true,"column_opx":12},
+				{"ordinal_position":16,"length":4294967295,"order":2,"hidden":true,"column_opx":13},
+				{"ordinal_position":17,"length":4294967295,"order":2,"hidden":true,"column_opx":14},
+				{"ordinal_position":18,"length":4294967295,"order":2,"hidden":true,"column_opx":15},
+				{"ordinal_position":19,"length":4294967295,"order":2,"hidden":true,"column_opx":16}
+			]
+		}
+	]
+}`
+
+// mysql.tables 的 SDI，只保留主键和前几列，主键之后的列都是隐藏元素
+const testMysqlTablesSdi = `{
+	"name": "tables",
+	"schema_ref": "mysql",
+	"hidden": 2,
+	"engine": "InnoDB",
+	"row_format": 2,
+	"se_private_id": 29,
+	"columns": [
+		{"name":"id","type":9,"is_nullable":false,"is_unsigned":true,"hidden":1,"ordinal_position":1,"char_length":20,"numeric_precision":20,"column_type_utf8":"bigint unsigned","elements":[],"collation_id":33},
+		{"name":"schema_id","type":9,"is_nullable":false,"is_unsigned":true,"hidden":1,"ordinal_position":2,"char_length":20,"numeric_precision":20,"column_type_utf8":"bigint unsigned","elements":[],"collation_id":33},
+		{"name":"name","type":16,"is_nullable":false,"is_unsigned":false,"hidden":1,"ordinal_position":3,"char_length":192,"column_type_utf8":"varchar(64)","elements":[],"collation_id":33},
+		{"name":"type","type":22,"is_nullable":false,"is_unsigned":false,"hidden":1,"ordinal_position":4,"char_length":33,"column_type_utf8":"enum('BASE TABLE','VIEW','SYSTEM VIEW')","elements":[{"name":"QkFTRSBUQUJMRQ==","index":1},{"name":"VklFVw==","index":2},{"name":"U1lTVEVNIFZJRVc=","index":3}],"collation_id":33},
+		{"name":"row_format","type":22,"is_nullable":true,"is_unsigned":false,"hidden":1,"ordinal_position":5,"char_length":30,"column_type_utf8":"enum('Fixed','Dynamic','Compressed','Redundant','Compact','Paged')","elements":[{"name":"Rml4ZWQ=","index":1},{"name":"RHluYW1pYw==","index":2},{"name":"Q29tcHJlc3NlZA==","index":3},{"name":"UmVkdW5kYW50","index":4},{"name":"Q29tcGFjdA==","index":5},{"name":"UGFnZWQ=","index":6}],"collation_id":33},
+		{"name":"se_private_data","type":25,"is_nullable":true,"is_unsigned":false,"hidden":1,"ordinal_position":6,"char_length":16777215,"column_type_utf8":"mediumtext","elements":[],"collation_id":33},
+		{"name":"created","type":18,"is_nullable":false,"is_unsigned":false,"hidden":1,"ordinal_position":7,"char_length":19,"column_type_utf8":"timestamp","elements":[],"collation_id":8},
+		{"name":"DB_TRX_ID","type":10,"is_nullable":false,"is_unsigned":false,"hidden":2,"ordinal_position":8,"char_length":6,"column_type_utf8":"","elements":[],"collation_id":63},
+		{"name":"DB_ROLL_PTR","type":9,"is_nullable":false,"is_unsigned":false,"hidden":2,"ordinal_position":9,"char_length":7,"column_type_utf8":"","elements":[],"collation_id":63}
+	],
+	"indexes": [
+		{
+			"name": "PRIMARY",
+			"hidden": false,
+			"ordinal_position": 1,
+			"type": 1,
+			"algorithm": 2,
+			"se_private_data": "id=78;root=142;space_id=4294967294;table_id=29;trx_id=0;",
+			"elements": [
+				{"ordinal_position":1,"length":8,"order":2,"hidden":false,"column_opx":0},
+				{"ordinal_position":2,"length":4294967295,"order":2,"hidden":true,"column_opx":7},
+				{"ordinal_position":3,"length":4294967295,"order":2,"hidden":true,"column_opx":8},
+				{"ordinal_position":4,"length":4294967295,"order":2,"hidden":true,"column_opx":1},
+				{"ordinal_position":5,"length":4294967295,"order":2,"hidden":true,"column_opx":2},
+				{"ordinal_position":6,"length":4294967295,"order":2,"hidden":true,"column_opx":3},
+				{"ordinal_position":7,"length":4294967295,"order":2,"hidden":true,"column_opx":4},
+				{"ordinal_position":8,"length":4294967295,"order":2,"hidden":true,"column_opx":5},
+				{"ordinal_position":9,"length":4294967295,"order":2,"hidden":true,"column_opx":6}
+			]
+		}
+	]
+}`
+
+func TestNewMysqlDdTable(t *testing.T) {
+	tests := []struct {
+		name string
+		sdi string
+		modify func(table *SdiTable)
+		wantRootPageNo uint32
+		wantFields []recordField
+		wantNullable uint32
+		wantErr bool
+	}{
+		{
+			name: "mysql.indexes",
+			sdi: testMysqlIndexesSdi,
+			wantRootPageNo: 70,
+			wantFields: []recordField{
+				{name: "id", fixedLength: 8},
+				{name: "DB_TRX_ID", fixedLength: 6},
+				{name: "DB_ROLL_PTR", fixedLength: 7},
+				{name: "table_id", fixedLength: 8},
+				{name: "name"},
+				{name: "type", fixedLength: 1},
+				{name: "algorithm", fixedLength: 1},
+				{name: "is_algorithm_explicit", fixedLength: 1},
+				{name: "is_visible", fixedLength: 1},
+				{name: "is_generated", fixedLength: 1},
+				{name: "hidden", fixedLength: 1},
+				{name: "ordinal_position", fixedLength: 4},
+				{name: "comment", bigField: true},
+				{name: "options", nullable: true, bigField: true},
+				{name: "se_private_data", nullable: true, bigField: true},
+				{name: "tablespace_id", fixedLength: 8, nullable: true},
+				{name: "engine"},
+				{name: "engine_attribute", nullable: true, bigField: true},
+				{name: "secondary_engine_attribute", nullable: true, bigField: true},
+			},
+			wantNullable: 5,
+		},
+		{
+			name: "mysql.tables",
+			sdi: testMysqlTablesSdi,
+			wantRootPageNo: 142,
+			wantFields: []recordField{
+				{name: "id", fixedLength: 8},
+				{name: "DB_TRX_ID", fixedLength: 6},
+				{name: "DB_ROLL_PTR", fixedLength: 7},
+				{name: "schema_id", fixedLength: 8},
+				{name: "name"},
+				{name: "type", fixedLength: 1},
+				{name: "row_format", fixedLength: 1, nullable: true},
+				{name: "se_private_data", nullable: true, bigField: true},
+				{name: "created", fixedLength: 4},
+			},
+			wantNullable: 2,
+		},
+		{
+			name: "no primary key",
+			sdi: testMysqlTablesSdi,
+			modify: func(table *SdiTable) {
+				table.Indexes[0].Type = 2
+			},
+			wantErr: true,
+		},
+		{
+			name: "no root page",
+			sdi: testMysqlTablesSdi,
+			modify: func(table *SdiTable) {
+				table.Indexes[0].SePrivateData = "id=78;space_id=4294967294;table_id=29;trx_id=0;"
+			},
+			wantErr: true,
+		},
+		{
+			name: "column_opx out of range",
+			sdi: testMysqlTablesSdi,
+			modify: func(table *SdiTable) {
+				table.Indexes[0].Elements[3].ColumnOpx = 9
+			},
+			wantErr: true,
+		},
+		{
+			name: "unsupported column type",
+			sdi: testMysqlTablesSdi,
+			modify: func(table *SdiTable) {
+				table.Columns[2].Type = 7
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sdiTable := &SdiTable{}
+			if err := json.Unmarshal([]byte(tt.sdi), sdiTable); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			if tt.modify != nil {
+				tt.modify(sdiTable)
+			}
+
+			table, err := newMysqlDdTable(sdiTable)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", table)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if table.rootPageNo != tt.wantRootPageNo {
+				t.Fatalf("rootPageNo = %d, want %d", table.rootPageNo, tt.wantRootPageNo)
+			}
+			if table.nNullable != tt.wantNullable {
+				t.Fatalf("nNullable = %d, want %d", table.nNullable, tt.wantNullable)
+			}
+			if len(table.fields) != len(tt.wantFields) {
+				t.Fatalf("got %d fields, want %d", len(table.fields), len(tt.wantFields))
+			}
+			for i, want := range tt.wantFields {
+				if table.fields[i] != want {
+					t.Fatalf("field %d = %+v, want %+v", i, table.fields[i], want)
+				}
+				if table.positions[want.name] != i || table.columns[i].Name != want.name {
+					t.Fatalf("field %s is not at position %d", want.name, i)
+				}
+			}
+
+			// 目录项记录中只有主键列和子节点的页号
+			wantKeyFields := []recordField{tt.wantFields[0], {name: "CHILD_PAGE_NO", fixedLength: 4}}
+			if len(table.keyFields) != len(wantKeyFields) || table.keyFields[0] != wantKeyFields[0] || table.keyFields[1] != wantKeyFields[1] {
+				t.Fatalf("keyFields = %+v, want %+v", table.keyFields, wantKeyFields)
+			}
+		})
+	}
+}
@@ -0,0 +1,291 @@
+package innobase
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Page 保存一个页的全部字节，所有字段都从内存中解析，不再访问文件
+type Page struct {
+	pageNo uint32 // 页在文件中的位置（InnoDB 页号，从 0 开始）
+	data []byte
+	compressed bool // 是否为压缩页（物理页大小小于逻辑页大小）
+}
+
+func NewPage(pageNo uint32, data []byte) *Page {
+	return &Page{
+		pageNo: pageNo,
+		data: data,
+	}
+}
+
+// GetPosition 读取页在文件中的位置，与 FIL 头中存储的页号（GetPageNo()）不一定相同
+func (page *Page)GetPosition() uint32 {
+	return page.pageNo
+}
+
+func (page *Page)IsCompressed() bool {
+	return page.compressed
+}
+
+// Clone 复制页的数据，得到的 Page 不再引用 File 的数据来源（如 mmap 映射区域），关闭 File 之后仍然可以使用
+func (page *Page)Clone() *Page {
+	clone := *page
+	clone.data = append([]byte(nil), page.data...)
+
+	return &clone
+}
+
+func (page *Page)GetData() []byte {
+	return page.data
+}
+
+func (page *Page)GetSize() uint32 {
+	return uint32(len(page.data))
+}
+
+func (page *Page)GetChecksum() (uint32, error) {
+	errPrefix := "Page::GetChecksum()"
+	checksum, err := page.getUint32(uint32(fileOffsetPageChecksum))
+	if err != nil {
+		return 0, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "FIL_PAGE_SPACE_OR_CHKSUM"))
+	}
+
+	return checksum, nil
+}
+
+func (page *Page)GetPageNo() (uint32, error) {
+	errPrefix := "Page::GetPageNo()"
+	pageNo, err := page.getUint32(uint32(fileOffsetPageNo))
+	if err != nil {
+		return 0, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "FIL_PAGE_OFFSET"))
+	}
+
+	return pageNo, nil
+}
+
+// CheckPageNo 检查页中存储的页号与页在文件中的位置是否一致，
+// 不一致说明页被写错了位置或者文件发生了偏移，全 0 的页不检查
+func (page *Page)CheckPageNo() error {
+	errPrefix := "Page::CheckPageNo()"
+
+	storedPageNo, err := page.GetPageNo()
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	if storedPageNo == page.pageNo || page.IsEmpty() {
+		return nil
+	}
+
+	err = fmt.Errorf("%w: stored page no %d", ErrPageNoMismatch, storedPageNo)
+
+	return fmt.Errorf("%s: [%w]", errPrefix, newPageError(page.pageNo, uint32(fileOffsetPageNo), "FIL_PAGE_OFFSET", err))
+}
+
+func (page *Page)GetPrevPageNo() (uint32, error) {
+	errPrefix := "Page::GetPrevPageNo()"
+	pageNo, err := page.getUint32(uint32(fileOffsetPagePrev))
+	if err != nil {
+		return 0, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "FIL_PAGE_PREV"))
+	}
+
+	return pageNo, nil
+}
+
+func (page *Page)GetNextPageNo() (uint32, error) {
+	errPrefix := "Page::GetNextPageNo()"
+	pageNo, err := page.getUint32(uint32(fileOffsetPageNext))
+	if err != nil {
+		return 0, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "FIL_PAGE_NEXT"))
+	}
+
+	return pageNo, nil
+}
+
+func (page *Page)GetLsn() (uint64, error) {
+	errPrefix := "Page::GetLsn()"
+	lsn, err := page.getUint64(uint32(fileOffsetPageLsn))
+	if err != nil {
+		return 0, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "FIL_PAGE_LSN"))
+	}
+
+	return lsn, nil
+}
+
+func (page *Page)GetPageType() (uint16, error) {
+	errPrefix := "Page::GetPageType()"
+	pageType, err := page.getUint16(uint32(fileOffsetPageType))
+	if err != nil {
+		return 0, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "FIL_PAGE_TYPE"))
+	}
+
+	return pageType, nil
+}
+
+func (page *Page)GetFlushedLsn() (uint64, error) {
+	errPrefix := "Page::GetFlushedLsn()"
+	lsn, err := page.getUint64(uint32(fileOffsetPageFlushedLsn))
+	if err != nil {
+		return 0, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "FIL_PAGE_FILE_FLUSH_LSN"))
+	}
+
+	return lsn, nil
+}
+
+func (page *Page)GetSpaceId() (uint32, error) {
+	errPrefix := "Page::GetSpaceId()"
+	spaceId, err := page.getUint32(uint32(fileOffsetSpaceId))
+	if err != nil {
+		return 0, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "FIL_PAGE_SPACE_ID"))
+	}
+
+	return spaceId, nil
+}
+
+// GetTrailerChecksum 读取页尾中的旧格式检验和，压缩页没有页尾
+func (page *Page)GetTrailerChecksum() (uint32, error) {
+	errPrefix := "Page::GetTrailerChecksum()"
+	checksum, err := page.getTrailerUint32(fileTrailerOffsetChecksum)
+	if err != nil {
+		return 0, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "FIL_PAGE_END_LSN_OLD_CHKSUM"))
+	}
+
+	return checksum, nil
+}
+
+// GetTrailerLsn 读取页尾中存储的 Lsn 低 32 位，压缩页没有页尾
+func (page *Page)GetTrailerLsn() (uint32, error) {
+	errPrefix := "Page::GetTrailerLsn()"
+	lsn, err := page.getTrailerUint32(fileTrailerOffsetLsn)
+	if err != nil {
+		return 0, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "FIL_PAGE_END_LSN_OLD_CHKSUM"))
+	}
+
+	return lsn, nil
+}
+
+func (page *Page)getTrailerUint32(fieldOffset uint8) (uint32, error) {
+	if page.compressed {
+		err := fmt.Errorf("%w: compressed page", ErrNoTrailer)
+		return 0, newPageError(page.pageNo, 0, "FIL_PAGE_END_LSN_OLD_CHKSUM", err)
+	}
+
+	if page.GetSize() < uint32(fileTrailerSize) {
+		return 0, newPageError(page.pageNo, page.GetSize(), "FIL_PAGE_END_LSN_OLD_CHKSUM", ErrTruncatedPage)
+	}
+
+	trailerOffset := page.GetSize() - uint32(fileTrailerSize)
+
+	return page.getUint32(trailerOffset + uint32(fieldOffset))
+}
+
+// TrailerResult 页头 Lsn 与页尾 Lsn 的比较结果
+type TrailerResult struct {
+	PageNo uint32
+	Checked bool // 压缩页没有页尾、全 0 页未初始化，都不做比较
+	Torn bool // 页头与页尾的 Lsn 不一致，页只写入了一部分
+
+	HeaderLsn uint64 // 页头中的完整 Lsn（FIL_PAGE_LSN）
+	TrailerLsn uint32 // 页尾中的 Lsn 低 32 位
+	TrailerChecksum uint32 // 页尾中的旧格式检验和
+
+	trailerOffset uint32
+}
+
+// Err 页不完整时返回包装了 ErrTornPage 的 PageError，否则返回 nil
+func (result *TrailerResult)Err() error {
+	if !result.Torn {
+		return nil
+	}
+
+	err := fmt.Errorf("%w: header lsn low 32 bits 0x%08x, trailer lsn 0x%08x", ErrTornPage, uint32(result.HeaderLsn), result.TrailerLsn)
+
+	return newPageError(result.PageNo, result.trailerOffset + uint32(fileTrailerOffsetLsn), "FIL_PAGE_END_LSN_OLD_CHKSUM", err)
+}
+
+// CheckTrailer 比较页头 Lsn 的低 32 位与页尾 Lsn，页在写入过程中崩溃时，
+// 页头和页尾分别属于新旧两个版本，两者不一致
+func (page *Page)CheckTrailer() (TrailerResult, error) {
+	errPrefix := "Page::CheckTrailer()"
+
+	result := TrailerResult{
+		PageNo: page.pageNo,
+	}
+
+	if page.compressed || page.IsEmpty() {
+		return result, nil
+	}
+
+	headerLsn, err := page.GetLsn()
+	if err != nil {
+		return result, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	trailerLsn, err := page.GetTrailerLsn()
+	if err != nil {
+		return result, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	trailerChecksum, err := page.GetTrailerChecksum()
+	if err != nil {
+		return result, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	result.Checked = true
+	result.trailerOffset = page.GetSize() - uint32(fileTrailerSize)
+	result.HeaderLsn = headerLsn
+	result.TrailerLsn = trailerLsn
+	result.TrailerChecksum = trailerChecksum
+	result.Torn = uint32(headerLsn) != trailerLsn
+
+	return result, nil
+}
+
+func (page *Page)checkRange(offset uint32, size uint32) error {
+	if uint64(offset) + uint64(size) > uint64(len(page.data)) {
+		return newPageError(page.pageNo, offset, "", fmt.Errorf("%w: %d bytes at offset %d, page size %d", ErrTruncatedPage, size, offset, len(page.data)))
+	}
+
+	return nil
+}
+
+func (page *Page)getBytes(offset uint32, size uint32) ([]byte, error) {
+	if err := page.checkRange(offset, size); err != nil {
+		return nil, err
+	}
+
+	return page.data[offset:offset + size], nil
+}
+
+func (page *Page)getUint8(offset uint32) (uint8, error) {
+	if err := page.checkRange(offset, uint32(1)); err != nil {
+		return 0, err
+	}
+
+	return page.data[offset], nil
+}
+
+func (page *Page)getUint16(offset uint32) (uint16, error) {
+	if err := page.checkRange(offset, uint32(size2)); err != nil {
+		return 0, err
+	}
+
+	return binary.BigEndian.Uint16(page.data[offset:]), nil
+}
+
+func (page *Page)getUint32(offset uint32) (uint32, error) {
+	if err := page.checkRange(offset, uint32(size4)); err != nil {
+		return 0, err
+	}
+
+	return binary.BigEndian.Uint32(page.data[offset:]), nil
+}
+
+func (page *Page)getUint64(offset uint32) (uint64, error) {
+	if err := page.checkRange(offset, uint32(size8)); err != nil {
+		return 0, err
+	}
+
+	return binary.BigEndian.Uint64(page.data[offset:]), nil
+}
@@ -0,0 +1,474 @@
+package innobase
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	recordInfimumOffset uint32 = 99 // COMPACT 格式页中 infimum 记录的位置
+	recordSupremumOffset uint32 = 112 // COMPACT 格式页中 supremum 记录的位置
+	recordExtraBytes uint32 = 5 // COMPACT 格式的记录头，在记录原点之前
+
+	recordOldInfimumOffset uint32 = 101 // REDUNDANT 格式页中 infimum 记录的位置
+	recordOldSupremumOffset uint32 = 116 // REDUNDANT 格式页中 supremum 记录的位置
+	recordOldExtraBytes uint32 = 6 // REDUNDANT 格式的记录头，在记录原点之前，字段结束位置数组在记录头之前
+
+	recordOldShortFlag uint8 = 0x01 // 字段结束位置为 1 字节
+	recordOldNullFlag1 uint8 = 0x80 // 1 字节的字段结束位置中的 NULL 标志
+	recordOldNullFlag2 uint16 = 0x8000 // 2 字节的字段结束位置中的 NULL 标志
+	recordOldExternFlag2 uint16 = 0x4000 // 2 字节的字段结束位置中的外部存储标志
+
+	recordStatusOrdinary uint8 = 0 // 叶子节点中的普通记录
+	recordStatusNodePtr uint8 = 1 // 非叶子节点中的目录项记录
+	recordStatusInfimum uint8 = 2
+	recordStatusSupremum uint8 = 3
+
+	recordInfoMinRecFlag uint8 = 0x10 // 非叶子节点中每层最左边的记录
+	recordInfoDeletedFlag uint8 = 0x20 // 已标记删除
+	recordInfoVersionFlag uint8 = 0x40 // 8.0.29 开始，记录中有行版本号（INSTANT ADD/DROP COLUMN 之后插入的记录）
+	recordInfoInstantFlag uint8 = 0x80 // 8.0.12 开始，记录中有字段数量（INSTANT ADD COLUMN 之后插入的记录）
+
+	pageHeapNoCompactFlag uint16 = 0x8000 // PAGE_N_HEAP 的第 15 位，为 1 表示 COMPACT 格式
+
+	externalFieldRefSize uint32 = 20 // 外部存储字段在记录中保留的引用：表空间 ID 4、页号 4、页内偏移量 4、长度 8
+
+	blobOffsetPartLen uint32 = 0 // BLOB 页中本页数据的长度，4 字节
+	blobOffsetNextPageNo uint32 = 4 // 下一个 BLOB 页的页号，4 字节
+	blobHeaderSize uint32 = 8
+)
+
+// compactRecordHeader COMPACT 格式的记录头
+type compactRecordHeader struct {
+	origin uint32 // 记录原点在页中的偏移量，字段从这里开始，记录头和变长字段长度在它之前
+	infoBits uint8
+	nOwned uint8
+	heapNo uint16
+	status uint8
+	next uint32 // 下一条记录原点的偏移量，为 0 表示没有下一条记录
+}
+
+func (header *compactRecordHeader)isDeleted() bool {
+	return header.infoBits & recordInfoDeletedFlag != 0
+}
+
+// getCompactRecordHeader 解析 origin 之前 5 字节的记录头
+func (page *Page)getCompactRecordHeader(origin uint32) (compactRecordHeader, error) {
+	if origin < recordExtraBytes {
+		return compactRecordHeader{}, newPageError(page.pageNo, origin, "REC_HEADER", ErrTruncatedPage)
+	}
+
+	data, err := page.getBytes(origin - recordExtraBytes, recordExtraBytes)
+	if err != nil {
+		return compactRecordHeader{}, withField(err, "REC_HEADER")
+	}
+
+	header := compactRecordHeader{
+		origin: origin,
+		infoBits: data[0] & 0xF0,
+		nOwned: data[0] & 0x0F,
+		heapNo: binary.BigEndian.Uint16(data[1:3]) >> 3,
+		status: data[2] & 0x07,
+	}
+
+	// 下一条记录的相对偏移量，按页大小取模
+	relative := binary.BigEndian.Uint16(data[3:5])
+	if relative != 0 {
+		header.next = (origin + uint32(relative)) % page.GetSize()
+	}
+
+	return header, nil
+}
+
+// redundantRecordHeader REDUNDANT 格式（MySQL 5.0 之前的行格式，系统表也使用这种格式）的记录头
+type redundantRecordHeader struct {
+	origin uint32
+	infoBits uint8
+	nOwned uint8
+	heapNo uint16
+	nFields uint16
+	shortOffsets bool // 字段结束位置为 1 字节，记录长度超过 127 字节时为 2 字节
+	next uint32 // 下一条记录原点在页中的绝对偏移量，为 0 表示没有下一条记录
+}
+
+func (header *redundantRecordHeader)isDeleted() bool {
+	return header.infoBits & recordInfoDeletedFlag != 0
+}
+
+// getRedundantRecordHeader 解析 origin 之前 6 字节的记录头
+func (page *Page)getRedundantRecordHeader(origin uint32) (redundantRecordHeader, error) {
+	if origin < recordOldExtraBytes {
+		return redundantRecordHeader{}, newPageError(page.pageNo, origin, "REC_HEADER", ErrTruncatedPage)
+	}
+
+	data, err := page.getBytes(origin - recordOldExtraBytes, recordOldExtraBytes)
+	if err != nil {
+		return redundantRecordHeader{}, withField(err, "REC_HEADER")
+	}
+
+	return redundantRecordHeader{
+		origin: origin,
+		infoBits: data[0] & 0xF0,
+		nOwned: data[0] & 0x0F,
+		heapNo: binary.BigEndian.Uint16(data[1:3]) >> 3,
+		nFields: (binary.BigEndian.Uint16(data[2:4]) & 0x07FE) >> 1,
+		shortOffsets: data[3] & recordOldShortFlag != 0,
+		next: uint32(binary.BigEndian.Uint16(data[4:6])),
+	}, nil
+}
+
+// getRedundantFieldEnd 读取第 i 个字段的结束位置（相对于记录原点）以及 NULL、外部存储标志
+func (page *Page)getRedundantFieldEnd(header redundantRecordHeader, i uint16) (uint32, bool, bool, error) {
+	if header.shortOffsets {
+		value, err := page.getUint8(header.origin - recordOldExtraBytes - uint32(i) - 1)
+		if err != nil {
+			return 0, false, false, withField(err, "REC_FIELD_END")
+		}
+
+		return uint32(value &^ recordOldNullFlag1), value & recordOldNullFlag1 != 0, false, nil
+	}
+
+	value, err := page.getUint16(header.origin - recordOldExtraBytes - (uint32(i) + 1) * 2)
+	if err != nil {
+		return 0, false, false, withField(err, "REC_FIELD_END")
+	}
+
+	return uint32(value &^ (recordOldNullFlag2 | recordOldExternFlag2)), value & recordOldNullFlag2 != 0, value & recordOldExternFlag2 != 0, nil
+}
+
+// getRedundantField 读取第 i 个字段的数据，NULL 字段返回 nil。外部存储的字段只返回记录中的部分，
+// 最后 20 字节为外部引用
+func (page *Page)getRedundantField(header redundantRecordHeader, i uint16) ([]byte, bool, error) {
+	if i >= header.nFields {
+		err := fmt.Errorf("field %d does not exist, record has %d fields", i, header.nFields)
+		return nil, false, newPageError(page.pageNo, header.origin, "REC_N_FIELDS", err)
+	}
+
+	start := uint32(0)
+	if i > 0 {
+		end, _, _, err := page.getRedundantFieldEnd(header, i - 1)
+		if err != nil {
+			return nil, false, err
+		}
+		start = end
+	}
+
+	end, null, external, err := page.getRedundantFieldEnd(header, i)
+	if err != nil {
+		return nil, false, err
+	}
+	if null {
+		return nil, false, nil
+	}
+	if end < start {
+		err := fmt.Errorf("field %d ends at %d before it starts at %d", i, end, start)
+		return nil, false, newPageError(page.pageNo, header.origin, "REC_FIELD_END", err)
+	}
+
+	data, err := page.getBytes(header.origin + start, end - start)
+	if err != nil {
+		return nil, false, withField(err, "REC_FIELD")
+	}
+
+	return data, external, nil
+}
+
+// recordField 记录中一个字段的物理格式，用于解析 COMPACT、DYNAMIC 格式的记录
+type recordField struct {
+	name string
+	fixedLength uint32 // 定长字段的长度，0 表示变长字段
+	nullable bool // 可以为 NULL 的字段在 NULL 位图中占一位
+	bigField bool // 变长字段的最大长度超过 255 字节，或者是 BLOB 类型，长度可能占 2 字节
+}
+
+// recordValue 记录中一个字段的值
+type recordValue struct {
+	data []byte // 外部存储的字段只有记录中的部分，最后 20 字节为外部引用
+	null bool
+	external bool
+}
+
+// getCompactFields 按 fields 解析 COMPACT、DYNAMIC 格式的记录。记录原点之前依次为记录头、NULL 位图、
+// 变长字段长度列表，后两者都是从后向前存储；NULL 字段不占用数据和长度。不支持 INSTANT 加列之后插入的记录。
+// NULL 位图的大小由索引中可为 NULL 的字段数 nNullable 决定，目录项记录只包含主键列，但位图的大小与叶子节点记录相同
+func (page *Page)getCompactFields(header compactRecordHeader, fields []recordField, nNullable uint32) ([]recordValue, error) {
+	if header.infoBits & (recordInfoInstantFlag | recordInfoVersionFlag) != 0 {
+		err := fmt.Errorf("records with instant columns are not supported")
+		return nil, newPageError(page.pageNo, header.origin, "REC_INFO_BITS", err)
+	}
+
+	nullBytes := (nNullable + 7) / 8
+	if header.origin < recordExtraBytes + nullBytes {
+		return nil, newPageError(page.pageNo, header.origin, "REC_NULL_BITMAP", ErrTruncatedPage)
+	}
+
+	nullOffset := header.origin - recordExtraBytes - 1
+	lengthOffset := header.origin - recordExtraBytes - nullBytes - 1
+	dataOffset := header.origin
+	nullIndex := uint32(0)
+
+	values := make([]recordValue, len(fields))
+	for i, field := range fields {
+		if field.nullable {
+			if nullIndex >= nNullable {
+				err := fmt.Errorf("field %s is nullable but the index has %d nullable fields", field.name, nNullable)
+				return nil, newPageError(page.pageNo, header.origin, "REC_NULL_BITMAP", err)
+			}
+			bits, err := page.getUint8(nullOffset - nullIndex / 8)
+			if err != nil {
+				return nil, withField(err, "REC_NULL_BITMAP")
+			}
+			null := (bits >> (nullIndex % 8)) & 1 == 1
+			nullIndex++
+			if null {
+				values[i].null = true
+				continue
+			}
+		}
+
+		length := field.fixedLength
+		if length == 0 {
+			varLength, external, nextOffset, err := page.getVarFieldLength(lengthOffset, field.bigField)
+			if err != nil {
+				return nil, err
+			}
+			length = varLength
+			values[i].external = external
+			lengthOffset = nextOffset
+		}
+
+		data, err := page.getBytes(dataOffset, length)
+		if err != nil {
+			return nil, withField(err, field.name)
+		}
+		values[i].data = data
+		dataOffset += length
+	}
+
+	return values, nil
+}
+
+// IsCompact 页中的记录是否为 COMPACT 格式（COMPACT、DYNAMIC、COMPRESSED 行格式）
+func (page *BTreePage)IsCompact() (bool, error) {
+	errPrefix := "BTreePage::IsCompact()"
+
+	nHeap, err := page.GetHeapCount()
+	if err != nil {
+		return false, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return nHeap & pageHeapNoCompactFlag != 0, nil
+}
+
+// getCompactRecords 从 infimum 开始沿 next 遍历页中的用户记录（包括已标记删除的），
+// 记录数量超过 PAGE_N_HEAP 时认为链表有环。压缩页中的记录需要先解压，不支持，返回 ErrCompressedPageUnsupported
+func (page *BTreePage)getCompactRecords() ([]compactRecordHeader, error) {
+	errPrefix := "BTreePage::getCompactRecords()"
+
+	if page.filePage.IsCompressed() {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, newPageError(page.filePage.pageNo, 0, "", ErrCompressedPageUnsupported))
+	}
+
+	compact, err := page.IsCompact()
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	if !compact {
+		err := fmt.Errorf("records are not in COMPACT format")
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, newPageError(page.filePage.pageNo, uint32(pageOffsetNHeap), "PAGE_N_HEAP", err))
+	}
+
+	nHeap, err := page.GetHeapCount()
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	nHeap &^= pageHeapNoCompactFlag
+
+	infimum, err := page.filePage.getCompactRecordHeader(recordInfimumOffset)
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	records := []compactRecordHeader{}
+	origin := infimum.next
+	for origin != recordSupremumOffset {
+		if origin == 0 || len(records) >= int(nHeap) {
+			err := fmt.Errorf("record list does not end at supremum")
+			return nil, fmt.Errorf("%s: [%w]", errPrefix, newPageError(page.filePage.pageNo, origin, "REC_NEXT", err))
+		}
+
+		header, err := page.filePage.getCompactRecordHeader(origin)
+		if err != nil {
+			return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+		}
+		records = append(records, header)
+		origin = header.next
+	}
+
+	return records, nil
+}
+
+// getRedundantRecords 从 infimum 开始沿 next 遍历 REDUNDANT 格式页中的用户记录（包括已标记删除的）
+func (page *BTreePage)getRedundantRecords() ([]redundantRecordHeader, error) {
+	errPrefix := "BTreePage::getRedundantRecords()"
+
+	compact, err := page.IsCompact()
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	if compact {
+		err := fmt.Errorf("records are not in REDUNDANT format")
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, newPageError(page.filePage.pageNo, uint32(pageOffsetNHeap), "PAGE_N_HEAP", err))
+	}
+
+	nHeap, err := page.GetHeapCount()
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	infimum, err := page.filePage.getRedundantRecordHeader(recordOldInfimumOffset)
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	records := []redundantRecordHeader{}
+	origin := infimum.next
+	for origin != recordOldSupremumOffset {
+		if origin == 0 || len(records) >= int(nHeap) {
+			err := fmt.Errorf("record list does not end at supremum")
+			return nil, fmt.Errorf("%s: [%w]", errPrefix, newPageError(page.filePage.pageNo, origin, "REC_NEXT", err))
+		}
+
+		header, err := page.filePage.getRedundantRecordHeader(origin)
+		if err != nil {
+			return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+		}
+		records = append(records, header)
+		origin = header.next
+	}
+
+	return records, nil
+}
+
+// getVarFieldLength 从 offset 向前读取一个变长字段的长度，bigField 表示字段最大长度可能超过 255（如 VARCHAR(256)、BLOB），
+// 此时长度可能占 2 字节：第一个字节的最高位为 1，第 6 位表示字段存储在外部。返回长度、是否外部存储和下一个长度的位置
+func (page *Page)getVarFieldLength(offset uint32, bigField bool) (uint32, bool, uint32, error) {
+	first, err := page.getUint8(offset)
+	if err != nil {
+		return 0, false, 0, withField(err, "REC_FIELD_LENGTH")
+	}
+
+	if !bigField || first & 0x80 == 0 {
+		return uint32(first), false, offset - 1, nil
+	}
+
+	second, err := page.getUint8(offset - 1)
+	if err != nil {
+		return 0, false, 0, withField(err, "REC_FIELD_LENGTH")
+	}
+
+	length := uint32(first & 0x3F) << 8 | uint32(second)
+	external := first & 0x40 != 0
+
+	return length, external, offset - 2, nil
+}
+
+// readExternalField 根据记录中 20 字节的外部引用，读取外部存储的字段。按第一个页的类型选择格式：
+// 旧格式 BLOB 页链（5.7 及之前的版本，8.0 的 SDI 也使用这种格式），或者 8.0 的新格式 LOB（DYNAMIC 表，包括 mysql.ibd 中的数据字典表）
+func (file *File)readExternalField(ref []byte) ([]byte, error) {
+	errPrefix := "File::readExternalField()"
+
+	if uint32(len(ref)) != externalFieldRefSize {
+		return nil, fmt.Errorf("%s: [external field reference is %d bytes]", errPrefix, len(ref))
+	}
+
+	pageNo := binary.BigEndian.Uint32(ref[4:8])
+	offset := binary.BigEndian.Uint32(ref[8:12])
+	// 长度的高 4 字节中是标志位
+	length := binary.BigEndian.Uint32(ref[16:20])
+
+	page, err := file.ReadPage(pageNo)
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	pageType, err := page.GetPageType()
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	var data []byte
+	switch pageType {
+	case pageTypeBlob, pageTypeSdiBlob:
+		data, err = file.readBlobField(pageNo, offset, length)
+	case pageTypeLobFirst:
+		data, err = file.readLobField(page, length)
+	default:
+		err = newPageError(pageNo, uint32(fileOffsetPageType), "FIL_PAGE_TYPE",
+			fmt.Errorf("page type %d (%s) is not a BLOB page", pageType, pageTypeMap[pageType]))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return data, nil
+}
+
+// readBlobField 读取旧格式的 BLOB 页链，从 pageNo 页的 offset 处开始，每个页中依次为本页数据长度、下一页页号和数据
+func (file *File)readBlobField(pageNo uint32, offset uint32, length uint32) ([]byte, error) {
+	errPrefix := "File::readBlobField()"
+
+	// length 来自磁盘，不可信，不按它预先分配内存；页链中有环或本页数据长度为 0 时结束，保证损坏的文件不会陷入死循环
+	data := []byte{}
+	visited := map[uint32]bool{}
+	for uint32(len(data)) < length {
+		if pageNo == fileNull {
+			return nil, fmt.Errorf("%s: [BLOB chain ends after %d of %d bytes]", errPrefix, len(data), length)
+		}
+		if visited[pageNo] {
+			return nil, fmt.Errorf("%s: [%w: BLOB page %d is visited twice]", errPrefix, ErrListCycle, pageNo)
+		}
+		visited[pageNo] = true
+
+		page, err := file.ReadPage(pageNo)
+		if err != nil {
+			return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+		}
+
+		pageType, err := page.GetPageType()
+		if err != nil {
+			return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+		}
+		if pageType != pageTypeBlob && pageType != pageTypeSdiBlob {
+			err := fmt.Errorf("page type %d (%s) is not a BLOB page", pageType, pageTypeMap[pageType])
+			return nil, fmt.Errorf("%s: [%w]", errPrefix, newPageError(pageNo, uint32(fileOffsetPageType), "FIL_PAGE_TYPE", err))
+		}
+
+		partLen, err := page.getUint32(offset + blobOffsetPartLen)
+		if err != nil {
+			return nil, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "BTR_BLOB_PART_LEN"))
+		}
+		if partLen == 0 {
+			err := fmt.Errorf("BLOB page has no data")
+			return nil, fmt.Errorf("%s: [%w]", errPrefix, newPageError(pageNo, offset + blobOffsetPartLen, "BTR_BLOB_PART_LEN", err))
+		}
+
+		part, err := page.getBytes(offset + blobHeaderSize, partLen)
+		if err != nil {
+			return nil, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "BLOB_DATA"))
+		}
+		data = append(data, part...)
+
+		pageNo, err = page.getUint32(offset + blobOffsetNextPageNo)
+		if err != nil {
+			return nil, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "BTR_BLOB_NEXT_PAGE_NO"))
+		}
+		offset = uint32(fileHeaderSize)
+	}
+
+	if uint32(len(data)) > length {
+		data = data[:length]
+	}
+
+	return data, nil
+}
@@ -0,0 +1,506 @@
+package innobase
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+const testRecordOrigin = 200
+
+// newTestRecordPage 构造一个 16K 的页，由 build 写入记录
+func newTestRecordPage(build func(data []byte)) *Page {
+	data := make([]byte, testPageSize)
+	if build != nil {
+		build(data)
+	}
+
+	return NewPage(1, data)
+}
+
+// putTestCompactHeader 在 origin 之前写入 5 字节的 COMPACT 记录头
+func putTestCompactHeader(data []byte, origin int, infoBits uint8, heapNo uint16, status uint8, next uint16) {
+	data[origin - 5] = infoBits
+	binary.BigEndian.PutUint16(data[origin - 4:], heapNo << 3 | uint16(status))
+	binary.BigEndian.PutUint16(data[origin - 2:], next)
+}
+
+// putTestRedundantHeader 在 origin 之前写入 6 字节的 REDUNDANT 记录头：heap_no 13 位、n_fields 10 位、1 字节偏移标志 1 位
+func putTestRedundantHeader(data []byte, origin int, infoBits uint8, heapNo uint16, nFields uint16, short bool, next uint16) {
+	bits := uint32(heapNo) << 11 | uint32(nFields) << 1
+	if short {
+		bits |= 1
+	}
+	data[origin - 6] = infoBits
+	data[origin - 5] = byte(bits >> 16)
+	data[origin - 4] = byte(bits >> 8)
+	data[origin - 3] = byte(bits)
+	binary.BigEndian.PutUint16(data[origin - 2:], next)
+}
+
+func TestGetCompactRecordHeader(t *testing.T) {
+	page := newTestRecordPage(func(data []byte) {
+		putTestCompactHeader(data, testRecordOrigin, recordInfoDeletedFlag | 3, 7, recordStatusNodePtr, 0xFFFF)
+	})
+
+	header, err := page.getCompactRecordHeader(testRecordOrigin)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !header.isDeleted() || header.nOwned != 3 || header.heapNo != 7 || header.status != recordStatusNodePtr {
+		t.Fatalf("header = %+v", header)
+	}
+	// 相对偏移量按页大小取模，0xFFFF 指向 origin 之前 1 字节
+	if header.next != testRecordOrigin - 1 {
+		t.Fatalf("next = %d, want %d", header.next, testRecordOrigin - 1)
+	}
+
+	if _, err := page.getCompactRecordHeader(4); !errors.Is(err, ErrTruncatedPage) {
+		t.Fatalf("error = %v, want %v", err, ErrTruncatedPage)
+	}
+}
+
+func TestGetVarFieldLength(t *testing.T) {
+	tests := []struct {
+		name string
+		bytes []byte // 从 offset 开始向前存储
+		bigField bool
+		wantLength uint32
+		wantExternal bool
+		wantNext uint32
+	}{
+		{name: "one byte", bytes: []byte{0x05}, bigField: false, wantLength: 5, wantNext: 99},
+		{name: "small field ignores high bit", bytes: []byte{0xC0, 0x10}, bigField: false, wantLength: 0xC0, wantNext: 99},
+		{name: "big field with one byte", bytes: []byte{0x7F, 0x10}, bigField: true, wantLength: 0x7F, wantNext: 99},
+		{name: "big field with two bytes", bytes: []byte{0x81, 0x2C}, bigField: true, wantLength: 300, wantNext: 98},
+		{name: "extern flag", bytes: []byte{0xC0, 0x14}, bigField: true, wantLength: 20, wantExternal: true, wantNext: 98},
+		{name: "max length", bytes: []byte{0xBF, 0xFF}, bigField: true, wantLength: 0x3FFF, wantNext: 98},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := newTestRecordPage(func(data []byte) {
+				for i, b := range tt.bytes {
+					data[100 - i] = b
+				}
+			})
+
+			length, external, next, err := page.getVarFieldLength(100, tt.bigField)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if length != tt.wantLength || external != tt.wantExternal || next != tt.wantNext {
+				t.Fatalf("got (%d, %v, %d), want (%d, %v, %d)", length, external, next, tt.wantLength, tt.wantExternal, tt.wantNext)
+			}
+		})
+	}
+}
+
+func TestGetCompactFields(t *testing.T) {
+	origin := testRecordOrigin
+
+	tests := []struct {
+		name string
+		fields []recordField
+		nNullable uint32
+		infoBits uint8
+		build func(data []byte) // 写入 NULL 位图、变长字段长度和字段数据
+		want []recordValue
+		wantErr bool
+		wantErrIs error
+	}{
+		{
+			name: "fixed and variable",
+			fields: []recordField{
+				{name: "id", fixedLength: 4},
+				{name: "name"},
+				{name: "value", bigField: true},
+			},
+			build: func(data []byte) {
+				data[origin - 6] = 3
+				data[origin - 7] = 0x81
+				data[origin - 8] = 0x2C
+				copy(data[origin:], testFill(1, 4 + 3 + 300))
+			},
+			want: []recordValue{
+				{data: testFill(1, 4)},
+				{data: testFill(5, 3)},
+				{data: testFill(8, 300)},
+			},
+		},
+		{
+			name: "null bits",
+			fields: []recordField{
+				{name: "a", fixedLength: 4, nullable: true},
+				{name: "b", nullable: true},
+				{name: "c", fixedLength: 2, nullable: true},
+				{name: "d"},
+			},
+			nNullable: 3,
+			build: func(data []byte) {
+				data[origin - 6] = 0x02 // b 为 NULL
+				data[origin - 7] = 1 // d 的长度，NULL 字段不占用长度
+				copy(data[origin:], testFill(1, 4 + 2 + 1))
+			},
+			want: []recordValue{
+				{data: testFill(1, 4)},
+				{null: true},
+				{data: testFill(5, 2)},
+				{data: testFill(7, 1)},
+			},
+		},
+		{
+			name: "two byte null bitmap",
+			fields: []recordField{
+				{name: "c0", fixedLength: 1, nullable: true},
+				{name: "c1", fixedLength: 1, nullable: true},
+				{name: "c2", fixedLength: 1, nullable: true},
+				{name: "c3", fixedLength: 1, nullable: true},
+				{name: "c4", fixedLength: 1, nullable: true},
+				{name: "c5", fixedLength: 1, nullable: true},
+				{name: "c6", fixedLength: 1, nullable: true},
+				{name: "c7", fixedLength: 1, nullable: true},
+				{name: "c8", fixedLength: 1, nullable: true},
+				{name: "v"},
+			},
+			nNullable: 9,
+			build: func(data []byte) {
+				data[origin - 6] = 0x01 // c0 为 NULL
+				data[origin - 7] = 0x01 // c8 为 NULL
+				data[origin - 8] = 2 // v 的长度在两字节的位图之前
+				copy(data[origin:], testFill(1, 7 + 2))
+			},
+			want: []recordValue{
+				{null: true},
+				{data: testFill(1, 1)},
+				{data: testFill(2, 1)},
+				{data: testFill(3, 1)},
+				{data: testFill(4, 1)},
+				{data: testFill(5, 1)},
+				{data: testFill(6, 1)},
+				{data: testFill(7, 1)},
+				{null: true},
+				{data: testFill(8, 2)},
+			},
+		},
+		{
+			name: "small field length with high bit",
+			fields: []recordField{{name: "name"}},
+			build: func(data []byte) {
+				data[origin - 6] = 0x90
+				copy(data[origin:], testFill(1, 0x90))
+			},
+			want: []recordValue{{data: testFill(1, 0x90)}},
+		},
+		{
+			name: "external",
+			fields: []recordField{{name: "id", fixedLength: 4}, {name: "doc", bigField: true}},
+			build: func(data []byte) {
+				data[origin - 6] = 0xC0
+				data[origin - 7] = 20
+				copy(data[origin:], testFill(1, 4 + 20))
+			},
+			want: []recordValue{
+				{data: testFill(1, 4)},
+				{data: testFill(5, 20), external: true},
+			},
+		},
+		{
+			name: "instant record",
+			fields: []recordField{{name: "id", fixedLength: 4}},
+			infoBits: recordInfoInstantFlag,
+			wantErr: true,
+		},
+		{
+			name: "more nullable fields than bitmap",
+			fields: []recordField{{name: "a", fixedLength: 1, nullable: true}, {name: "b", fixedLength: 1, nullable: true}},
+			nNullable: 1,
+			wantErr: true,
+		},
+		{
+			name: "field beyond page",
+			fields: []recordField{{name: "id", fixedLength: testPageSize}},
+			wantErr: true,
+			wantErrIs: ErrTruncatedPage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := newTestRecordPage(func(data []byte) {
+				putTestCompactHeader(data, origin, tt.infoBits, 2, recordStatusOrdinary, 0)
+				if tt.build != nil {
+					tt.build(data)
+				}
+			})
+
+			header, err := page.getCompactRecordHeader(uint32(origin))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			values, err := page.getCompactFields(header, tt.fields, tt.nNullable)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", values)
+				}
+				if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErrIs)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(values) != len(tt.want) {
+				t.Fatalf("got %d values, want %d", len(values), len(tt.want))
+			}
+			for i, want := range tt.want {
+				got := values[i]
+				if got.null != want.null || got.external != want.external || !bytes.Equal(got.data, want.data) {
+					t.Fatalf("field %s = %+v, want %+v", tt.fields[i].name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestGetRedundantRecordHeader(t *testing.T) {
+	tests := []struct {
+		name string
+		short bool
+	}{
+		{name: "short offsets", short: true},
+		{name: "long offsets", short: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := newTestRecordPage(func(data []byte) {
+				putTestRedundantHeader(data, testRecordOrigin, recordInfoDeletedFlag | 1, 0x1ABC, 0x3FF, tt.short, 0x1234)
+			})
+
+			header, err := page.getRedundantRecordHeader(testRecordOrigin)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !header.isDeleted() || header.nOwned != 1 || header.heapNo != 0x1ABC || header.nFields != 0x3FF ||
+				header.shortOffsets != tt.short || header.next != 0x1234 {
+				t.Fatalf("header = %+v", header)
+			}
+		})
+	}
+}
+
+func TestGetRedundantField(t *testing.T) {
+	origin := testRecordOrigin
+
+	type wantField struct {
+		data []byte
+		null bool
+		external bool
+	}
+
+	tests := []struct {
+		name string
+		short bool
+		nFields uint16
+		build func(data []byte) // 写入字段结束位置数组和字段数据
+		want []wantField
+		wantErr uint16 // 读取第 wantErr 个字段时报错，0 表示不报错
+	}{
+		{
+			name: "short offsets",
+			short: true,
+			nFields: 3,
+			build: func(data []byte) {
+				data[origin - 7] = 4
+				data[origin - 8] = 4 | recordOldNullFlag1
+				data[origin - 9] = 7
+				copy(data[origin:], testFill(1, 7))
+			},
+			want: []wantField{
+				{data: testFill(1, 4)},
+				{null: true},
+				{data: testFill(5, 3)},
+			},
+		},
+		{
+			name: "long offsets",
+			short: false,
+			nFields: 3,
+			build: func(data []byte) {
+				binary.BigEndian.PutUint16(data[origin - 8:], 4)
+				binary.BigEndian.PutUint16(data[origin - 10:], 4 | recordOldNullFlag2)
+				binary.BigEndian.PutUint16(data[origin - 12:], 4 + 200)
+				copy(data[origin:], testFill(1, 204))
+			},
+			want: []wantField{
+				{data: testFill(1, 4)},
+				{null: true},
+				{data: testFill(5, 200)},
+			},
+		},
+		{
+			name: "extern flag",
+			short: false,
+			nFields: 2,
+			build: func(data []byte) {
+				binary.BigEndian.PutUint16(data[origin - 8:], 4)
+				binary.BigEndian.PutUint16(data[origin - 10:], (4 + 788) | recordOldExternFlag2)
+				copy(data[origin:], testFill(1, 4 + 788))
+			},
+			want: []wantField{
+				{data: testFill(1, 4)},
+				{data: testFill(5, 788), external: true},
+			},
+		},
+		{
+			name: "end before start",
+			short: true,
+			nFields: 2,
+			build: func(data []byte) {
+				data[origin - 7] = 8
+				data[origin - 8] = 4
+			},
+			want: []wantField{{data: make([]byte, 8)}},
+			wantErr: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := newTestRecordPage(func(data []byte) {
+				putTestRedundantHeader(data, origin, 0, 2, tt.nFields, tt.short, 0)
+				tt.build(data)
+			})
+
+			header, err := page.getRedundantRecordHeader(uint32(origin))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for i, want := range tt.want {
+				data, external, err := page.getRedundantField(header, uint16(i))
+				if err != nil {
+					t.Fatalf("field %d: unexpected error: %v", i, err)
+				}
+				if (data == nil) != want.null || external != want.external || (!want.null && !bytes.Equal(data, want.data)) {
+					t.Fatalf("field %d = (%v, %v), want %+v", i, data, external, want)
+				}
+			}
+
+			if tt.wantErr != 0 {
+				if _, _, err := page.getRedundantField(header, tt.wantErr); err == nil {
+					t.Fatalf("field %d: expected an error", tt.wantErr)
+				}
+			}
+
+			// 超出字段数量
+			if _, _, err := page.getRedundantField(header, tt.nFields); err == nil {
+				t.Fatalf("field %d: expected an error", tt.nFields)
+			}
+		})
+	}
+}
+
+// putTestBlobPage 写入一个旧格式 BLOB 页：本页数据长度、下一页页号和数据
+func putTestBlobPage(data []byte, pageNo uint32, part []byte, nextPageNo uint32) {
+	page := data[int(pageNo) * testPageSize:]
+	putTestPageType(data, pageNo, pageTypeBlob)
+	binary.BigEndian.PutUint32(page[uint32(fileHeaderSize) + blobOffsetPartLen:], uint32(len(part)))
+	binary.BigEndian.PutUint32(page[uint32(fileHeaderSize) + blobOffsetNextPageNo:], nextPageNo)
+	copy(page[uint32(fileHeaderSize) + blobHeaderSize:], part)
+}
+
+// newTestExternalRef 构造 20 字节的外部引用
+func newTestExternalRef(pageNo uint32, offset uint32, length uint32) []byte {
+	ref := make([]byte, externalFieldRefSize)
+	binary.BigEndian.PutUint32(ref[4:], pageNo)
+	binary.BigEndian.PutUint32(ref[8:], offset)
+	binary.BigEndian.PutUint32(ref[16:], length)
+
+	return ref
+}
+
+func TestReadExternalField(t *testing.T) {
+	tests := []struct {
+		name string
+		build func(data []byte)
+		length uint32
+		want []byte
+		wantErr bool
+		wantErrIs error
+	}{
+		{
+			name: "chain",
+			build: func(data []byte) {
+				putTestBlobPage(data, 1, testFill(1, 100), 2)
+				putTestBlobPage(data, 2, testFill(101, 50), fileNull)
+			},
+			length: 150,
+			want: testFill(1, 150),
+		},
+		{
+			name: "chain ends early",
+			build: func(data []byte) {
+				putTestBlobPage(data, 1, testFill(1, 100), fileNull)
+			},
+			length: 150,
+			wantErr: true,
+		},
+		{
+			name: "cycle",
+			build: func(data []byte) {
+				putTestBlobPage(data, 1, testFill(1, 10), 2)
+				putTestBlobPage(data, 2, testFill(11, 10), 1)
+			},
+			length: 0xFFFFFFFF,
+			wantErr: true,
+			wantErrIs: ErrListCycle,
+		},
+		{
+			name: "empty part",
+			build: func(data []byte) {
+				putTestBlobPage(data, 1, nil, 1)
+			},
+			length: 0xFFFFFFFF,
+			wantErr: true,
+		},
+		{
+			name: "not a BLOB page",
+			build: func(data []byte) {
+				putTestBlobPage(data, 1, testFill(1, 10), fileNull)
+				putTestPageType(data, 1, pageTypeIndex)
+			},
+			length: 10,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := newTestFile(4, tt.build)
+			defer file.Close()
+
+			data, err := file.readExternalField(newTestExternalRef(1, uint32(fileHeaderSize), tt.length))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %d bytes", len(data))
+				}
+				if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErrIs)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !bytes.Equal(data, tt.want) {
+				t.Fatalf("got %d bytes, want %d", len(data), len(tt.want))
+			}
+		})
+	}
+}
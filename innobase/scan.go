@@ -0,0 +1,115 @@
+package innobase
+
+import (
+	"fmt"
+	"sync"
+)
+
+const (
+	scanRangePages uint32 = 1024 // 并发扫描时每个任务包含的页数量
+)
+
+// pageVisitor 扫描时处理每个页，并发扫描时每个工作协程持有一个独立的 pageVisitor，
+// 不需要加锁，扫描结束后由调用方合并
+type pageVisitor interface {
+	visitPage(page *Page) error
+}
+
+// pageRange 页号区间 [start, end)
+type pageRange struct {
+	start uint32
+	end uint32
+}
+
+// scanPages 把表空间按页号区间切分后交给 workers 个协程并发读取，
+// 每个协程只通过 ReadRawPage() 读取页，不修改 File 的状态。
+// workers 为 1 时按页号顺序扫描。gzip 等只能顺序读取的数据来源总是只用一个协程，
+// 多个协程交错读取不同的区间会使数据来源反复从头解压
+func scanPages(file *File, workers int, newVisitor func() pageVisitor) ([]pageVisitor, error) {
+	errPrefix := "scanPages()"
+
+	// 在启动协程之前打开文件并确定页大小，之后 File 只会被并发读取
+	pageCount, err := file.getPageCount()
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	if isSequentialSource(file.source) {
+		workers = 1
+	}
+	if rangeCount := int((pageCount + scanRangePages - 1) / scanRangePages); workers > rangeCount {
+		workers = rangeCount
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	ranges := make(chan pageRange)
+	stop := make(chan struct{})
+	errs := make(chan error, workers)
+	visitors := make([]pageVisitor, workers)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		visitor := newVisitor()
+		visitors[i] = visitor
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range ranges {
+				if err := scanPageRange(file, r, visitor); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+
+	// 任意协程出错后停止分发
+	go func() {
+		defer close(ranges)
+		for start := uint32(0); start < pageCount; start += scanRangePages {
+			end := start + scanRangePages
+			if end > pageCount || end < start {
+				end = pageCount
+			}
+
+			select {
+			case ranges <- pageRange{start: start, end: end}:
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(errs)
+	}()
+
+	if err, ok := <-errs; ok {
+		close(stop)
+		// 等待其余协程退出
+		for range errs {
+		}
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return visitors, nil
+}
+
+func scanPageRange(file *File, r pageRange, visitor pageVisitor) error {
+	for pageNo := r.start; pageNo < r.end; pageNo++ {
+		page, err := file.ReadRawPage(pageNo)
+		if err != nil {
+			return err
+		}
+
+		if err := visitor.visitPage(page); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,395 @@
+package innobase
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	encryptionInfoMaxSize uint32 = 115 // 第 0 页中 XDES 数组之后为加密信息预留的空间（Encryption::INFO_MAX_SIZE）
+
+	sdiOffsetVersion uint32 = 0 // SDI 版本，4 字节
+	sdiOffsetRootPageNo uint32 = 4 // SDI 索引根页的页号，4 字节
+	sdiVersion uint32 = 1
+
+	// SDI 索引中的字段：type 4、id 8、DB_TRX_ID 6、DB_ROLL_PTR 7、uncompressed_len 4、compressed_len 4、data
+	sdiRecOffsetType uint32 = 0
+	sdiRecOffsetId uint32 = 4
+	sdiRecOffsetUncompressedLen uint32 = 25
+	sdiRecOffsetCompressedLen uint32 = 29
+	sdiRecOffsetData uint32 = 33
+	sdiRecOffsetChildPageNo uint32 = 12 // 非叶子节点记录中子节点的页号
+)
+
+const (
+	SdiTypeTable uint32 = 1
+	SdiTypeTablespace uint32 = 2
+)
+
+// SdiRecord SDI 索引中的一条记录，Data 为解压后的 JSON
+type SdiRecord struct {
+	Type uint32
+	Id uint64
+	UncompressedLength uint32
+	CompressedLength uint32
+	Data []byte
+}
+
+// SdiObject SDI JSON 的最外层，DdObject 按 DdObjectType 解析为 SdiTable 或 SdiTablespace
+type SdiObject struct {
+	MysqldVersionId uint64 `json:"mysqld_version_id"`
+	DdVersion uint64 `json:"dd_version"`
+	SdiVersion uint64 `json:"sdi_version"`
+	DdObjectType string `json:"dd_object_type"`
+	DdObject json.RawMessage `json:"dd_object"`
+}
+
+type SdiTable struct {
+	Name string `json:"name"`
+	SchemaRef string `json:"schema_ref"`
+	Hidden int `json:"hidden"`
+	EngineName string `json:"engine"`
+	RowFormat int `json:"row_format"`
+	SePrivateId uint64 `json:"se_private_id"`
+	SePrivateData string `json:"se_private_data"`
+	Columns []SdiColumn `json:"columns"`
+	Indexes []SdiIndex `json:"indexes"`
+}
+
+type SdiColumn struct {
+	Name string `json:"name"`
+	Type int `json:"type"` // dd::enum_column_types
+	IsNullable bool `json:"is_nullable"`
+	IsUnsigned bool `json:"is_unsigned"`
+	Hidden int `json:"hidden"` // 1 可见，2 InnoDB 内部列（DB_ROW_ID、DB_TRX_ID、DB_ROLL_PTR）
+	OrdinalPosition int `json:"ordinal_position"`
+	CharLength uint32 `json:"char_length"`
+	NumericPrecision uint32 `json:"numeric_precision"`
+	NumericScale uint32 `json:"numeric_scale"`
+	DatetimePrecision uint32 `json:"datetime_precision"`
+	ColumnTypeUtf8 string `json:"column_type_utf8"`
+	CollationId uint32 `json:"collation_id"`
+	SePrivateData string `json:"se_private_data"`
+	Elements []SdiColumnElement `json:"elements"` // ENUM、SET 的取值
+}
+
+type SdiColumnElement struct {
+	Name string `json:"name"`
+	Index int `json:"index"`
+}
+
+type SdiIndex struct {
+	Name string `json:"name"`
+	Hidden bool `json:"hidden"`
+	Ordinal int `json:"ordinal_position"`
+	Type int `json:"type"` // 1 PRIMARY、2 UNIQUE、3 MULTIPLE、4 FULLTEXT、5 SPATIAL
+	Algorithm int `json:"algorithm"`
+	SePrivateData string `json:"se_private_data"` // id=..;root=..;space_id=..;...
+	Elements []SdiIndexElement `json:"elements"`
+}
+
+type SdiIndexElement struct {
+	Ordinal int `json:"ordinal_position"`
+	Length uint32 `json:"length"`
+	Order int `json:"order"`
+	Hidden bool `json:"hidden"`
+	ColumnOpx int `json:"column_opx"` // 列在 SdiTable.Columns 中的下标
+}
+
+type SdiTablespace struct {
+	Name string `json:"name"`
+	SePrivateData string `json:"se_private_data"`
+	EngineName string `json:"engine"`
+	Files []SdiTablespaceFile `json:"files"`
+}
+
+type SdiTablespaceFile struct {
+	Ordinal int `json:"ordinal_position"`
+	Filename string `json:"filename"`
+	SePrivateData string `json:"se_private_data"`
+}
+
+// GetObject 解析 SDI 的 JSON
+func (record *SdiRecord)GetObject() (*SdiObject, error) {
+	errPrefix := "SdiRecord::GetObject()"
+
+	object := &SdiObject{}
+	if err := json.Unmarshal(record.Data, object); err != nil {
+		return nil, fmt.Errorf("%s: [type %d, id %d: %w]", errPrefix, record.Type, record.Id, err)
+	}
+
+	return object, nil
+}
+
+// GetTable 解析类型为 Table 的 dd_object
+func (object *SdiObject)GetTable() (*SdiTable, error) {
+	errPrefix := "SdiObject::GetTable()"
+
+	if object.DdObjectType != "Table" {
+		return nil, fmt.Errorf("%s: [dd_object_type is %s]", errPrefix, object.DdObjectType)
+	}
+
+	table := &SdiTable{}
+	if err := json.Unmarshal(object.DdObject, table); err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return table, nil
+}
+
+// GetTablespace 解析类型为 Tablespace 的 dd_object
+func (object *SdiObject)GetTablespace() (*SdiTablespace, error) {
+	errPrefix := "SdiObject::GetTablespace()"
+
+	if object.DdObjectType != "Tablespace" {
+		return nil, fmt.Errorf("%s: [dd_object_type is %s]", errPrefix, object.DdObjectType)
+	}
+
+	tablespace := &SdiTablespace{}
+	if err := json.Unmarshal(object.DdObject, tablespace); err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return tablespace, nil
+}
+
+// ParseSePrivateData 解析 se_private_data 等 key=value; 格式的属性
+func ParseSePrivateData(data string) map[string]string {
+	properties := map[string]string{}
+	for _, item := range strings.Split(data, ";") {
+		if item == "" {
+			continue
+		}
+		pos := strings.IndexByte(item, '=')
+		if pos < 0 {
+			properties[item] = ""
+			continue
+		}
+		properties[item[:pos]] = item[pos + 1:]
+	}
+
+	return properties
+}
+
+// getSdiOffset SDI 版本和根页页号在第 0 页中的位置：XDES 数组和加密信息之后
+func getSdiOffset(physicalPageSize uint32, extentPages uint32) uint32 {
+	xdesArrSize := physicalPageSize / extentPages
+
+	return uint32(xdesArrOffset) + getXdesEntrySize(extentPages) * xdesArrSize + encryptionInfoMaxSize
+}
+
+// GetSdiRootPageNo 从第 0 页中读取 SDI 索引根页的页号，表空间没有 SDI 时返回 ErrNoSdi
+func (file *File)GetSdiRootPageNo() (uint32, error) {
+	errPrefix := "File::GetSdiRootPageNo()"
+
+	header, err := file.ReadFSPHeader()
+	if err != nil {
+		return 0, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	if header.SpaceFlags & fspFlagsMaskSdi == 0 {
+		return 0, fmt.Errorf("%s: [%w]", errPrefix, ErrNoSdi)
+	}
+
+	physicalPageSize, err := file.GetPhysicalPageSize()
+	if err != nil {
+		return 0, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	extentPages, err := file.GetExtentPages()
+	if err != nil {
+		return 0, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	page, err := file.ReadPage(0)
+	if err != nil {
+		return 0, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	offset := getSdiOffset(physicalPageSize, extentPages)
+	version, err := page.getUint32(offset + sdiOffsetVersion)
+	if err != nil {
+		return 0, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "SDI_VERSION"))
+	}
+	if version != sdiVersion {
+		err := fmt.Errorf("%w: SDI version %d", ErrNoSdi, version)
+		return 0, fmt.Errorf("%s: [%w]", errPrefix, newPageError(0, offset + sdiOffsetVersion, "SDI_VERSION", err))
+	}
+
+	rootPageNo, err := page.getUint32(offset + sdiOffsetRootPageNo)
+	if err != nil {
+		return 0, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "SDI_ROOT_PAGE_NO"))
+	}
+
+	return rootPageNo, nil
+}
+
+// ReadSdi 读取 SDI 索引中的所有记录：从根页沿最左边的目录项下降到叶子节点，再沿 FIL_PAGE_NEXT 遍历叶子节点。
+// 压缩表空间中的 SDI 页也是压缩页，不支持解析，返回 ErrCompressedPageUnsupported
+func (file *File)ReadSdi() ([]SdiRecord, error) {
+	errPrefix := "File::ReadSdi()"
+
+	rootPageNo, err := file.GetSdiRootPageNo()
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	compressed, err := file.IsCompressed()
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	if compressed {
+		return nil, fmt.Errorf("%s: [%w: SDI pages in a compressed tablespace]", errPrefix, ErrCompressedPageUnsupported)
+	}
+
+	sdiRecords := []SdiRecord{}
+	decoder := btreeDecoder{
+		pageType: pageTypeSdi,
+		childPageNo: func(page BTreePage) (uint32, bool, error) {
+			records, err := page.getCompactRecords()
+			if err != nil || len(records) == 0 {
+				return 0, false, err
+			}
+
+			childPageNo, err := page.filePage.getUint32(records[0].origin + sdiRecOffsetChildPageNo)
+			if err != nil {
+				return 0, false, withField(err, "SDI_CHILD_PAGE_NO")
+			}
+
+			return childPageNo, true, nil
+		},
+		visitLeaf: func(page BTreePage) error {
+			records, err := page.getCompactRecords()
+			if err != nil {
+				return err
+			}
+
+			for _, header := range records {
+				if header.isDeleted() || header.status != recordStatusOrdinary {
+					continue
+				}
+
+				record, err := file.readSdiRecord(page.filePage, header.origin)
+				if err != nil {
+					return err
+				}
+				sdiRecords = append(sdiRecords, record)
+			}
+
+			return nil
+		},
+	}
+	if err := file.walkBTreeLeaves(rootPageNo, decoder); err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return sdiRecords, nil
+}
+
+// readSdiRecord 解析叶子节点中的一条 SDI 记录并解压数据，数据较大时存储在 SDI BLOB 页中
+func (file *File)readSdiRecord(page *Page, origin uint32) (SdiRecord, error) {
+	errPrefix := "File::readSdiRecord()"
+
+	record := SdiRecord{}
+
+	recordType, err := page.getUint32(origin + sdiRecOffsetType)
+	if err != nil {
+		return record, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "SDI_TYPE"))
+	}
+	record.Type = recordType
+
+	id, err := page.getUint64(origin + sdiRecOffsetId)
+	if err != nil {
+		return record, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "SDI_ID"))
+	}
+	record.Id = id
+
+	uncompressedLength, err := page.getUint32(origin + sdiRecOffsetUncompressedLen)
+	if err != nil {
+		return record, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "SDI_UNCOMPRESSED_LEN"))
+	}
+	record.UncompressedLength = uncompressedLength
+
+	compressedLength, err := page.getUint32(origin + sdiRecOffsetCompressedLen)
+	if err != nil {
+		return record, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "SDI_COMPRESSED_LEN"))
+	}
+	record.CompressedLength = compressedLength
+
+	// data 是唯一的变长字段，长度在记录头之前
+	length, external, _, err := page.getVarFieldLength(origin - recordExtraBytes - 1, true)
+	if err != nil {
+		return record, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	local, err := page.getBytes(origin + sdiRecOffsetData, length)
+	if err != nil {
+		return record, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "SDI_DATA"))
+	}
+
+	compressed := local
+	if external {
+		if length < externalFieldRefSize {
+			err := fmt.Errorf("external field has only %d local bytes", length)
+			return record, fmt.Errorf("%s: [%w]", errPrefix, newPageError(page.pageNo, origin + sdiRecOffsetData, "SDI_DATA", err))
+		}
+
+		ref := local[length - externalFieldRefSize:]
+		externalData, err := file.readExternalField(ref)
+		if err != nil {
+			return record, fmt.Errorf("%s: [%w]", errPrefix, err)
+		}
+		compressed = append(append([]byte(nil), local[:length - externalFieldRefSize]...), externalData...)
+	}
+
+	if uint32(len(compressed)) != compressedLength {
+		err := fmt.Errorf("compressed data is %d bytes, expected %d", len(compressed), compressedLength)
+		return record, fmt.Errorf("%s: [%w]", errPrefix, newPageError(page.pageNo, origin + sdiRecOffsetData, "SDI_DATA", err))
+	}
+
+	reader, err := zlib.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return record, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return record, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	if uint32(len(data)) != uncompressedLength {
+		return record, fmt.Errorf("%s: [uncompressed data is %d bytes, expected %d]", errPrefix, len(data), uncompressedLength)
+	}
+	record.Data = data
+
+	return record, nil
+}
+
+// WriteSdiJson 按 ibd2sdi 的格式输出所有 SDI 记录
+func WriteSdiJson(w io.Writer, records []SdiRecord) error {
+	errPrefix := "WriteSdiJson()"
+
+	out := bufio.NewWriter(w)
+	fmt.Fprintf(out, "[\"ibd2sdi\"\n")
+	for _, record := range records {
+		var object bytes.Buffer
+		if err := json.Indent(&object, record.Data, "", "    "); err != nil {
+			return fmt.Errorf("%s: [type %d, id %d: %w]", errPrefix, record.Type, record.Id, err)
+		}
+
+		fmt.Fprintf(out, ",\n{\n\t\"type\": %d,\n\t\"id\": %d,\n\t\"object\":\n\t\t", record.Type, record.Id)
+		out.Write(object.Bytes())
+		fmt.Fprintf(out, "\n}\n")
+	}
+	fmt.Fprintf(out, "]\n")
+
+	if err := out.Flush(); err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return nil
+}
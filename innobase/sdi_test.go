@@ -0,0 +1,153 @@
+package innobase
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"testing"
+)
+
+func TestGetSdiOffset(t *testing.T) {
+	tests := []struct {
+		name string
+		physicalPageSize uint32
+		extentPages uint32
+		want uint32
+	}{
+		// 与 ibd2sdi 中的 FSP_SDI 偏移量一致：XDES 数组之后再跳过 115 字节的加密信息
+		{name: "4k", physicalPageSize: 4096, extentPages: 256, want: 1673},
+		{name: "8k", physicalPageSize: 8192, extentPages: 128, want: 3849},
+		{name: "16k", physicalPageSize: 16384, extentPages: 64, want: 10505},
+		{name: "32k", physicalPageSize: 32768, extentPages: 64, want: 20745},
+		{name: "64k", physicalPageSize: 65536, extentPages: 64, want: 41225},
+		{name: "compressed 8k in 16k extents", physicalPageSize: 8192, extentPages: 64, want: 5385},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getSdiOffset(tt.physicalPageSize, tt.extentPages); got != tt.want {
+				t.Fatalf("getSdiOffset(%d, %d) = %d, want %d", tt.physicalPageSize, tt.extentPages, got, tt.want)
+			}
+		})
+	}
+}
+
+// testSdiCompress 用 zlib 压缩 SDI 的 JSON
+func testSdiCompress(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+	writer := zlib.NewWriter(&buf)
+	if _, err := writer.Write(data); err != nil {
+		t.Fatalf("compress: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("compress: %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+// putTestSdiRecord 在第 1 页的 origin 处写入 SDI 记录的定长字段和本地数据，data 的长度写在记录头之前，
+// external 时长度的第一个字节带外部存储标志
+func putTestSdiRecord(data []byte, origin int, id uint64, uncompressedLen uint32, compressedLen uint32, local []byte, external bool) {
+	page := data[testPageSize:]
+	binary.BigEndian.PutUint32(page[origin + int(sdiRecOffsetType):], SdiTypeTable)
+	binary.BigEndian.PutUint64(page[origin + int(sdiRecOffsetId):], id)
+	binary.BigEndian.PutUint32(page[origin + int(sdiRecOffsetUncompressedLen):], uncompressedLen)
+	binary.BigEndian.PutUint32(page[origin + int(sdiRecOffsetCompressedLen):], compressedLen)
+	copy(page[origin + int(sdiRecOffsetData):], local)
+
+	first := byte(0x80 | len(local) >> 8)
+	if external {
+		first |= 0x40
+	}
+	page[origin - 6] = first
+	page[origin - 7] = byte(len(local))
+}
+
+func TestReadSdiRecord(t *testing.T) {
+	json := []byte(`{"dd_object_type": "Table", "dd_object": {"name": "t1", "columns": []}}`)
+	compressed := testSdiCompress(t, json)
+	origin := testRecordOrigin
+
+	tests := []struct {
+		name string
+		build func(data []byte)
+		wantErr bool
+	}{
+		{
+			name: "inline",
+			build: func(data []byte) {
+				putTestSdiRecord(data, origin, 7, uint32(len(json)), uint32(len(compressed)), compressed, false)
+			},
+		},
+		{
+			name: "external",
+			build: func(data []byte) {
+				// 记录中保留前 10 字节和外部引用，其余数据分两个 BLOB 页存储
+				rest := compressed[10:]
+				half := len(rest) / 2
+				local := append(append([]byte(nil), compressed[:10]...), newTestExternalRef(2, uint32(fileHeaderSize), uint32(len(rest)))...)
+				putTestSdiRecord(data, origin, 7, uint32(len(json)), uint32(len(compressed)), local, true)
+				putTestBlobPage(data, 2, rest[:half], 3)
+				putTestBlobPage(data, 3, rest[half:], fileNull)
+			},
+		},
+		{
+			name: "compressed length mismatch",
+			build: func(data []byte) {
+				putTestSdiRecord(data, origin, 7, uint32(len(json)), uint32(len(compressed)) + 1, compressed, false)
+			},
+			wantErr: true,
+		},
+		{
+			name: "uncompressed length mismatch",
+			build: func(data []byte) {
+				putTestSdiRecord(data, origin, 7, uint32(len(json)) - 1, uint32(len(compressed)), compressed, false)
+			},
+			wantErr: true,
+		},
+		{
+			name: "external field too short",
+			build: func(data []byte) {
+				putTestSdiRecord(data, origin, 7, uint32(len(json)), uint32(len(compressed)), compressed[:10], true)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := newTestFile(4, tt.build)
+			defer file.Close()
+
+			page, err := file.ReadPage(1)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			record, err := file.readSdiRecord(page, uint32(origin))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", record)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if record.Type != SdiTypeTable || record.Id != 7 || !bytes.Equal(record.Data, json) {
+				t.Fatalf("record = {Type: %d, Id: %d, Data: %s}", record.Type, record.Id, record.Data)
+			}
+
+			object, err := record.GetObject()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			table, err := object.GetTable()
+			if err != nil || table.Name != "t1" {
+				t.Fatalf("table = %+v, error = %v", table, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,360 @@
+package innobase
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"sync"
+)
+
+// Source 表空间文件的数据来源，只要求支持随机读取并且大小已知，
+// 可以是磁盘文件、内存中的字节、gzip 压缩文件或 tar 包中的成员文件
+type Source interface {
+	io.ReaderAt
+	io.Closer
+	Name() string // 数据来源的名称，用于输出
+	Size() int64 // 数据的总字节数
+}
+
+// sliceSource 可以直接返回内部数据切片的数据来源（如 mmap），
+// File 读取页时不再复制数据，返回的切片是只读的
+type sliceSource interface {
+	Slice(offset int64, size int) ([]byte, bool)
+}
+
+// sequentialSource 只能高效地顺序读取的数据来源（如 gzip），向前读取时代价很高，
+// 并发扫描时只能用一个协程按页号顺序读取
+type sequentialSource interface {
+	Sequential() bool
+}
+
+func isSequentialSource(source Source) bool {
+	sequential, ok := source.(sequentialSource)
+	return ok && sequential.Sequential()
+}
+
+// readerAtSource 把任意 io.ReaderAt 包装成 Source
+type readerAtSource struct {
+	name string
+	reader io.ReaderAt
+	size int64
+	closer io.Closer
+}
+
+func NewReaderAtSource(name string, reader io.ReaderAt, size int64) Source {
+	source := &readerAtSource{
+		name: name,
+		reader: reader,
+		size: size,
+	}
+
+	if closer, ok := reader.(io.Closer); ok {
+		source.closer = closer
+	}
+
+	return source
+}
+
+func NewBytesSource(name string, data []byte) Source {
+	return NewReaderAtSource(name, bytes.NewReader(data), int64(len(data)))
+}
+
+func (source *readerAtSource)ReadAt(p []byte, offset int64) (int, error) {
+	return source.reader.ReadAt(p, offset)
+}
+
+func (source *readerAtSource)Name() string {
+	return source.name
+}
+
+func (source *readerAtSource)Size() int64 {
+	return source.size
+}
+
+func (source *readerAtSource)Close() error {
+	if source.closer == nil {
+		return nil
+	}
+
+	return source.closer.Close()
+}
+
+// fileSource 磁盘上的普通文件
+type fileSource struct {
+	path string
+	fileHandler *os.File
+	size int64
+}
+
+func NewFileSource(path string) (Source, error) {
+	errPrefix := "NewFileSource()"
+
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, ErrEmptyPath)
+	}
+
+	fp, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	fileInfo, err := fp.Stat()
+	if err != nil {
+		_ = fp.Close()
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return &fileSource{
+		path: path,
+		fileHandler: fp,
+		size: fileInfo.Size(),
+	}, nil
+}
+
+func (source *fileSource)ReadAt(p []byte, offset int64) (int, error) {
+	return source.fileHandler.ReadAt(p, offset)
+}
+
+func (source *fileSource)Name() string {
+	return source.path
+}
+
+func (source *fileSource)Size() int64 {
+	return source.size
+}
+
+func (source *fileSource)Close() error {
+	return source.fileHandler.Close()
+}
+
+// gzipSource gzip 压缩的表空间文件，不解压到磁盘。
+// gzip 不支持随机读取，向后读取时继续解压，向前读取时从头重新解压，
+// 按页号顺序扫描时每个字节只解压一次
+type gzipSource struct {
+	compressed Source
+	size int64
+
+	mutex sync.Mutex
+	reader *gzip.Reader
+	offset int64 // reader 当前的解压位置
+}
+
+// NewGzipSource 需要完整解压一遍来计算解压后的大小（gzip 尾部的 ISIZE 只有低 32 位）
+func NewGzipSource(compressed Source) (Source, error) {
+	errPrefix := "NewGzipSource()"
+
+	source := &gzipSource{
+		compressed: compressed,
+	}
+
+	if err := source.reset(); err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	size, err := io.Copy(ioutil.Discard, source.reader)
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	source.size = size
+
+	if err := source.reset(); err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return source, nil
+}
+
+func (source *gzipSource)reset() error {
+	compressed := io.NewSectionReader(source.compressed, 0, source.compressed.Size())
+	reader, err := gzip.NewReader(bufio.NewReader(compressed))
+	if err != nil {
+		return err
+	}
+
+	source.reader = reader
+	source.offset = 0
+
+	return nil
+}
+
+func (source *gzipSource)ReadAt(p []byte, offset int64) (int, error) {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+
+	if offset < 0 {
+		return 0, fmt.Errorf("gzipSource::ReadAt(): [negative offset %d]", offset)
+	}
+	if offset >= source.size {
+		return 0, io.EOF
+	}
+
+	if offset < source.offset {
+		if err := source.reset(); err != nil {
+			return 0, err
+		}
+	}
+
+	if offset > source.offset {
+		skipped, err := io.CopyN(ioutil.Discard, source.reader, offset - source.offset)
+		source.offset += skipped
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := io.ReadFull(source.reader, p)
+	source.offset += int64(n)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+
+	return n, err
+}
+
+func (source *gzipSource)Sequential() bool {
+	return true
+}
+
+func (source *gzipSource)Name() string {
+	return source.compressed.Name()
+}
+
+func (source *gzipSource)Size() int64 {
+	return source.size
+}
+
+func (source *gzipSource)Close() error {
+	return source.compressed.Close()
+}
+
+// tarMemberSource tar 包中的一个成员文件，成员数据在 tar 包中是连续存放的，
+// 直接映射到 tar 包中的一段区间
+type tarMemberSource struct {
+	name string
+	archive Source
+	section *io.SectionReader
+}
+
+// NewTarMemberSource 在 tar 包中查找成员文件，member 为 tar 包中的路径，
+// 开头的 "./" 可以省略
+func NewTarMemberSource(archive Source, member string) (Source, error) {
+	errPrefix := "NewTarMemberSource()"
+
+	member = cleanTarPath(member)
+	archiveReader := io.NewSectionReader(archive, 0, archive.Size())
+	reader := tar.NewReader(archiveReader)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("%s: [member %s not found in %s]", errPrefix, member, archive.Name())
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+		}
+
+		if cleanTarPath(header.Name) != member {
+			continue
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			return nil, fmt.Errorf("%s: [member %s is not a regular file (type %c)]", errPrefix, member, header.Typeflag)
+		}
+
+		// tar.Reader 读完头信息后，archiveReader 正好位于成员数据的起始位置
+		offset, err := archiveReader.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+		}
+
+		return &tarMemberSource{
+			name: archive.Name() + ":" + member,
+			archive: archive,
+			section: io.NewSectionReader(archive, offset, header.Size),
+		}, nil
+	}
+}
+
+func cleanTarPath(name string) string {
+	return strings.TrimPrefix(path.Clean("/" + name), "/")
+}
+
+func (source *tarMemberSource)ReadAt(p []byte, offset int64) (int, error) {
+	return source.section.ReadAt(p, offset)
+}
+
+// Sequential .tar.gz 中的成员文件与 gzip 文件一样只能顺序读取
+func (source *tarMemberSource)Sequential() bool {
+	return isSequentialSource(source.archive)
+}
+
+func (source *tarMemberSource)Name() string {
+	return source.name
+}
+
+func (source *tarMemberSource)Size() int64 {
+	return source.section.Size()
+}
+
+func (source *tarMemberSource)Close() error {
+	return source.archive.Close()
+}
+
+// OpenSource 根据文件后缀打开数据来源，.gz 文件按 gzip 解压读取
+func OpenSource(path string) (Source, error) {
+	errPrefix := "OpenSource()"
+
+	path = strings.TrimSpace(path)
+	source, err := openSource(path, strings.HasSuffix(path, ".gz"))
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return source, nil
+}
+
+// OpenTarMember 打开 tar 包（支持 .tar、.tar.gz、.tgz）中的成员文件
+func OpenTarMember(archivePath string, member string) (Source, error) {
+	errPrefix := "OpenTarMember()"
+
+	archivePath = strings.TrimSpace(archivePath)
+	gzipped := strings.HasSuffix(archivePath, ".gz") || strings.HasSuffix(archivePath, ".tgz")
+	archive, err := openSource(archivePath, gzipped)
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	source, err := NewTarMemberSource(archive, member)
+	if err != nil {
+		_ = archive.Close()
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return source, nil
+}
+
+func openSource(path string, gzipped bool) (Source, error) {
+	source, err := NewFileSource(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !gzipped {
+		return source, nil
+	}
+
+	gzSource, err := NewGzipSource(source)
+	if err != nil {
+		_ = source.Close()
+		return nil, err
+	}
+
+	return gzSource, nil
+}
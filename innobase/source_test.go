@@ -0,0 +1,204 @@
+package innobase
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+)
+
+func testGzip(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+// testTarMember tar 包中的一个成员，data 为 nil 时写入目录
+type testTarMember struct {
+	name string
+	data []byte
+}
+
+func testTar(t *testing.T, members []testTarMember) []byte {
+	var buf bytes.Buffer
+	writer := tar.NewWriter(&buf)
+	for _, member := range members {
+		header := &tar.Header{Name: member.name, Mode: 0640, Size: int64(len(member.data)), Typeflag: tar.TypeReg}
+		if member.data == nil {
+			header.Typeflag = tar.TypeDir
+		}
+		if err := writer.WriteHeader(header); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := writer.Write(member.data); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+// testSourceRead 一次 ReadAt，依次执行时覆盖向后跳过、向前重新读取和读到末尾的情况
+type testSourceRead struct {
+	offset int64
+	length int
+	wantN int
+	wantEOF bool
+}
+
+func testSourceReads(size int) []testSourceRead {
+	return []testSourceRead{
+		{offset: 100, length: 200, wantN: 200},
+		{offset: 300, length: 100, wantN: 100},
+		{offset: int64(size) / 2, length: 1000, wantN: 1000},
+		{offset: 10, length: 50, wantN: 50},
+		{offset: int64(size) - 30, length: 100, wantN: 30, wantEOF: true},
+		{offset: 0, length: size, wantN: size},
+		{offset: int64(size), length: 10, wantN: 0, wantEOF: true},
+		{offset: int64(size) + 100, length: 10, wantN: 0, wantEOF: true},
+	}
+}
+
+// checkSourceReads 按顺序执行 reads，比较读到的数据与 want 中对应的区间
+func checkSourceReads(t *testing.T, source Source, want []byte) {
+	if source.Size() != int64(len(want)) {
+		t.Fatalf("size = %d, want %d", source.Size(), len(want))
+	}
+
+	for _, read := range testSourceReads(len(want)) {
+		buf := make([]byte, read.length)
+		n, err := source.ReadAt(buf, read.offset)
+		if n != read.wantN {
+			t.Fatalf("ReadAt(%d bytes at %d) = %d bytes, want %d", read.length, read.offset, n, read.wantN)
+		}
+		if read.wantEOF && err != io.EOF {
+			t.Fatalf("ReadAt(%d bytes at %d) error = %v, want EOF", read.length, read.offset, err)
+		}
+		if !read.wantEOF && err != nil {
+			t.Fatalf("ReadAt(%d bytes at %d): unexpected error: %v", read.length, read.offset, err)
+		}
+		if n > 0 && !bytes.Equal(buf[:n], want[read.offset:read.offset + int64(n)]) {
+			t.Fatalf("ReadAt(%d bytes at %d) returned different data", read.length, read.offset)
+		}
+	}
+}
+
+func TestGzipSource(t *testing.T) {
+	data := newTestChecksumData(3 * testPageSize + 123)
+
+	source, err := NewGzipSource(NewBytesSource("test.ibd.gz", testGzip(t, data)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer source.Close()
+
+	if !isSequentialSource(source) {
+		t.Fatalf("gzip source should be sequential")
+	}
+	if source.Name() != "test.ibd.gz" {
+		t.Fatalf("name = %q, want %q", source.Name(), "test.ibd.gz")
+	}
+	checkSourceReads(t, source, data)
+
+	if _, err := source.ReadAt(make([]byte, 10), -1); err == nil {
+		t.Fatalf("expected an error for a negative offset")
+	}
+
+	if _, err := NewGzipSource(NewBytesSource("test.ibd.gz", data)); err == nil {
+		t.Fatalf("expected an error for data that is not gzip")
+	}
+}
+
+func TestTarMemberSource(t *testing.T) {
+	first := newTestChecksumData(2 * testPageSize)
+	second := testFill(7, 5000)
+	archive := testTar(t, []testTarMember{
+		{name: "./backup/", data: nil},
+		{name: "./backup/ibdata1", data: first},
+		{name: "backup/db/t.ibd", data: second},
+	})
+
+	tests := []struct {
+		name string
+		gzipped bool
+		member string
+		want []byte
+		wantName string
+		wantErr bool
+	}{
+		{name: "member with ./ prefix", member: "backup/ibdata1", want: first, wantName: "test.tar:backup/ibdata1"},
+		{name: "lookup with ./ prefix", member: "./backup/db/t.ibd", want: second, wantName: "test.tar:backup/db/t.ibd"},
+		{name: "lookup with redundant separators", member: "/backup//db/./t.ibd", want: second, wantName: "test.tar:backup/db/t.ibd"},
+		{name: "gzipped archive", gzipped: true, member: "backup/db/t.ibd", want: second, wantName: "test.tar:backup/db/t.ibd"},
+		{name: "missing member", member: "backup/db/t2.ibd", wantErr: true},
+		{name: "missing member in gzipped archive", gzipped: true, member: "t.ibd", wantErr: true},
+		{name: "directory", member: "backup", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var archiveSource Source = NewBytesSource("test.tar", archive)
+			if tt.gzipped {
+				var err error
+				archiveSource, err = NewGzipSource(NewBytesSource("test.tar", testGzip(t, archive)))
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			source, err := NewTarMemberSource(archiveSource, tt.member)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got a source of %d bytes", source.Size())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer source.Close()
+
+			if source.Name() != tt.wantName {
+				t.Fatalf("name = %q, want %q", source.Name(), tt.wantName)
+			}
+			if isSequentialSource(source) != tt.gzipped {
+				t.Fatalf("sequential = %t, want %t", isSequentialSource(source), tt.gzipped)
+			}
+			checkSourceReads(t, source, tt.want)
+		})
+	}
+}
+
+func TestCleanTarPath(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"ibdata1", "ibdata1"},
+		{"./ibdata1", "ibdata1"},
+		{"/ibdata1", "ibdata1"},
+		{"backup/", "backup"},
+		{"./backup//db/./t.ibd", "backup/db/t.ibd"},
+		{"backup/../db/t.ibd", "db/t.ibd"},
+		{"../../t.ibd", "t.ibd"},
+		{".", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := cleanTarPath(tt.name); got != tt.want {
+			t.Fatalf("cleanTarPath(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package innobase
+
+import "fmt"
+
+// SegmentSpace 一个段实际占用的空间，区的数量来自段的三个区链表，已使用的页数量由区描述符中的位图统计
+type SegmentSpace struct {
+	SegmentId uint64
+	Inode FileAddress
+	FragPages uint32 // 碎片页数量
+	FullExtents uint32
+	NotFullExtents uint32
+	FreeExtents uint32
+	NotFullNUsed uint32 // inode 中记录的 NOT_FULL 区已使用的页数量
+	NotFullUsedPages uint32 // 位图中统计的 NOT_FULL 区已使用的页数量
+	AllocatedPages uint32 // 分配给段的页数量（区中的所有页和碎片页）
+	UsedPages uint32 // 段中已使用的页数量
+}
+
+// IndexSpace 一个索引的叶子节点段和非叶子节点段占用的空间
+type IndexSpace struct {
+	IndexId uint64
+	RootPageNo uint32
+	Leaf SegmentSpace
+	NonLeaf SegmentSpace
+}
+
+// GetFillFactor 已使用的页占分配的页的百分比
+func (segment *SegmentSpace)GetFillFactor() float64 {
+	if segment.AllocatedPages == 0 {
+		return 0
+	}
+
+	return float64(segment.UsedPages) * 100 / float64(segment.AllocatedPages)
+}
+
+func (index *IndexSpace)GetAllocatedPages() uint32 {
+	return index.Leaf.AllocatedPages + index.NonLeaf.AllocatedPages
+}
+
+func (index *IndexSpace)GetUsedPages() uint32 {
+	return index.Leaf.UsedPages + index.NonLeaf.UsedPages
+}
+
+// ReadSegmentSpace 统计 inode 描述的段占用的空间，会遍历段的三个区链表
+func (file *File)ReadSegmentSpace(inode Inode) (SegmentSpace, error) {
+	errPrefix := "File::ReadSegmentSpace()"
+
+	extentPages, err := file.GetExtentPages()
+	if err != nil {
+		return SegmentSpace{}, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	segment := SegmentSpace{
+		SegmentId: inode.SegmentId,
+		Inode: inode.Address,
+		FragPages: uint32(len(inode.GetFragPages())),
+		NotFullNUsed: inode.NotFullNUsed,
+	}
+
+	lists := []struct {
+		field string
+		base ListBaseNode
+		count *uint32
+	}{
+		{"FSEG_FULL", inode.Full, &segment.FullExtents},
+		{"FSEG_NOT_FULL", inode.NotFull, &segment.NotFullExtents},
+		{"FSEG_FREE", inode.Free, &segment.FreeExtents},
+	}
+	for _, list := range lists {
+		extents, err := file.ReadExtentList(list.base)
+		if err != nil {
+			return SegmentSpace{}, fmt.Errorf("%s: [%s: %w]", errPrefix, list.field, err)
+		}
+		*list.count = uint32(len(extents))
+
+		if list.field == "FSEG_NOT_FULL" {
+			for i := range extents {
+				segment.NotFullUsedPages += extents[i].GetUsedPages()
+			}
+		}
+	}
+
+	extentCount := segment.FullExtents + segment.NotFullExtents + segment.FreeExtents
+	segment.AllocatedPages = extentCount * extentPages + segment.FragPages
+	segment.UsedPages = segment.FullExtents * extentPages + segment.NotFullUsedPages + segment.FragPages
+
+	return segment, nil
+}
+
+// ReadIndexSpaces 统计表空间中每个索引占用的空间，索引的根页通过 FindIndexRoots() 用 workers 个协程查找
+func (file *File)ReadIndexSpaces(workers int) ([]IndexSpace, error) {
+	errPrefix := "File::ReadIndexSpaces()"
+
+	roots, err := file.FindIndexRoots(workers)
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	indexes := make([]IndexSpace, 0, len(roots))
+	for _, rootPageNo := range roots {
+		segments, err := file.ReadIndexSegments(rootPageNo)
+		if err != nil {
+			return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+		}
+
+		leaf, err := file.ReadSegmentSpace(segments.Leaf)
+		if err != nil {
+			return nil, fmt.Errorf("%s: [leaf segment of index %d: %w]", errPrefix, segments.IndexId, err)
+		}
+
+		nonLeaf, err := file.ReadSegmentSpace(segments.NonLeaf)
+		if err != nil {
+			return nil, fmt.Errorf("%s: [non-leaf segment of index %d: %w]", errPrefix, segments.IndexId, err)
+		}
+
+		indexes = append(indexes, IndexSpace{
+			IndexId: segments.IndexId,
+			RootPageNo: rootPageNo,
+			Leaf: leaf,
+			NonLeaf: nonLeaf,
+		})
+	}
+
+	return indexes, nil
+}
+
+func printSegmentSpace(index IndexSpace, name string, segment SegmentSpace) {
+	fmt.Printf("%-10d %-6d %-10s %-10d %-10d %-10d %-6d %-6d %-9d %-6d %.2f%%\n",
+		index.IndexId, index.RootPageNo, name, segment.SegmentId, segment.AllocatedPages, segment.UsedPages,
+		segment.FragPages, segment.FullExtents, segment.NotFullExtents, segment.FreeExtents, segment.GetFillFactor())
+
+	if segment.NotFullUsedPages != segment.NotFullNUsed {
+		fmt.Printf("    warning: FSEG_NOT_FULL_N_USED = %d, but the extent bitmaps show %d used pages\n",
+			segment.NotFullNUsed, segment.NotFullUsedPages)
+	}
+}
@@ -0,0 +1,490 @@
+package innobase
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"sort"
+)
+
+const (
+	mapSymbolFree byte = '.' // 空闲页
+	mapSymbolReserved byte = ',' // 段已经预留但还没有使用的页
+	mapSymbolMisplaced byte = '!' // 页中存储的页号与页的位置不一致
+	mapSymbolUnknown byte = '?'
+
+	mapSvgCellSize = 10 // SVG 中每个页的边长
+	mapSvgLabelWidth = 80 // SVG 中每行左侧页号的宽度
+	mapSvgLegendLineHeight = 16
+)
+
+// 索引页用字母表示，每个索引一个字母，超出后用 '#'
+const mapIndexSymbols = "ABCDEGHJKMNOPQRSVWYabcdeghjkmnopqrsvwy"
+
+// 各类型页的符号和颜色，INDEX 页的符号和颜色由所属索引决定
+var pageTypeSymbolMap = map[uint16]byte {
+	pageTypeAllocated: '_',
+	pageTypeUndoLog: 'u',
+	pageTypeInode: 'i',
+	pageTypeIBufFreeList: 'f',
+	pageTypeIBufBitmap: 'b',
+	pageTypeSys: 's',
+	pageTypeTrxSys: 't',
+	pageTypeRsegArray: 'U',
+	pageTypeFSP: 'F',
+	pageTypeXDES: 'X',
+	pageTypeBlob: 'L',
+	pageTypeZBlob: 'Z',
+	pageTypeZBlob2: 'z',
+	pageTypeCompressed: 'C',
+	pageTypeEncrptyed: 'E',
+	pageTypeCompressedAndEncrypted: 'E',
+	pageTypeEncryptedRTree: 'E',
+	pageTypeSdiBlob: 'L',
+	pageTypeSdiZBlob: 'Z',
+	pageTypeLobIndex: 'L',
+	pageTypeLobData: 'L',
+	pageTypeLobFirst: 'L',
+	pageTypeSdi: '$',
+	pageTypeRTree: 'T',
+}
+
+// ANSI 256 色
+var pageTypeAnsiColorMap = map[uint16]int {
+	pageTypeAllocated: 250,
+	pageTypeUndoLog: 136,
+	pageTypeInode: 99,
+	pageTypeIBufFreeList: 66,
+	pageTypeIBufBitmap: 66,
+	pageTypeSys: 244,
+	pageTypeTrxSys: 244,
+	pageTypeRsegArray: 136,
+	pageTypeFSP: 208,
+	pageTypeXDES: 208,
+	pageTypeBlob: 172,
+	pageTypeZBlob: 172,
+	pageTypeZBlob2: 172,
+	pageTypeSdiBlob: 172,
+	pageTypeSdiZBlob: 172,
+	pageTypeLobIndex: 172,
+	pageTypeLobData: 172,
+	pageTypeLobFirst: 172,
+	pageTypeSdi: 141,
+}
+
+var pageTypeSvgColorMap = map[uint16]string {
+	pageTypeAllocated: "#d0d0d0",
+	pageTypeUndoLog: "#af8700",
+	pageTypeInode: "#875fff",
+	pageTypeIBufFreeList: "#5f8787",
+	pageTypeIBufBitmap: "#5f8787",
+	pageTypeSys: "#808080",
+	pageTypeTrxSys: "#808080",
+	pageTypeRsegArray: "#af8700",
+	pageTypeFSP: "#ff8700",
+	pageTypeXDES: "#ff8700",
+	pageTypeBlob: "#d78700",
+	pageTypeZBlob: "#d78700",
+	pageTypeZBlob2: "#d78700",
+	pageTypeSdiBlob: "#d78700",
+	pageTypeSdiZBlob: "#d78700",
+	pageTypeLobIndex: "#d78700",
+	pageTypeLobData: "#d78700",
+	pageTypeLobFirst: "#d78700",
+	pageTypeSdi: "#af87af",
+}
+
+// 索引依次使用的颜色
+var mapIndexAnsiColors = []int{27, 34, 166, 127, 37, 142, 61, 160, 31, 70}
+var mapIndexSvgColors = []string{"#005fff", "#00af00", "#d75f00", "#af00af", "#00afaf", "#afaf00", "#5f5faf", "#d70000", "#0087af", "#5faf00"}
+
+const (
+	mapSvgColorFree = "#ffffff"
+	mapSvgColorReserved = "#f0f0c0"
+	mapSvgColorMisplaced = "#ff0000"
+	mapSvgColorUnknown = "#000000"
+)
+
+// MapPage 表空间地图中的一个页
+type MapPage struct {
+	PageType uint16
+	SpaceId uint32 // 页中存储的表空间 ID
+	IndexId uint64 // 只有 INDEX 页有值
+	Allocated bool // 区描述符中的位图显示页已分配
+	Reserved bool // 页空闲，但所在的区属于某个段
+	Misplaced bool
+	Corrupted bool
+}
+
+// SpaceMap 整个表空间每个页的类型、所属索引和分配状态，按区分行输出
+type SpaceMap struct {
+	Path string
+	ExtentPages uint32
+	Pages []MapPage
+	IndexIds []uint64 // 表空间中出现的索引 ID，按 ID 排序
+}
+
+// spaceMapVisitor 扫描时填充 SpaceMap.Pages，各协程处理的页号区间不重叠，
+// 写入同一个切片的不同元素，不需要合并
+type spaceMapVisitor struct {
+	pages []MapPage
+}
+
+func (visitor *spaceMapVisitor)visitPage(filePage *Page) error {
+	errPrefix := "spaceMapVisitor::visitPage()"
+
+	entry := &visitor.pages[filePage.GetPosition()]
+
+	// 与 Stats() 一样，页号不一致的页无法确定类型
+	if err := filePage.CheckPageNo(); err != nil {
+		if !errors.Is(err, ErrPageNoMismatch) {
+			return fmt.Errorf("%s: [%w]", errPrefix, err)
+		}
+		entry.Misplaced = true
+		return nil
+	}
+
+	pageType, err := filePage.GetPageType()
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	entry.PageType = pageType
+
+	spaceId, err := filePage.GetSpaceId()
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	entry.SpaceId = spaceId
+
+	checksum, err := filePage.VerifyChecksum()
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	entry.Corrupted = !checksum.Valid
+
+	if pageType != pageTypeIndex {
+		return nil
+	}
+
+	page := NewBTreePage(filePage)
+	indexId, err := page.GetIndexId()
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	entry.IndexId = indexId
+
+	return nil
+}
+
+// ReadSpaceMap 用 workers 个协程扫描整个表空间，并结合区描述符生成表空间地图
+func (file *File)ReadSpaceMap(workers int) (*SpaceMap, error) {
+	errPrefix := "File::ReadSpaceMap()"
+
+	pageCount, err := file.getPageCount()
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	allocation, err := file.ReadAllocationMap()
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	spaceMap := &SpaceMap{
+		Path: file.GetPath(),
+		ExtentPages: allocation.ExtentPages,
+		Pages: make([]MapPage, pageCount),
+	}
+
+	_, err = scanPages(file, workers, func() pageVisitor {
+		return &spaceMapVisitor{pages: spaceMap.Pages}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	indexIds := map[uint64]bool{}
+	for pageNo := range spaceMap.Pages {
+		entry := &spaceMap.Pages[pageNo]
+		entry.Allocated = allocation.IsPageAllocated(uint32(pageNo))
+		if !entry.Allocated {
+			if extent, exists := allocation.GetExtent(uint32(pageNo)); exists {
+				entry.Reserved = extent.State == xdesStateFseg || extent.State == xdesStateFsegFrag
+			}
+		}
+		if entry.PageType == pageTypeIndex && !entry.Misplaced {
+			indexIds[entry.IndexId] = true
+		}
+	}
+
+	for indexId := range indexIds {
+		spaceMap.IndexIds = append(spaceMap.IndexIds, indexId)
+	}
+	sort.Slice(spaceMap.IndexIds, func(i, j int) bool {
+		return spaceMap.IndexIds[i] < spaceMap.IndexIds[j]
+	})
+
+	return spaceMap, nil
+}
+
+func (spaceMap *SpaceMap)getIndexNo(indexId uint64) int {
+	return sort.Search(len(spaceMap.IndexIds), func(i int) bool {
+		return spaceMap.IndexIds[i] >= indexId
+	})
+}
+
+func getIndexSymbol(indexNo int) byte {
+	if indexNo < len(mapIndexSymbols) {
+		return mapIndexSymbols[indexNo]
+	}
+
+	return '#'
+}
+
+// getSymbol 页在地图中的符号：空闲页不论页类型都显示为空闲
+func (spaceMap *SpaceMap)getSymbol(entry MapPage) byte {
+	if entry.Misplaced {
+		return mapSymbolMisplaced
+	}
+	if !entry.Allocated {
+		if entry.Reserved {
+			return mapSymbolReserved
+		}
+		return mapSymbolFree
+	}
+	if entry.PageType == pageTypeIndex {
+		return getIndexSymbol(spaceMap.getIndexNo(entry.IndexId))
+	}
+	if symbol, exists := pageTypeSymbolMap[entry.PageType]; exists {
+		return symbol
+	}
+
+	return mapSymbolUnknown
+}
+
+func (spaceMap *SpaceMap)getAnsiColor(entry MapPage) (int, bool) {
+	if entry.Misplaced || !entry.Allocated {
+		return 0, false
+	}
+	if entry.PageType == pageTypeIndex {
+		return mapIndexAnsiColors[spaceMap.getIndexNo(entry.IndexId) % len(mapIndexAnsiColors)], true
+	}
+	color, exists := pageTypeAnsiColorMap[entry.PageType]
+
+	return color, exists
+}
+
+func (spaceMap *SpaceMap)getSvgColor(entry MapPage) string {
+	if entry.Misplaced {
+		return mapSvgColorMisplaced
+	}
+	if !entry.Allocated {
+		if entry.Reserved {
+			return mapSvgColorReserved
+		}
+		return mapSvgColorFree
+	}
+	if entry.PageType == pageTypeIndex {
+		return mapIndexSvgColors[spaceMap.getIndexNo(entry.IndexId) % len(mapIndexSvgColors)]
+	}
+	if color, exists := pageTypeSvgColorMap[entry.PageType]; exists {
+		return color
+	}
+
+	return mapSvgColorUnknown
+}
+
+// mapLegendItem 图例中的一项
+type mapLegendItem struct {
+	symbol byte
+	description string
+	svgColor string
+}
+
+// getLegend 地图中出现的符号及其含义
+func (spaceMap *SpaceMap)getLegend() []mapLegendItem {
+	legend := []mapLegendItem{}
+	for indexNo, indexId := range spaceMap.IndexIds {
+		legend = append(legend, mapLegendItem{
+			symbol: getIndexSymbol(indexNo),
+			description: fmt.Sprintf("index %d", indexId),
+			svgColor: mapIndexSvgColors[indexNo % len(mapIndexSvgColors)],
+		})
+	}
+
+	pageTypes := map[uint16]bool{}
+	free, reserved, misplaced := false, false, false
+	for _, entry := range spaceMap.Pages {
+		switch {
+		case entry.Misplaced:
+			misplaced = true
+		case !entry.Allocated && entry.Reserved:
+			reserved = true
+		case !entry.Allocated:
+			free = true
+		case entry.PageType != pageTypeIndex:
+			pageTypes[entry.PageType] = true
+		}
+	}
+
+	types := make([]int, 0, len(pageTypes))
+	for pageType := range pageTypes {
+		types = append(types, int(pageType))
+	}
+	sort.Ints(types)
+	for _, pageType := range types {
+		symbol, exists := pageTypeSymbolMap[uint16(pageType)]
+		if !exists {
+			symbol = mapSymbolUnknown
+		}
+		name, exists := pageTypeMap[uint16(pageType)]
+		if !exists {
+			name = fmt.Sprintf("page type %d", pageType)
+		}
+		svgColor, exists := pageTypeSvgColorMap[uint16(pageType)]
+		if !exists {
+			svgColor = mapSvgColorUnknown
+		}
+		legend = append(legend, mapLegendItem{symbol: symbol, description: name, svgColor: svgColor})
+	}
+
+	if reserved {
+		legend = append(legend, mapLegendItem{symbol: mapSymbolReserved, description: "reserved by a segment, not used", svgColor: mapSvgColorReserved})
+	}
+	if free {
+		legend = append(legend, mapLegendItem{symbol: mapSymbolFree, description: "free", svgColor: mapSvgColorFree})
+	}
+	if misplaced {
+		legend = append(legend, mapLegendItem{symbol: mapSymbolMisplaced, description: "misplaced", svgColor: mapSvgColorMisplaced})
+	}
+
+	return legend
+}
+
+// RenderAnsi 每个区输出一行，每个页一个字符，color 为 true 时使用 ANSI 颜色，检验和错误的页用红色背景
+func (spaceMap *SpaceMap)RenderAnsi(w io.Writer, color bool) error {
+	errPrefix := "SpaceMap::RenderAnsi()"
+
+	out := bufio.NewWriter(w)
+	fmt.Fprintf(out, "Space Map (%s, %d pages per extent):\n", spaceMap.Path, spaceMap.ExtentPages)
+
+	pageCount := uint32(len(spaceMap.Pages))
+	for first := uint32(0); first < pageCount; first += spaceMap.ExtentPages {
+		fmt.Fprintf(out, "%10d ", first)
+		for pageNo := first; pageNo < first + spaceMap.ExtentPages && pageNo < pageCount; pageNo++ {
+			entry := spaceMap.Pages[pageNo]
+			symbol := spaceMap.getSymbol(entry)
+			if !color {
+				out.WriteByte(symbol)
+				continue
+			}
+
+			ansiColor, exists := spaceMap.getAnsiColor(entry)
+			switch {
+			case entry.Corrupted:
+				fmt.Fprintf(out, "\x1b[97;41m%c\x1b[0m", symbol)
+			case exists:
+				fmt.Fprintf(out, "\x1b[38;5;%dm%c\x1b[0m", ansiColor, symbol)
+			default:
+				out.WriteByte(symbol)
+			}
+		}
+		out.WriteByte('\n')
+	}
+
+	fmt.Fprintf(out, "Legend:\n")
+	for _, item := range spaceMap.getLegend() {
+		fmt.Fprintf(out, "    %c: %s\n", item.symbol, item.description)
+	}
+	fmt.Fprintln(out)
+
+	if err := out.Flush(); err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return nil
+}
+
+// RenderSvg 输出独立的 SVG 文件，每个区一行，每个页一个方格，鼠标悬停时显示页号和类型
+func (spaceMap *SpaceMap)RenderSvg(w io.Writer) error {
+	errPrefix := "SpaceMap::RenderSvg()"
+
+	pageCount := uint32(len(spaceMap.Pages))
+	rows := (pageCount + spaceMap.ExtentPages - 1) / spaceMap.ExtentPages
+	legend := spaceMap.getLegend()
+
+	mapHeight := int(rows) * mapSvgCellSize
+	width := mapSvgLabelWidth + int(spaceMap.ExtentPages) * mapSvgCellSize + mapSvgCellSize
+	height := mapSvgLegendLineHeight * 2 + mapHeight + mapSvgLegendLineHeight * (len(legend) + 1)
+
+	out := bufio.NewWriter(w)
+	fmt.Fprintf(out, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	fmt.Fprintf(out, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" font-family=\"monospace\" font-size=\"10\">\n", width, height)
+	fmt.Fprintf(out, "<rect width=\"100%%\" height=\"100%%\" fill=\"#ffffff\"/>\n")
+	fmt.Fprintf(out, "<text x=\"0\" y=\"12\">%s, %d pages per extent</text>\n", html.EscapeString(spaceMap.Path), spaceMap.ExtentPages)
+
+	top := mapSvgLegendLineHeight * 2
+	for row := uint32(0); row < rows; row++ {
+		first := row * spaceMap.ExtentPages
+		y := top + int(row) * mapSvgCellSize
+		fmt.Fprintf(out, "<text x=\"0\" y=\"%d\">%d</text>\n", y + mapSvgCellSize - 1, first)
+
+		for pageNo := first; pageNo < first + spaceMap.ExtentPages && pageNo < pageCount; pageNo++ {
+			entry := spaceMap.Pages[pageNo]
+			x := mapSvgLabelWidth + int(pageNo - first) * mapSvgCellSize
+
+			stroke := "#e0e0e0"
+			if entry.Corrupted {
+				stroke = mapSvgColorMisplaced
+			}
+
+			title := fmt.Sprintf("page %d: %s", pageNo, spaceMap.describe(entry))
+			fmt.Fprintf(out, "<rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" fill=\"%s\" stroke=\"%s\"><title>%s</title></rect>\n",
+				x, y, mapSvgCellSize, mapSvgCellSize, spaceMap.getSvgColor(entry), stroke, html.EscapeString(title))
+		}
+	}
+
+	top += mapHeight + mapSvgLegendLineHeight
+	for i, item := range legend {
+		y := top + i * mapSvgLegendLineHeight
+		fmt.Fprintf(out, "<rect x=\"0\" y=\"%d\" width=\"%d\" height=\"%d\" fill=\"%s\" stroke=\"#e0e0e0\"/>\n", y, mapSvgCellSize, mapSvgCellSize, item.svgColor)
+		fmt.Fprintf(out, "<text x=\"%d\" y=\"%d\">%s: %s</text>\n", mapSvgCellSize * 2, y + mapSvgCellSize - 1,
+			html.EscapeString(string(item.symbol)), html.EscapeString(item.description))
+	}
+	fmt.Fprintf(out, "</svg>\n")
+
+	if err := out.Flush(); err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return nil
+}
+
+// describe SVG 中页的提示信息
+func (spaceMap *SpaceMap)describe(entry MapPage) string {
+	if entry.Misplaced {
+		return "misplaced"
+	}
+
+	desc, exists := pageTypeMap[entry.PageType]
+	if !exists {
+		desc = fmt.Sprintf("page type %d", entry.PageType)
+	}
+	if entry.PageType == pageTypeIndex {
+		desc = fmt.Sprintf("%s, index %d", desc, entry.IndexId)
+	}
+	if !entry.Allocated {
+		if entry.Reserved {
+			desc += ", reserved by a segment"
+		} else {
+			desc += ", free"
+		}
+	}
+	if entry.Corrupted {
+		desc += ", checksum mismatch"
+	}
+
+	return desc
+}
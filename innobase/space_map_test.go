@@ -0,0 +1,162 @@
+package innobase
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+const testMapUnknownPageType uint16 = 0xFFF0
+
+// newTestSpaceMapFile 在 newTestVerifyFile 的基础上修改页类型和分配状态：
+// 第 3、4 页属于索引 20，第 64 页属于索引 10；第 5、6、7、10 页分别为已分配的 LOB、SDI、未知类型和检验和错误的页；
+// 第 65 页的页号错误。第 1 个区属于段 1，其中空闲的页为预留的页
+func newTestSpaceMapFile() *File {
+	return newTestVerifyFile(func(data []byte) {
+		for pageNo, indexId := range map[uint32]uint64{3: 20, 4: 20, 64: 10} {
+			binary.BigEndian.PutUint64(data[int(pageNo) * testPageSize + int(pageOffsetIndexId):], indexId)
+		}
+		for pageNo, pageType := range map[uint32]uint16{
+			5: pageTypeLobFirst,
+			6: pageTypeSdi,
+			7: testMapUnknownPageType,
+			10: pageTypeAllocated,
+		} {
+			putTestPageType(data, pageNo, pageType)
+			putTestXdesPageFree(data, pageNo, false)
+		}
+		binary.BigEndian.PutUint32(data[10 * testPageSize + int(fileOffsetPageChecksum):], 1)
+		binary.BigEndian.PutUint32(data[65 * testPageSize + int(fileOffsetPageNo):], 66)
+	})
+}
+
+func readTestSpaceMap(t *testing.T, workers int) *SpaceMap {
+	file := newTestSpaceMapFile()
+	defer file.Close()
+
+	spaceMap, err := file.ReadSpaceMap(workers)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return spaceMap
+}
+
+func TestReadSpaceMap(t *testing.T) {
+	for _, workers := range []int{1, 4} {
+		spaceMap := readTestSpaceMap(t, workers)
+
+		if spaceMap.ExtentPages != testVerifyExtentPages || len(spaceMap.Pages) != testVerifyPageCount {
+			t.Fatalf("workers %d: %d pages per extent, %d pages, want %d and %d",
+				workers, spaceMap.ExtentPages, len(spaceMap.Pages), testVerifyExtentPages, testVerifyPageCount)
+		}
+		if len(spaceMap.IndexIds) != 2 || spaceMap.IndexIds[0] != 10 || spaceMap.IndexIds[1] != 20 {
+			t.Fatalf("workers %d: index ids = %v, want [10 20]", workers, spaceMap.IndexIds)
+		}
+
+		tests := []struct {
+			pageNo uint32
+			want MapPage
+		}{
+			{0, MapPage{PageType: pageTypeFSP, SpaceId: testVerifySpaceId, Allocated: true}},
+			{3, MapPage{PageType: pageTypeIndex, SpaceId: testVerifySpaceId, IndexId: 20, Allocated: true}},
+			{7, MapPage{PageType: testMapUnknownPageType, Allocated: true}},
+			{8, MapPage{}},
+			{10, MapPage{Allocated: true, Corrupted: true}},
+			{64, MapPage{PageType: pageTypeIndex, SpaceId: testVerifySpaceId, IndexId: 10, Allocated: true}},
+			{65, MapPage{Allocated: true, Misplaced: true}},
+			{66, MapPage{Reserved: true}},
+		}
+		for _, tt := range tests {
+			if got := spaceMap.Pages[tt.pageNo]; got != tt.want {
+				t.Fatalf("workers %d: page %d = %+v, want %+v", workers, tt.pageNo, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestSpaceMapRenderAnsi(t *testing.T) {
+	spaceMap := readTestSpaceMap(t, 1)
+
+	// 第 0 个区中未使用的页空闲，第 1 个区中未使用的页被段预留
+	wantRows := []string{
+		"         0 FbiBBL$?.._" + strings.Repeat(".", 53),
+		"        64 A!" + strings.Repeat(",", 62),
+	}
+	wantLegend := []string{
+		"    A: index 10",
+		"    B: index 20",
+		"    _: Freshly Allocated",
+		"    i: Inode",
+		"    b: Change Buffer Bitmap",
+		"    F: File Space Header",
+		"    L: First LOB Page",
+		"    $: SDI Index Page",
+		"    ?: page type 65520",
+		"    ,: reserved by a segment, not used",
+		"    .: free",
+		"    !: misplaced",
+	}
+	want := "Space Map (test.ibd, 64 pages per extent):\n" + strings.Join(wantRows, "\n") + "\nLegend:\n" + strings.Join(wantLegend, "\n") + "\n\n"
+
+	var plain bytes.Buffer
+	if err := spaceMap.RenderAnsi(&plain, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if plain.String() != want {
+		t.Fatalf("output = %q, want %q", plain.String(), want)
+	}
+
+	var colored bytes.Buffer
+	if err := spaceMap.RenderAnsi(&colored, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, segment := range []string{
+		"\x1b[38;5;208mF\x1b[0m", // FSP_HDR
+		"\x1b[38;5;27mA\x1b[0m", // 第一个索引
+		"\x1b[38;5;34mB\x1b[0m", // 第二个索引
+		"\x1b[97;41m_\x1b[0m", // 检验和错误
+		"?..", // 未知类型、空闲页没有颜色
+		"\x1b[0m!,,", // 页号错误的页和预留的页没有颜色
+	} {
+		if !strings.Contains(colored.String(), segment) {
+			t.Fatalf("output %q does not contain %q", colored.String(), segment)
+		}
+	}
+}
+
+func TestSpaceMapRenderSvg(t *testing.T) {
+	spaceMap := readTestSpaceMap(t, 1)
+	spaceMap.Path = "<test>.ibd"
+
+	var out bytes.Buffer
+	if err := spaceMap.RenderSvg(&out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	svg := out.String()
+
+	if !strings.HasPrefix(svg, "<?xml") || !strings.HasSuffix(svg, "</svg>\n") {
+		t.Fatalf("output is not a complete SVG document: %q", svg)
+	}
+	if got := strings.Count(svg, "<title>"); got != testVerifyPageCount {
+		t.Fatalf("got %d page cells, want %d", got, testVerifyPageCount)
+	}
+
+	for _, segment := range []string{
+		"&lt;test&gt;.ibd, 64 pages per extent",
+		"fill=\"#ff8700\" stroke=\"#e0e0e0\"><title>page 0: File Space Header</title>",
+		"fill=\"#00af00\" stroke=\"#e0e0e0\"><title>page 3: BTree Page, index 20</title>",
+		"fill=\"#000000\" stroke=\"#e0e0e0\"><title>page 7: page type 65520</title>",
+		"fill=\"#ffffff\" stroke=\"#e0e0e0\"><title>page 8: Freshly Allocated, free</title>",
+		"stroke=\"#ff0000\"><title>page 10: Freshly Allocated, checksum mismatch</title>",
+		"fill=\"#005fff\" stroke=\"#e0e0e0\"><title>page 64: BTree Page, index 10</title>",
+		"fill=\"#ff0000\" stroke=\"#e0e0e0\"><title>page 65: misplaced</title>",
+		"fill=\"#f0f0c0\" stroke=\"#e0e0e0\"><title>page 66: Freshly Allocated, reserved by a segment</title>",
+		"$: SDI Index Page",
+	} {
+		if !strings.Contains(svg, segment) {
+			t.Fatalf("output does not contain %q", segment)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package innobase
+
+import (
+	"fmt"
+)
+
+// dataRange 文件中有数据的区间 [start, end)，区间之外是空洞
+type dataRange struct {
+	start int64
+	end int64
+}
+
+// diskLayoutSource 能够读取磁盘上的数据区间和实际分配字节数的数据来源
+type diskLayoutSource interface {
+	getDiskLayout() ([]dataRange, int64, error)
+}
+
+func (source *fileSource)getDiskLayout() ([]dataRange, int64, error) {
+	return readDiskLayout(source.path, source.size)
+}
+
+// getDiskLayout 拼接每个数据文件的数据区间，分配字节数累加
+func (source *multiSource)getDiskLayout() ([]dataRange, int64, error) {
+	var ranges []dataRange
+	allocated := int64(0)
+	for i, s := range source.sources {
+		layoutSource, ok := s.(diskLayoutSource)
+		if !ok {
+			return nil, 0, fmt.Errorf("%w: %s", ErrNoDiskLayout, s.Name())
+		}
+
+		fileRanges, fileAllocated, err := layoutSource.getDiskLayout()
+		if err != nil {
+			return nil, 0, err
+		}
+
+		for _, r := range fileRanges {
+			ranges = append(ranges, dataRange{start: r.start + source.offsets[i], end: r.end + source.offsets[i]})
+		}
+		allocated += fileAllocated
+	}
+
+	return ranges, allocated, nil
+}
+
+// DiskUsage 表空间文件在磁盘上实际占用的空间。
+// 透明页压缩（COMPRESSION='zlib'）通过打洞释放页中压缩后剩余的空间，
+// 自动扩展的文件也可能是稀疏的，这部分空间计入文件大小，但不占用磁盘
+type DiskUsage struct {
+	LogicalSize int64 // 文件大小
+	AllocatedSize int64 // 文件系统实际分配的字节数（st_blocks * 512）
+	DataSize int64 // 所有数据区间的字节数
+	PageSize uint32 // 物理页大小
+	ExtentPages uint32
+	PageDataSizes []uint32 // 每个页中有数据（不是空洞）的字节数
+}
+
+// GetHoledPages 含有空洞的页的数量
+func (usage *DiskUsage)GetHoledPages() uint32 {
+	count := uint32(0)
+	for _, size := range usage.PageDataSizes {
+		if size < usage.PageSize {
+			count++
+		}
+	}
+
+	return count
+}
+
+// GetExtentDataSize 第 extentNo 个区中有数据的字节数
+func (usage *DiskUsage)GetExtentDataSize(extentNo uint32) uint64 {
+	size := uint64(0)
+	first := extentNo * usage.ExtentPages
+	for pageNo := first; pageNo < first + usage.ExtentPages && pageNo < uint32(len(usage.PageDataSizes)); pageNo++ {
+		size += uint64(usage.PageDataSizes[pageNo])
+	}
+
+	return size
+}
+
+// ReadDiskUsage 读取文件的数据区间，计算每个页在磁盘上实际占用的字节数，
+// Linux 上使用 SEEK_DATA/SEEK_HOLE，其他平台认为文件没有空洞
+func (file *File)ReadDiskUsage() (*DiskUsage, error) {
+	errPrefix := "File::ReadDiskUsage()"
+
+	pageCount, err := file.getPageCount()
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	physicalPageSize, err := file.GetPhysicalPageSize()
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	extentPages, err := file.GetExtentPages()
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	layoutSource, ok := file.source.(diskLayoutSource)
+	if !ok {
+		return nil, fmt.Errorf("%s: [%w: %s]", errPrefix, ErrNoDiskLayout, file.source.Name())
+	}
+
+	ranges, allocated, err := layoutSource.getDiskLayout()
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	usage := &DiskUsage{
+		LogicalSize: file.source.Size(),
+		AllocatedSize: allocated,
+		PageSize: physicalPageSize,
+		ExtentPages: extentPages,
+		PageDataSizes: make([]uint32, pageCount),
+	}
+
+	pageSize := int64(physicalPageSize)
+	for _, r := range ranges {
+		usage.DataSize += r.end - r.start
+
+		// 把数据区间按页切分，累加到每个页
+		for start := r.start; start < r.end; {
+			pageNo := start / pageSize
+			if pageNo >= int64(pageCount) {
+				break
+			}
+
+			end := (pageNo + 1) * pageSize
+			if end > r.end {
+				end = r.end
+			}
+			usage.PageDataSizes[pageNo] += uint32(end - start)
+			start = end
+		}
+	}
+
+	return usage, nil
+}
@@ -0,0 +1,70 @@
+//go:build linux
+// +build linux
+
+package innobase
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+const (
+	seekData = 3 // SEEK_DATA，syscall 包中没有定义
+	seekHole = 4 // SEEK_HOLE
+)
+
+func (source *mmapSource)getDiskLayout() ([]dataRange, int64, error) {
+	return readDiskLayout(source.path, int64(len(source.data)))
+}
+
+// readDiskLayout 用 SEEK_DATA/SEEK_HOLE 读取 [0, size) 中的数据区间，
+// 文件系统不支持时认为整个文件都是数据
+func readDiskLayout(path string, size int64) ([]dataRange, int64, error) {
+	fp, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer fp.Close()
+
+	fileInfo, err := fp.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	allocated := size
+	if stat, ok := fileInfo.Sys().(*syscall.Stat_t); ok {
+		allocated = stat.Blocks * 512
+	}
+
+	ranges := []dataRange{}
+	for offset := int64(0); offset < size; {
+		start, err := fp.Seek(offset, seekData)
+		if err != nil {
+			// ENXIO：offset 之后没有数据了
+			if errors.Is(err, syscall.ENXIO) {
+				break
+			}
+			if errors.Is(err, syscall.EINVAL) && offset == 0 {
+				return []dataRange{{start: 0, end: size}}, allocated, nil
+			}
+			return nil, 0, err
+		}
+		if start >= size {
+			break
+		}
+
+		end, err := fp.Seek(start, seekHole)
+		if err != nil {
+			return nil, 0, err
+		}
+		if end > size {
+			end = size
+		}
+
+		ranges = append(ranges, dataRange{start: start, end: end})
+		offset = end
+	}
+
+	return ranges, allocated, nil
+}
@@ -0,0 +1,66 @@
+//go:build linux
+// +build linux
+
+package innobase
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"syscall"
+	"testing"
+)
+
+const (
+	fallocPunchHole = 0x01 | 0x02 // FALLOC_FL_KEEP_SIZE | FALLOC_FL_PUNCH_HOLE
+)
+
+func TestReadDiskUsagePunchedHole(t *testing.T) {
+	// 写入的 0 也会分配磁盘空间，只有打洞的区间没有数据
+	path := writeTestFile(t, "test.ibd", newTestPagesData(4))
+
+	// 第 1 页整页打洞，第 2 页只保留前 4K
+	fp, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, hole := range []dataRange{{testPageSize, 2 * testPageSize}, {2 * testPageSize + 4096, 3 * testPageSize}} {
+		err := syscall.Fallocate(int(fp.Fd()), fallocPunchHole, hole.start, hole.end - hole.start)
+		if errors.Is(err, syscall.EOPNOTSUPP) || errors.Is(err, syscall.ENOSYS) {
+			fp.Close()
+			t.Skipf("file system does not support punching holes: %v", err)
+		}
+		if err != nil {
+			fp.Close()
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := fp.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, useMmap := range []bool{false, true} {
+		file := NewFile(path)
+		file.SetUseMmap(useMmap)
+
+		usage, err := file.ReadDiskUsage()
+		file.Close()
+		if err != nil {
+			t.Fatalf("mmap %t: unexpected error: %v", useMmap, err)
+		}
+		if usage.DataSize == usage.LogicalSize {
+			t.Skip("file system does not report holes through SEEK_DATA/SEEK_HOLE")
+		}
+
+		want := []uint32{testPageSize, 0, 4096, testPageSize}
+		if !reflect.DeepEqual(usage.PageDataSizes, want) {
+			t.Fatalf("mmap %t: page data sizes = %v, want %v", useMmap, usage.PageDataSizes, want)
+		}
+		if usage.GetHoledPages() != 2 {
+			t.Fatalf("mmap %t: holed pages = %d, want 2", useMmap, usage.GetHoledPages())
+		}
+		if usage.AllocatedSize >= usage.LogicalSize {
+			t.Fatalf("mmap %t: allocated size %d is not smaller than the file size %d", useMmap, usage.AllocatedSize, usage.LogicalSize)
+		}
+	}
+}
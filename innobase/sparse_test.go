@@ -0,0 +1,100 @@
+package innobase
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// testLayoutSource 返回固定数据区间的数据来源，用于测试按页切分数据区间
+type testLayoutSource struct {
+	Source
+	ranges []dataRange
+	allocated int64
+}
+
+func (source *testLayoutSource)getDiskLayout() ([]dataRange, int64, error) {
+	return source.ranges, source.allocated, nil
+}
+
+func TestReadDiskUsage(t *testing.T) {
+	const pageSize = testPageSize
+
+	tests := []struct {
+		name string
+		ranges []dataRange
+		wantPageDataSizes []uint32
+		wantDataSize int64
+		wantHoledPages uint32
+	}{
+		{
+			name: "no holes",
+			ranges: []dataRange{{0, 4 * pageSize}},
+			wantPageDataSizes: []uint32{pageSize, pageSize, pageSize, pageSize},
+			wantDataSize: 4 * pageSize,
+		},
+		{
+			name: "all holes",
+			wantPageDataSizes: []uint32{0, 0, 0, 0},
+			wantHoledPages: 4,
+		},
+		{
+			name: "ranges crossing page boundaries",
+			ranges: []dataRange{{0, pageSize + 4096}, {2 * pageSize - 4096, 2 * pageSize + 8192}},
+			wantPageDataSizes: []uint32{pageSize, 8192, 8192, 0},
+			wantDataSize: pageSize + 4096 + 12288,
+			wantHoledPages: 3,
+		},
+		{
+			name: "several ranges in one page",
+			ranges: []dataRange{{0, 4096}, {8192, 12288}, {pageSize, 4 * pageSize}},
+			wantPageDataSizes: []uint32{8192, pageSize, pageSize, pageSize},
+			wantDataSize: 8192 + 3 * pageSize,
+			wantHoledPages: 1,
+		},
+		{
+			// 文件末尾不足一页的部分不属于任何页，但计入数据字节数
+			name: "range past page count",
+			ranges: []dataRange{{3 * pageSize, 5 * pageSize + 100}},
+			wantPageDataSizes: []uint32{0, 0, 0, pageSize},
+			wantDataSize: 2 * pageSize + 100,
+			wantHoledPages: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := &testLayoutSource{
+				Source: NewBytesSource("test.ibd", newTestPagesData(4)),
+				ranges: tt.ranges,
+				allocated: 12345,
+			}
+			file := NewFileFromSource(source)
+			defer file.Close()
+
+			usage, err := file.ReadDiskUsage()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(usage.PageDataSizes, tt.wantPageDataSizes) {
+				t.Fatalf("page data sizes = %v, want %v", usage.PageDataSizes, tt.wantPageDataSizes)
+			}
+			if usage.DataSize != tt.wantDataSize {
+				t.Fatalf("data size = %d, want %d", usage.DataSize, tt.wantDataSize)
+			}
+			if holed := usage.GetHoledPages(); holed != tt.wantHoledPages {
+				t.Fatalf("holed pages = %d, want %d", holed, tt.wantHoledPages)
+			}
+			if usage.LogicalSize != 4 * pageSize || usage.AllocatedSize != 12345 || usage.PageSize != pageSize {
+				t.Fatalf("usage = %+v", usage)
+			}
+		})
+	}
+
+	// 不能读取数据区间的数据来源
+	file := newTestFile(4, nil)
+	defer file.Close()
+	if _, err := file.ReadDiskUsage(); !errors.Is(err, ErrNoDiskLayout) {
+		t.Fatalf("error = %v, want %v", err, ErrNoDiskLayout)
+	}
+}
@@ -0,0 +1,167 @@
+package innobase
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	pageTypeStatsPrefix = "page_type_" // 索引统计信息中各类型页面数量的前缀
+)
+
+// spaceStats 表空间扫描的统计结果，并发扫描时每个协程各有一份，最后合并
+type spaceStats struct {
+	// 表空间统计信息
+	counters map[string]uint32
+
+	// 索引统计信息
+	indexStats map[uint64]map[string]int
+
+	// 页面类型统计信息
+	pageTypeStats map[uint16]int32
+
+	// 各类型页面中检验和错误的页面数量
+	pageTypeCorrupted map[uint16]int32
+
+	// 页中存储的页号与页的位置不一致的页，页的位置 => 页中存储的页号
+	misplacedPages map[uint32]uint32
+}
+
+func newSpaceStats() *spaceStats {
+	return &spaceStats{
+		counters: map[string]uint32 {
+			"corrupted_page": 0,
+			"torn_page": 0,
+			"misplaced_page": 0,
+		},
+		indexStats: map[uint64]map[string]int{},
+		pageTypeStats: map[uint16]int32{},
+		pageTypeCorrupted: map[uint16]int32{},
+		misplacedPages: map[uint32]uint32{},
+	}
+}
+
+func (stats *spaceStats)visitPage(filePage *Page) error {
+	errPrefix := "spaceStats::visitPage()"
+
+	// 页号不一致的页无法确定归属，单独输出，不计入统计
+	if err := filePage.CheckPageNo(); err != nil {
+		if !errors.Is(err, ErrPageNoMismatch) {
+			return fmt.Errorf("%s: [%w]", errPrefix, err)
+		}
+		storedPageNo, _ := filePage.GetPageNo()
+		stats.misplacedPages[filePage.GetPosition()] = storedPageNo
+		stats.counters["misplaced_page"]++
+		return nil
+	}
+
+	// 读取页类型
+	pageType, err := filePage.GetPageType()
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	stats.pageTypeStats[pageType]++
+
+	// 校验检验和
+	checksum, err := filePage.VerifyChecksum()
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	if !checksum.Valid {
+		stats.pageTypeCorrupted[pageType]++
+		stats.counters["corrupted_page"]++
+	}
+
+	// 比较页头与页尾的 Lsn
+	trailer, err := filePage.CheckTrailer()
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	if trailer.Torn {
+		stats.counters["torn_page"]++
+	}
+
+	if pageType != pageTypeIndex {
+		return nil
+	}
+	page := NewBTreePage(filePage)
+
+	// 读取索引 ID
+	indexId, err := page.GetIndexId()
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	if indexId <= 0 {
+		return nil
+	}
+
+	if _, exists := stats.indexStats[indexId]; !exists {
+		stats.indexStats[indexId] = map[string]int{}
+	}
+
+	// 读取 PAGE_LEVEL
+	pageLevel, err := page.GetPageLevel()
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	levelKey := fmt.Sprintf("level_%d_page", pageLevel)
+	stats.indexStats[indexId][levelKey]++
+
+	// 独立表空间中各类型页面数量
+	pageTypeKey := fmt.Sprintf("%s%d", pageTypeStatsPrefix, pageType)
+	stats.indexStats[indexId][pageTypeKey]++
+
+	if !checksum.Valid {
+		stats.indexStats[indexId]["corrupted_page"]++
+	}
+
+	return nil
+}
+
+// merge 把另一个协程的统计结果累加到当前结果中
+func (stats *spaceStats)merge(other *spaceStats) {
+	for key, count := range other.counters {
+		stats.counters[key] += count
+	}
+
+	for indexId, otherIndexStats := range other.indexStats {
+		if _, exists := stats.indexStats[indexId]; !exists {
+			stats.indexStats[indexId] = map[string]int{}
+		}
+		for key, count := range otherIndexStats {
+			stats.indexStats[indexId][key] += count
+		}
+	}
+
+	for pageType, count := range other.pageTypeStats {
+		stats.pageTypeStats[pageType] += count
+	}
+
+	for pageType, count := range other.pageTypeCorrupted {
+		stats.pageTypeCorrupted[pageType] += count
+	}
+
+	for position, storedPageNo := range other.misplacedPages {
+		stats.misplacedPages[position] = storedPageNo
+	}
+}
+
+// collectSpaceStats 用 workers 个协程扫描整个表空间并合并统计结果
+func collectSpaceStats(file *File, workers int) (*spaceStats, error) {
+	errPrefix := "collectSpaceStats()"
+
+	visitors, err := scanPages(file, workers, func() pageVisitor {
+		return newSpaceStats()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	stats := newSpaceStats()
+	for _, visitor := range visitors {
+		stats.merge(visitor.(*spaceStats))
+	}
+
+	return stats, nil
+}
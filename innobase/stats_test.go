@@ -0,0 +1,97 @@
+package innobase
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+const (
+	testStatsPageSize = 4096
+	testStatsPageCount = 2600 // 超过两个 scanRangePages，并发扫描时分成 3 个区间
+)
+
+// newTestStatsFile 构造 4K 页的表空间，每隔 7 页一个 INDEX 页，索引 ID 和层级按页号变化
+func newTestStatsFile() *File {
+	data := make([]byte, testStatsPageCount * testStatsPageSize)
+	binary.BigEndian.PutUint32(data[fspHeaderOffset + fspOffsetSpaceFlags:], 3 << fspFlagsPosPageSsize)
+
+	for pageNo := 0; pageNo < testStatsPageCount; pageNo++ {
+		page := data[pageNo * testStatsPageSize:(pageNo + 1) * testStatsPageSize]
+		binary.BigEndian.PutUint32(page[fileOffsetPageNo:], uint32(pageNo))
+		binary.BigEndian.PutUint16(page[fileOffsetPageType:], pageTypeAllocated)
+
+		if pageNo % 7 == 3 {
+			binary.BigEndian.PutUint16(page[fileOffsetPageType:], pageTypeIndex)
+			binary.BigEndian.PutUint16(page[pageOffsetPageLevel:], uint16(pageNo % 3))
+			binary.BigEndian.PutUint64(page[pageOffsetIndexId:], uint64(pageNo % 5))
+		}
+	}
+
+	// 每个区间中各有一个检验和错误的 INDEX 页、一个页头页尾 Lsn 不一致的页和一个页号错误的页
+	for _, pageNo := range []int{10, 1039, 2054} {
+		binary.BigEndian.PutUint32(data[pageNo * testStatsPageSize + int(fileOffsetPageChecksum):], 1)
+	}
+	for _, pageNo := range []int{20, 1044, 2068} {
+		torn := data[pageNo * testStatsPageSize:(pageNo + 1) * testStatsPageSize]
+		binary.BigEndian.PutUint64(torn[fileOffsetPageLsn:], 5)
+		binary.BigEndian.PutUint32(torn[testStatsPageSize - 4:], 6)
+	}
+	for _, pageNo := range []int{30, 1054, 2078} {
+		binary.BigEndian.PutUint32(data[pageNo * testStatsPageSize + int(fileOffsetPageNo):], uint32(pageNo + 1))
+	}
+
+	return NewFileFromSource(NewBytesSource("test.ibd", data))
+}
+
+func TestCollectSpaceStatsWorkers(t *testing.T) {
+	file := newTestStatsFile()
+	defer file.Close()
+
+	want, err := collectSpaceStats(file, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 顺序扫描的结果本身也需要正确，否则两种扫描方式可能以同样的方式出错
+	wantCounters := map[string]uint32{"corrupted_page": 3, "torn_page": 3, "misplaced_page": 3}
+	if !reflect.DeepEqual(want.counters, wantCounters) {
+		t.Fatalf("counters = %v, want %v", want.counters, wantCounters)
+	}
+	if want.pageTypeCorrupted[pageTypeIndex] != 3 {
+		t.Fatalf("corrupted INDEX pages = %d, want 3", want.pageTypeCorrupted[pageTypeIndex])
+	}
+	wantMisplaced := map[uint32]uint32{30: 31, 1054: 1055, 2078: 2079}
+	if !reflect.DeepEqual(want.misplacedPages, wantMisplaced) {
+		t.Fatalf("misplaced pages = %v, want %v", want.misplacedPages, wantMisplaced)
+	}
+	if total := want.pageTypeStats[pageTypeIndex] + want.pageTypeStats[pageTypeAllocated]; total != testStatsPageCount - 3 {
+		t.Fatalf("got %d pages by type, want %d", total, testStatsPageCount - 3)
+	}
+	if len(want.indexStats) != 4 {
+		t.Fatalf("got %d indexes, want 4", len(want.indexStats))
+	}
+
+	for _, workers := range []int{2, 3, 8} {
+		got, err := collectSpaceStats(file, workers)
+		if err != nil {
+			t.Fatalf("workers %d: unexpected error: %v", workers, err)
+		}
+
+		if !reflect.DeepEqual(got.counters, want.counters) {
+			t.Fatalf("workers %d: counters = %v, want %v", workers, got.counters, want.counters)
+		}
+		if !reflect.DeepEqual(got.pageTypeStats, want.pageTypeStats) {
+			t.Fatalf("workers %d: pageTypeStats = %v, want %v", workers, got.pageTypeStats, want.pageTypeStats)
+		}
+		if !reflect.DeepEqual(got.pageTypeCorrupted, want.pageTypeCorrupted) {
+			t.Fatalf("workers %d: pageTypeCorrupted = %v, want %v", workers, got.pageTypeCorrupted, want.pageTypeCorrupted)
+		}
+		if !reflect.DeepEqual(got.indexStats, want.indexStats) {
+			t.Fatalf("workers %d: indexStats = %v, want %v", workers, got.indexStats, want.indexStats)
+		}
+		if !reflect.DeepEqual(got.misplacedPages, want.misplacedPages) {
+			t.Fatalf("workers %d: misplacedPages = %v, want %v", workers, got.misplacedPages, want.misplacedPages)
+		}
+	}
+}
@@ -0,0 +1,332 @@
+package innobase
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultDataFilePath = "ibdata1:12M:autoextend" // innodb_data_file_path 的默认值
+)
+
+// DataFileSpec innodb_data_file_path 中的一个数据文件，
+// 格式为 file_name:file_size[:autoextend[:max:max_file_size]]
+type DataFileSpec struct {
+	Name string
+	Size int64 // 文件的初始大小，单位字节
+	AutoExtend bool // 只有最后一个文件可以自动扩展
+	MaxSize int64 // 自动扩展的最大大小，0 表示不限制
+	Raw bool // 文件大小后带 newraw 或 raw，表示使用裸设备
+}
+
+// ParseDataFilePath 按 innodb_data_file_path 的语法解析系统表空间的文件列表，
+// 如 ibdata1:1G;ibdata2:10G:autoextend，为空时使用默认值 ibdata1:12M:autoextend
+func ParseDataFilePath(dataFilePath string) ([]DataFileSpec, error) {
+	errPrefix := "ParseDataFilePath()"
+
+	dataFilePath = strings.TrimSpace(dataFilePath)
+	if dataFilePath == "" {
+		dataFilePath = defaultDataFilePath
+	}
+
+	specs := []DataFileSpec{}
+	for _, item := range strings.Split(dataFilePath, ";") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		if len(specs) > 0 && specs[len(specs) - 1].AutoExtend {
+			return nil, fmt.Errorf("%s: [only the last data file can be autoextend: %s]", errPrefix, dataFilePath)
+		}
+
+		spec, err := parseDataFileSpec(item)
+		if err != nil {
+			return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+		}
+
+		specs = append(specs, spec)
+	}
+
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("%s: [no data file in %s]", errPrefix, dataFilePath)
+	}
+
+	return specs, nil
+}
+
+func parseDataFileSpec(item string) (DataFileSpec, error) {
+	spec := DataFileSpec{}
+
+	parts := strings.Split(item, ":")
+	if len(parts) < 2 || parts[0] == "" {
+		return spec, fmt.Errorf("invalid data file %s", item)
+	}
+	spec.Name = parts[0]
+
+	sizeStr := parts[1]
+	for _, suffix := range []string{"newraw", "raw"} {
+		if strings.HasSuffix(sizeStr, suffix) {
+			sizeStr = strings.TrimSuffix(sizeStr, suffix)
+			spec.Raw = true
+			break
+		}
+	}
+
+	size, err := parseDataFileSize(sizeStr)
+	if err != nil {
+		return spec, fmt.Errorf("invalid size of data file %s: %w", item, err)
+	}
+	spec.Size = size
+
+	options := parts[2:]
+	if len(options) == 0 {
+		return spec, nil
+	}
+
+	if options[0] != "autoextend" {
+		return spec, fmt.Errorf("invalid option %s of data file %s", options[0], item)
+	}
+	spec.AutoExtend = true
+	options = options[1:]
+
+	if len(options) == 0 {
+		return spec, nil
+	}
+
+	if len(options) != 2 || options[0] != "max" {
+		return spec, fmt.Errorf("invalid max size of data file %s", item)
+	}
+
+	maxSize, err := parseDataFileSize(options[1])
+	if err != nil {
+		return spec, fmt.Errorf("invalid max size of data file %s: %w", item, err)
+	}
+	spec.MaxSize = maxSize
+
+	return spec, nil
+}
+
+// parseDataFileSize 解析带 K、M、G 后缀的文件大小，没有后缀时单位为字节
+func parseDataFileSize(sizeStr string) (int64, error) {
+	unit := int64(1)
+	switch {
+	case strings.HasSuffix(sizeStr, "K"), strings.HasSuffix(sizeStr, "k"):
+		unit = 1 << 10
+	case strings.HasSuffix(sizeStr, "M"), strings.HasSuffix(sizeStr, "m"):
+		unit = 1 << 20
+	case strings.HasSuffix(sizeStr, "G"), strings.HasSuffix(sizeStr, "g"):
+		unit = 1 << 30
+	}
+	if unit > 1 {
+		sizeStr = sizeStr[:len(sizeStr) - 1]
+	}
+
+	size, err := strconv.ParseInt(sizeStr, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if size <= 0 {
+		return 0, fmt.Errorf("size must be positive")
+	}
+
+	return size * unit, nil
+}
+
+// multiSource 把多个数据文件按顺序拼接成一个连续的数据来源，
+// 页号跨越文件边界连续编号，与 InnoDB 的系统表空间相同
+type multiSource struct {
+	name string
+	sources []Source
+	offsets []int64 // 每个数据来源在拼接后的起始位置
+	size int64
+}
+
+func NewMultiSource(sources ...Source) Source {
+	source := &multiSource{
+		sources: sources,
+		offsets: make([]int64, len(sources)),
+	}
+
+	names := make([]string, 0, len(sources))
+	for i, s := range sources {
+		source.offsets[i] = source.size
+		source.size += s.Size()
+		names = append(names, s.Name())
+	}
+	source.name = strings.Join(names, ";")
+
+	return source
+}
+
+func (source *multiSource)ReadAt(p []byte, offset int64) (int, error) {
+	if offset < 0 {
+		return 0, fmt.Errorf("multiSource::ReadAt(): [negative offset %d]", offset)
+	}
+
+	// 找到包含 offset 的最后一个数据来源
+	i := sort.Search(len(source.offsets), func(i int) bool {
+		return source.offsets[i] > offset
+	}) - 1
+
+	read := 0
+	for ; i >= 0 && i < len(source.sources) && read < len(p); i++ {
+		current := offset + int64(read) - source.offsets[i]
+		remain := source.sources[i].Size() - current
+		if remain <= 0 {
+			continue
+		}
+
+		buf := p[read:]
+		if int64(len(buf)) > remain {
+			buf = buf[:remain]
+		}
+
+		n, err := source.sources[i].ReadAt(buf, current)
+		read += n
+		if err != nil && !(err == io.EOF && n == len(buf)) {
+			return read, err
+		}
+	}
+
+	if read < len(p) {
+		return read, io.EOF
+	}
+
+	return read, nil
+}
+
+// Slice 页不跨越文件边界并且所在的数据来源支持 Slice() 时，不复制数据
+func (source *multiSource)Slice(offset int64, size int) ([]byte, bool) {
+	i := sort.Search(len(source.offsets), func(i int) bool {
+		return source.offsets[i] > offset
+	}) - 1
+	if i < 0 || offset < 0 {
+		return nil, false
+	}
+
+	slicer, ok := source.sources[i].(sliceSource)
+	if !ok {
+		return nil, false
+	}
+
+	return slicer.Slice(offset - source.offsets[i], size)
+}
+
+func (source *multiSource)Sequential() bool {
+	for _, s := range source.sources {
+		if isSequentialSource(s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (source *multiSource)Name() string {
+	return source.name
+}
+
+func (source *multiSource)Size() int64 {
+	return source.size
+}
+
+func (source *multiSource)Close() error {
+	var firstErr error
+	for _, s := range source.sources {
+		if err := s.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// OpenSystemTableSpace 按 innodb_data_file_path 打开系统表空间的所有数据文件，
+// dataHomeDir 对应 innodb_data_home_dir，文件名为绝对路径时忽略。
+// 除最后一个文件外，文件大小必须与配置一致，否则后续文件的页号会错位
+func OpenSystemTableSpace(dataHomeDir string, dataFilePath string) (*File, error) {
+	errPrefix := "OpenSystemTableSpace()"
+
+	file, err := openSystemTableSpace(dataHomeDir, dataFilePath, false)
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return file, nil
+}
+
+// OpenSystemTableSpaceMmap 与 OpenSystemTableSpace() 相同，使用 mmap 读取每个数据文件
+func OpenSystemTableSpaceMmap(dataHomeDir string, dataFilePath string) (*File, error) {
+	errPrefix := "OpenSystemTableSpaceMmap()"
+
+	file, err := openSystemTableSpace(dataHomeDir, dataFilePath, true)
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return file, nil
+}
+
+func openSystemTableSpace(dataHomeDir string, dataFilePath string, useMmap bool) (*File, error) {
+	errPrefix := "openSystemTableSpace()"
+
+	specs, err := ParseDataFilePath(dataFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	sources := make([]Source, 0, len(specs))
+	closeSources := func() {
+		for _, source := range sources {
+			_ = source.Close()
+		}
+	}
+
+	for _, spec := range specs {
+		path := spec.Name
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dataHomeDir, path)
+		}
+
+		var source Source
+		if useMmap {
+			source, err = NewMmapSource(path)
+		} else {
+			source, err = NewFileSource(path)
+		}
+		if err != nil {
+			closeSources()
+			return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+		}
+		sources = append(sources, source)
+
+		// 只有最后一个文件可以自动扩展，其余文件必须与配置的大小完全一致
+		if source.Size() < spec.Size || (!spec.AutoExtend && source.Size() != spec.Size) {
+			closeSources()
+			return nil, fmt.Errorf("%s: [data file %s is %d bytes, expected %d bytes]", errPrefix, path, source.Size(), spec.Size)
+		}
+	}
+
+	file := NewFileFromSource(NewMultiSource(sources...))
+	file.sourceOwned = true
+
+	// 页号跨越文件边界，每个文件的大小必须是页大小的整数倍
+	physicalPageSize, err := file.GetPhysicalPageSize()
+	if err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	for _, source := range sources[:len(sources) - 1] {
+		if source.Size() % int64(physicalPageSize) != 0 {
+			_ = file.Close()
+			return nil, fmt.Errorf("%s: [data file %s is not a multiple of page size %d]", errPrefix, source.Name(), physicalPageSize)
+		}
+	}
+
+	return file, nil
+}
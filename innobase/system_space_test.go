@@ -0,0 +1,94 @@
+package innobase
+
+import (
+	"testing"
+)
+
+func TestParseDataFilePath(t *testing.T) {
+	tests := []struct {
+		name string
+		dataFilePath string
+		want []DataFileSpec
+		wantErr bool
+	}{
+		{
+			name: "default",
+			dataFilePath: "",
+			want: []DataFileSpec{{Name: "ibdata1", Size: 12 << 20, AutoExtend: true}},
+		},
+		{
+			name: "suffixes",
+			dataFilePath: "ibdata1:512K;ibdata2:64m;ibdata3:1G;ibdata4:2g:autoextend",
+			want: []DataFileSpec{
+				{Name: "ibdata1", Size: 512 << 10},
+				{Name: "ibdata2", Size: 64 << 20},
+				{Name: "ibdata3", Size: 1 << 30},
+				{Name: "ibdata4", Size: 2 << 30, AutoExtend: true},
+			},
+		},
+		{
+			name: "bare byte count",
+			dataFilePath: "ibdata1:12582912",
+			want: []DataFileSpec{{Name: "ibdata1", Size: 12582912}},
+		},
+		{
+			name: "autoextend with max",
+			dataFilePath: "ibdata1:12M:autoextend:max:500M",
+			want: []DataFileSpec{{Name: "ibdata1", Size: 12 << 20, AutoExtend: true, MaxSize: 500 << 20}},
+		},
+		{
+			name: "raw devices",
+			dataFilePath: "/dev/hdd1:3Gnewraw;/dev/hdd2:2Graw",
+			want: []DataFileSpec{
+				{Name: "/dev/hdd1", Size: 3 << 30, Raw: true},
+				{Name: "/dev/hdd2", Size: 2 << 30, Raw: true},
+			},
+		},
+		{
+			name: "spaces and empty items",
+			dataFilePath: " ibdata1:1G ; ;ibdata2:1G:autoextend; ",
+			want: []DataFileSpec{
+				{Name: "ibdata1", Size: 1 << 30},
+				{Name: "ibdata2", Size: 1 << 30, AutoExtend: true},
+			},
+		},
+		{name: "only separators", dataFilePath: ";;", wantErr: true},
+		{name: "autoextend not last", dataFilePath: "ibdata1:1G:autoextend;ibdata2:1G", wantErr: true},
+		{name: "missing size", dataFilePath: "ibdata1", wantErr: true},
+		{name: "missing name", dataFilePath: ":12M", wantErr: true},
+		{name: "empty size", dataFilePath: "ibdata1:", wantErr: true},
+		{name: "raw without size", dataFilePath: "ibdata1:raw", wantErr: true},
+		{name: "zero size", dataFilePath: "ibdata1:0M", wantErr: true},
+		{name: "negative size", dataFilePath: "ibdata1:-12M", wantErr: true},
+		{name: "unknown suffix", dataFilePath: "ibdata1:12T", wantErr: true},
+		{name: "unknown option", dataFilePath: "ibdata1:12M:autoextendd", wantErr: true},
+		{name: "max without autoextend", dataFilePath: "ibdata1:12M:max:1G", wantErr: true},
+		{name: "max without size", dataFilePath: "ibdata1:12M:autoextend:max", wantErr: true},
+		{name: "invalid max size", dataFilePath: "ibdata1:12M:autoextend:max:big", wantErr: true},
+		{name: "trailing option", dataFilePath: "ibdata1:12M:autoextend:max:1G:more", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			specs, err := ParseDataFilePath(tt.dataFilePath)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %+v", specs)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(specs) != len(tt.want) {
+				t.Fatalf("got %+v, want %+v", specs, tt.want)
+			}
+			for i := range tt.want {
+				if specs[i] != tt.want[i] {
+					t.Fatalf("data file %d = %+v, want %+v", i, specs[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
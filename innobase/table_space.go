@@ -1,29 +1,152 @@
 package innobase
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"runtime"
 	"sort"
 	"strconv"
 	"strings"
 )
 
 type TableSpace struct {
-
+	useMmap bool // 是否使用 mmap 读取文件
+	workers int // 扫描表空间的协程数量
+	dictionary *DataDictionary // 用于显示索引名的数据字典，为空时只显示索引 ID
 }
 
 func NewTableSpace() TableSpace {
-	return TableSpace {}
+	return TableSpace {
+		workers: 1,
+	}
+}
+
+// SetWorkers 设置扫描表空间的协程数量，小于 1 时使用 CPU 核数，默认为 1（按页号顺序扫描）。
+// gzip 压缩的表空间只能顺序读取，总是只用一个协程
+func (space *TableSpace)SetWorkers(workers int) {
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+
+	space.workers = workers
+}
+
+// SetUseMmap 使用 mmap 读取表空间文件，适合扫描很大的 ibdata 文件
+func (space *TableSpace)SetUseMmap(useMmap bool) {
+	space.useMmap = useMmap
+}
+
+// SetDictionary 设置数据字典，Stats、IndexHeader 输出索引 ID 时同时输出 db/table.index_name
+func (space *TableSpace)SetDictionary(dictionary *DataDictionary) {
+	space.dictionary = dictionary
+}
+
+// LoadDictionary 从系统表空间（ibdata1）中读取 5.7 的数据字典，用于显示索引名
+func (space *TableSpace)LoadDictionary(path string) error {
+	errPrefix := "TableSpace::LoadDictionary()"
+
+	file := space.newFile(path)
+	defer file.Close()
+
+	dictionary, err := file.ReadDataDictionary()
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	space.dictionary = dictionary
+
+	return nil
+}
+
+// LoadDictionarySystem 从由多个数据文件组成的系统表空间中读取 5.7 的数据字典
+func (space *TableSpace)LoadDictionarySystem(dataHomeDir string, dataFilePath string) error {
+	errPrefix := "TableSpace::LoadDictionarySystem()"
+
+	file, err := openSystemTableSpace(dataHomeDir, dataFilePath, space.useMmap)
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	defer file.Close()
+
+	dictionary, err := file.ReadDataDictionary()
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	space.dictionary = dictionary
+
+	return nil
+}
+
+// LoadMysqlDictionary 从 8.0 的 mysql.ibd 中读取数据字典，用于显示索引名和表空间名
+func (space *TableSpace)LoadMysqlDictionary(path string) error {
+	errPrefix := "TableSpace::LoadMysqlDictionary()"
+
+	file := space.newFile(path)
+	defer file.Close()
+
+	dictionary, err := file.ReadMysqlDictionary()
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	space.dictionary = dictionary
+
+	return nil
+}
+
+// formatIndexId 数据字典中有该索引时输出 "索引 ID (db/table.index_name)"
+func (space *TableSpace)formatIndexId(indexId uint64) string {
+	if name := space.dictionary.GetIndexName(indexId); name != "" {
+		return fmt.Sprintf("%d (%s)", indexId, name)
+	}
+
+	return fmt.Sprintf("%d", indexId)
+}
+
+func (space *TableSpace)newFile(path string) *File {
+	file := NewFile(path)
+	file.SetUseMmap(space.useMmap)
+
+	return file
 }
 
 func (space *TableSpace)Stats(path string) error {
 	errPrefix := "TableSpace::Stats()"
 
-	file := NewFile(path)
-	page := NewBTreePage(file)
+	file := space.newFile(path)
+	defer file.Close()
+
+	if err := space.StatsFile(file); err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return nil
+}
+
+// StatsSystem 统计由多个数据文件组成的系统表空间，
+// dataFilePath 与 innodb_data_file_path 的语法相同，如 ibdata1:1G;ibdata2:10G:autoextend
+func (space *TableSpace)StatsSystem(dataHomeDir string, dataFilePath string) error {
+	errPrefix := "TableSpace::StatsSystem()"
+
+	file, err := openSystemTableSpace(dataHomeDir, dataFilePath, space.useMmap)
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	defer file.Close()
+
+	if err := space.StatsFile(file); err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return nil
+}
+
+// StatsFile 统计任意数据来源的表空间，file 可以由 NewFileFromSource() 创建
+func (space *TableSpace)StatsFile(file *File) error {
+	errPrefix := "TableSpace::StatsFile()"
 
 	pageCount, err := file.getPageCount()
 	if err != nil {
-		return fmt.Errorf("%s: [%s]", errPrefix, err)
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
 	}
 
 	// 表空间统计信息
@@ -32,73 +155,49 @@ func (space *TableSpace)Stats(path string) error {
 		"total_page": pageCount,
 	}
 
-	// 索引统计信息
-	pageTypePrefix := "page_type_"
-	indexStats := map[uint64]map[string]int {}
+	// 读取页大小
+	logicalPageSize, err := file.GetLogicalPageSize()
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	stats["logical_page_size"] = logicalPageSize
 
-	// 页面类型统计信息
-	pageTypeStats := map[uint16]int32{}
+	physicalPageSize, err := file.GetPhysicalPageSize()
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	stats["physical_page_size"] = physicalPageSize
 
 	// 读取表空间 ID
-	spaceId, err := file.GetSpaceId()
+	fspPage, err := file.ReadPage(0)
 	if err != nil {
-		return fmt.Errorf("%s: [%s]", errPrefix, err)
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	spaceId, err := fspPage.GetSpaceId()
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
 	}
 	stats["space_id"] = uint32(spaceId)
 
-	for pageNo := uint32(1); pageNo < pageCount; pageNo++ {
-		if err := file.SetPageNo(pageNo); err != nil {
-			return fmt.Errorf("%s: [%s]", errPrefix, err)
-		}
-
-		// 读取页类型
-		pageType, err := file.GetPageType()
-		if err != nil {
-			return fmt.Errorf("%s: [%s]", errPrefix, err)
-		}
-		pageTypeStats[pageType]++
-
-		if !file.IsBTreePage(pageType) {
-			continue
-		}
-
-		// 读取索引 ID
-		indexId, err := page.GetIndexId()
-		if err != nil {
-			return fmt.Errorf("%s: [%s]", errPrefix, err)
-		}
-		if indexId <= 0 {
-			continue
-		}
-
-		if _, exists := indexStats[indexId]; !exists {
-			indexStats[indexId] = map[string]int{}
-		}
-
-		// 读取 PAGE_LEVEL
-		pageLevel, err := page.GetPageLevel()
-		if err != nil {
-			return fmt.Errorf("%s: [%s]", errPrefix, err)
-		}
-
-		levelKey := fmt.Sprintf("level_%d_page", pageLevel)
-		indexStats[indexId][levelKey]++
-
-		// 独立表空间中各类型页面数量
-		pageTypeKey := fmt.Sprintf("%s%d", pageTypePrefix, pageType)
-		indexStats[indexId][pageTypeKey]++
-
-		// 读取页号
-		_, err = file.GetPageNo()
-		if err != nil {
-			return fmt.Errorf("%s: [%s]", errPrefix, err)
-		}
+	// 解析 FSP 头
+	fspHeader, err := fspPage.GetFSPHeader()
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
 
-		/* fmt.Printf("page no = %d, level = %d, type = %d\n", pageNo, pageLevel, 0)
-		if i >= 100 {
-			break
-		} */
+	// 扫描所有页，workers 大于 1 时并发扫描
+	scanStats, err := collectSpaceStats(file, space.workers)
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	for key, count := range scanStats.counters {
+		stats[key] = count
 	}
+	indexStats := scanStats.indexStats
+	pageTypeStats := scanStats.pageTypeStats
+	pageTypeCorrupted := scanStats.pageTypeCorrupted
+	misplacedPages := scanStats.misplacedPages
+	pageTypePrefix := pageTypeStatsPrefix
 
 	fmt.Printf("Stats (%s):\n", file.GetPath())
 	keys := make([]string, 0, len(stats))
@@ -109,17 +208,39 @@ func (space *TableSpace)Stats(path string) error {
 	for _, key := range keys {
 		fmt.Printf("    %s: %v\n", key, stats[key])
 	}
+	if name := space.dictionary.GetSpaceName(spaceId); name != "" {
+		fmt.Printf("    space_name: %s\n", name)
+	}
 	fmt.Println()
 
+	printFSPHeader(fspHeader, physicalPageSize, getExtentPages(logicalPageSize))
+
+	if len(misplacedPages) > 0 {
+		fmt.Println("Misplaced Pages:")
+		positions := make([]uint32, 0, len(misplacedPages))
+		for position := range misplacedPages {
+			positions = append(positions, position)
+		}
+		sort.Slice(positions, func(i, j int) bool { return positions[i] < positions[j] })
+		for _, position := range positions {
+			fmt.Printf("    page %d: stored page no %d\n", position, misplacedPages[position])
+		}
+		fmt.Println()
+	}
+
 	fmt.Println("Page Type Stats:")
 	for pageType, typeCount := range pageTypeStats {
-		fmt.Printf("    %s (%d): %d\n", pageTypeMap[uint16(pageType)], pageType, typeCount)
+		fmt.Printf("    %s (%d): %d", pageTypeMap[uint16(pageType)], pageType, typeCount)
+		if corruptedCount := pageTypeCorrupted[pageType]; corruptedCount > 0 {
+			fmt.Printf(", corrupted: %d", corruptedCount)
+		}
+		fmt.Println()
 	}
 	fmt.Println()
 
 	fmt.Printf("Index Stats (%d indexes):\n", len(indexStats))
 	for indexId, singleIndexStats := range indexStats {
-		fmt.Printf("    %d: \n", indexId)
+		fmt.Printf("    %s: \n", space.formatIndexId(indexId))
 		keys := make([]string, 0, len(singleIndexStats))
 		for indexId, _ := range singleIndexStats {
 			keys = append(keys, indexId)
@@ -150,25 +271,62 @@ func (space *TableSpace)Stats(path string) error {
 func (space *TableSpace)IndexHeader(path string) error {
 	errPrefix := "TableSpace::indexDetail()"
 
-	file := NewFile(path)
-	page := NewBTreePage(file)
+	file := space.newFile(path)
+	defer file.Close()
+
+	if err := space.IndexHeaderFile(file); err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return nil
+}
+
+func (space *TableSpace)IndexHeaderSystem(dataHomeDir string, dataFilePath string) error {
+	errPrefix := "TableSpace::IndexHeaderSystem()"
+
+	file, err := openSystemTableSpace(dataHomeDir, dataFilePath, space.useMmap)
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	defer file.Close()
 
-	if err := file.SetPath(path); err != nil {
-		return fmt.Errorf("%s: [%s]", errPrefix, err)
+	if err := space.IndexHeaderFile(file); err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
 	}
 
+	return nil
+}
+
+func (space *TableSpace)IndexHeaderFile(file *File) error {
+	errPrefix := "TableSpace::IndexHeaderFile()"
+
 	pageCount, err := file.getPageCount()
 	if err != nil {
-		return fmt.Errorf("%s: [%s]", errPrefix, err)
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
 	}
 
-	for pageNo := uint32(1); pageNo < pageCount; pageNo++ {
-		if err := file.SetPageNo(pageNo); err != nil {
-			return fmt.Errorf("%s: [%s]", errPrefix, err)
+	for pageNo := uint32(0); pageNo < pageCount; pageNo++ {
+		// 整页读入内存，后续字段都从内存中解析
+		filePage, err := file.ReadRawPage(pageNo)
+		if err != nil {
+			return fmt.Errorf("%s: [%w]", errPrefix, err)
+		}
+
+		// 检查页中存储的页号
+		if err := filePage.CheckPageNo(); err != nil {
+			if !errors.Is(err, ErrPageNoMismatch) {
+				return fmt.Errorf("%s: [%w]", errPrefix, err)
+			}
+			storedPageNo, _ := filePage.GetPageNo()
+			fmt.Printf("页号 = %d, 页中存储的页号 = %d, 页位置错误\n", pageNo, storedPageNo)
+			continue
 		}
 
 		// 读取页类型
-		pageType, err := file.GetPageType()
+		pageType, err := filePage.GetPageType()
+		if err != nil {
+			return fmt.Errorf("%s: [%w]", errPrefix, err)
+		}
 		pageTypeDesc := pageTypeMap[pageType]
 
 		fmt.Printf("页号 = %d, 页类型 = %s", pageNo, pageTypeDesc)
@@ -178,32 +336,33 @@ func (space *TableSpace)IndexHeader(path string) error {
 		} else {
 			fmt.Printf(", ")
 		}
+		page := NewBTreePage(filePage)
 
 		// 读取页所属的索引 ID
 		indexId, err := page.GetIndexId()
 		if err != nil {
-			return fmt.Errorf("%s: [%s]", errPrefix, err)
+			return fmt.Errorf("%s: [%w]", errPrefix, err)
 		}
-		fmt.Printf("索引 ID = %d, ", indexId)
+		fmt.Printf("索引 ID = %s, ", space.formatIndexId(indexId))
 
 		// 读取节点所在层级
 		level, err := page.GetPageLevel()
 		if err != nil {
-			return fmt.Errorf("%s: [%s]", errPrefix, err)
+			return fmt.Errorf("%s: [%w]", errPrefix, err)
 		}
 		fmt.Printf("索引层级 = %d, ", level)
 
 		// 读取分组（槽）的数量
 		slotsCount, err := page.GetSlotsCount()
 		if err != nil {
-			return fmt.Errorf("%s: [%s]", errPrefix, err)
+			return fmt.Errorf("%s: [%w]", errPrefix, err)
 		}
 		fmt.Printf("分组 = %d, ", slotsCount)
 
 		// 读取记录数量（含 infimum、supremum、已标记删除记录、正常记录）
 		nHeap, err := page.GetHeapCount()
 		if err != nil {
-			return fmt.Errorf("%s: [%s]", errPrefix, err)
+			return fmt.Errorf("%s: [%w]", errPrefix, err)
 		}
 		nHeap = nHeap - 32768
 		fmt.Printf("记录 = %d, ", nHeap)
@@ -211,63 +370,63 @@ func (space *TableSpace)IndexHeader(path string) error {
 		// 读取正常记录数量
 		nRecords, err := page.GetRecordCount()
 		if err != nil {
-			return fmt.Errorf("%s: [%s]", errPrefix, err)
+			return fmt.Errorf("%s: [%w]", errPrefix, err)
 		}
 		fmt.Printf("有效记录 = %d, ", nRecords)
 
 		// 读取空白空间首地址（未插入过记录的空间）
 		heapTop, err := page.GetHeapTop()
 		if err != nil {
-			return fmt.Errorf("%s: [%s]", errPrefix, err)
+			return fmt.Errorf("%s: [%w]", errPrefix, err)
 		}
 		fmt.Printf("未使用空间首地址 = %d, ", heapTop)
 
 		// 读取垃圾链表首地址
 		free, err := page.GetFreeOffset()
 		if err != nil {
-			return fmt.Errorf("%s: [%s]", errPrefix, err)
+			return fmt.Errorf("%s: [%w]", errPrefix, err)
 		}
 		fmt.Printf("垃圾链表首地址 = %d, ", free)
 
 		// 读取垃圾链表占用字节数
 		garbage, err := page.GetGarbageSize()
 		if err != nil {
-			return fmt.Errorf("%s: [%s]", errPrefix, err)
+			return fmt.Errorf("%s: [%w]", errPrefix, err)
 		}
 		fmt.Printf("垃圾链表占用空间 = %d, ", garbage)
 
 		// 读取最后插入记录的位置
 		lastInsert, err := page.GetLastInsertOffset()
 		if err != nil {
-			return fmt.Errorf("%s: [%s]", errPrefix, err)
+			return fmt.Errorf("%s: [%w]", errPrefix, err)
 		}
 		fmt.Printf("最后插入记录数据地址 = %d, ", lastInsert)
 
 		// 读取插入记录的方向
 		direction, err := page.GetLastInsertDirection()
 		if err != nil {
-			return fmt.Errorf("%s: [%s]", errPrefix, err)
+			return fmt.Errorf("%s: [%w]", errPrefix, err)
 		}
 		fmt.Printf("插入方向 = %d, ", direction)
 
 		// 读取同一个方向上插入的记录数量
 		directionCount, err := page.GetDirectionInsertCount()
 		if err != nil {
-			return fmt.Errorf("%s: [%s]", errPrefix, err)
+			return fmt.Errorf("%s: [%w]", errPrefix, err)
 		}
 		fmt.Printf("同一方向插入记录数 = %d, ", directionCount)
 
 		// 读取修改页的最大事务 ID，只有主键索引有该值
 		maxTrxId, err := page.GetMaxTrxId()
 		if err != nil {
-			return fmt.Errorf("%s: [%s]", errPrefix, err)
+			return fmt.Errorf("%s: [%w]", errPrefix, err)
 		}
 		fmt.Printf("最后修改页的事务 ID = %d", maxTrxId)
 
 		// 读取索引非叶子节点段的 inode 存储信息
 		topSpaceID, topPageNo, topPageOffset, err := page.GetBtrSegTop()
 		if err != nil {
-			return fmt.Errorf("%s: [%s]", errPrefix, err)
+			return fmt.Errorf("%s: [%w]", errPrefix, err)
 		}
 		if topPageOffset > 0 {
 			fmt.Printf(", 内结点段 [表空间 = %d, 页号 = %d, 页内位置 = %d]", topSpaceID, topPageNo, topPageOffset)
@@ -276,7 +435,7 @@ func (space *TableSpace)IndexHeader(path string) error {
 		// 读取索引叶子节点段的 inode 存储信息
 		leafSpaceId, leafPageNo, leafPageOffset, err := page.GetBtrSegLeaf()
 		if err != nil {
-			return fmt.Errorf("%s: [%s]", errPrefix, err)
+			return fmt.Errorf("%s: [%w]", errPrefix, err)
 		}
 		if leafPageOffset > 0 {
 			fmt.Printf(", 叶子节点段 [表空间 = %d, 页号 = %d, 页内位置 = %d]", leafSpaceId, leafPageNo, leafPageOffset)
@@ -286,4 +445,940 @@ func (space *TableSpace)IndexHeader(path string) error {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+func (space *TableSpace)Checksum(path string) error {
+	errPrefix := "TableSpace::Checksum()"
+
+	file := space.newFile(path)
+	defer file.Close()
+
+	if err := space.ChecksumFile(file); err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return nil
+}
+
+// ChecksumFile 校验所有页的检验和，输出检验和错误的页以及存储的值和计算出的值
+func (space *TableSpace)ChecksumFile(file *File) error {
+	errPrefix := "TableSpace::ChecksumFile()"
+
+	pageCount, err := file.getPageCount()
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	emptyCount := 0
+	corruptedCount := 0
+	algorithmStats := map[uint8]int{}
+
+	fmt.Printf("Checksum (%s):\n", file.GetPath())
+	for pageNo := uint32(0); pageNo < pageCount; pageNo++ {
+		result, err := file.VerifyPageChecksum(pageNo)
+		if err != nil {
+			return fmt.Errorf("%s: [%w]", errPrefix, err)
+		}
+
+		if result.Empty {
+			emptyCount++
+			continue
+		}
+
+		if result.Valid {
+			algorithmStats[result.Algorithm]++
+			continue
+		}
+
+		corruptedCount++
+		if result.Compressed {
+			fmt.Printf("    page %d: checksum mismatch, stored = 0x%08x, crc32 = 0x%08x, innodb = 0x%08x\n",
+				pageNo, result.StoredChecksum, result.Crc32Checksum, result.InnodbChecksum)
+		} else {
+			fmt.Printf("    page %d: checksum mismatch, stored = 0x%08x, trailer = 0x%08x, crc32 = 0x%08x, innodb = 0x%08x, innodb_old = 0x%08x\n",
+				pageNo, result.StoredChecksum, result.StoredTrailerChecksum, result.Crc32Checksum, result.InnodbChecksum, result.InnodbOldChecksum)
+		}
+	}
+
+	fmt.Printf("    total_page: %d\n", pageCount)
+	fmt.Printf("    empty_page: %d\n", emptyCount)
+	for _, algorithm := range []uint8{ChecksumAlgorithmCrc32, ChecksumAlgorithmInnodb, ChecksumAlgorithmNone} {
+		fmt.Printf("    %s_page: %d\n", checksumAlgorithmMap[algorithm], algorithmStats[algorithm])
+	}
+	fmt.Printf("    corrupted_page: %d\n", corruptedCount)
+	fmt.Println()
+
+	return nil
+}
+
+func (space *TableSpace)TornPages(path string) error {
+	errPrefix := "TableSpace::TornPages()"
+
+	file := space.newFile(path)
+	defer file.Close()
+
+	if err := space.TornPagesFile(file); err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return nil
+}
+
+// TornPagesFile 找出页头与页尾 Lsn 不一致（只写入了一部分）的页，
+// 同时输出检验和是否正确，用于崩溃后判断是从 doublewrite 还是从备份恢复
+func (space *TableSpace)TornPagesFile(file *File) error {
+	errPrefix := "TableSpace::TornPagesFile()"
+
+	pageCount, err := file.getPageCount()
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	tornCount := 0
+	checkedCount := 0
+
+	fmt.Printf("Torn Pages (%s):\n", file.GetPath())
+	for pageNo := uint32(0); pageNo < pageCount; pageNo++ {
+		page, err := file.ReadRawPage(pageNo)
+		if err != nil {
+			return fmt.Errorf("%s: [%w]", errPrefix, err)
+		}
+
+		trailer, err := page.CheckTrailer()
+		if err != nil {
+			return fmt.Errorf("%s: [%w]", errPrefix, err)
+		}
+
+		if !trailer.Checked {
+			continue
+		}
+		checkedCount++
+
+		if !trailer.Torn {
+			continue
+		}
+		tornCount++
+
+		checksum, err := page.VerifyChecksum()
+		if err != nil {
+			return fmt.Errorf("%s: [%w]", errPrefix, err)
+		}
+
+		pageType, err := page.GetPageType()
+		if err != nil {
+			return fmt.Errorf("%s: [%w]", errPrefix, err)
+		}
+
+		fmt.Printf("    page %d (%s): header lsn = %d (low 32 bits 0x%08x), trailer lsn = 0x%08x, checksum valid = %t\n",
+			pageNo, pageTypeMap[pageType], trailer.HeaderLsn, uint32(trailer.HeaderLsn), trailer.TrailerLsn, checksum.Valid)
+	}
+
+	fmt.Printf("    total_page: %d\n", pageCount)
+	fmt.Printf("    checked_page: %d\n", checkedCount)
+	fmt.Printf("    torn_page: %d\n", tornCount)
+	fmt.Println()
+
+	return nil
+}
+
+func (space *TableSpace)Extents(path string) error {
+	errPrefix := "TableSpace::Extents()"
+
+	file := space.newFile(path)
+	defer file.Close()
+
+	if err := space.ExtentsFile(file); err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return nil
+}
+
+// ExtentsFile 输出每个区的状态、所属段以及区中每个页是否已分配
+func (space *TableSpace)ExtentsFile(file *File) error {
+	errPrefix := "TableSpace::ExtentsFile()"
+
+	allocation, err := file.ReadAllocationMap()
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	fmt.Printf("Extents (%s, %d pages per extent):\n", file.GetPath(), allocation.ExtentPages)
+	for _, extent := range allocation.Extents {
+		lastPageNo := extent.FirstPageNo + extent.PageCount - 1
+		fmt.Printf("    pages %d-%d: state = %s, segment = %d, used = %d, %s\n",
+			extent.FirstPageNo, lastPageNo, extent.GetStateName(), extent.SegmentId, extent.GetUsedPages(), extent.GetBitmapString())
+	}
+	fmt.Println()
+
+	return nil
+}
+
+func (space *TableSpace)Segments(path string) error {
+	errPrefix := "TableSpace::Segments()"
+
+	file := space.newFile(path)
+	defer file.Close()
+
+	if err := space.SegmentsFile(file); err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return nil
+}
+
+// SegmentsFile 从每个索引的根页出发，输出索引的叶子节点段和非叶子节点段
+func (space *TableSpace)SegmentsFile(file *File) error {
+	errPrefix := "TableSpace::SegmentsFile()"
+
+	extentPages, err := file.GetExtentPages()
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	roots, err := file.FindIndexRoots(space.workers)
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	fmt.Printf("Segments (%s):\n", file.GetPath())
+	for _, rootPageNo := range roots {
+		segments, err := file.ReadIndexSegments(rootPageNo)
+		if err != nil {
+			return fmt.Errorf("%s: [%w]", errPrefix, err)
+		}
+
+		fmt.Printf("index %d (root page %d):\n", segments.IndexId, segments.RootPageNo)
+		printInode("leaf", segments.Leaf, extentPages)
+		printInode("non-leaf", segments.NonLeaf, extentPages)
+	}
+	fmt.Println()
+
+	return nil
+}
+
+func (space *TableSpace)SpaceIndexes(path string) error {
+	errPrefix := "TableSpace::SpaceIndexes()"
+
+	file := space.newFile(path)
+	defer file.Close()
+
+	if err := space.SpaceIndexesFile(file); err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return nil
+}
+
+// SpaceIndexesFile 输出每个索引、每个段分配的页和已使用的页，
+// 分配的页包括段已经预留但还没有使用的页，Stats() 按页类型统计时看不到这部分空间
+func (space *TableSpace)SpaceIndexesFile(file *File) error {
+	errPrefix := "TableSpace::SpaceIndexesFile()"
+
+	indexes, err := file.ReadIndexSpaces(space.workers)
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	physicalPageSize, err := file.GetPhysicalPageSize()
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	fmt.Printf("Index Space (%s):\n", file.GetPath())
+	fmt.Printf("%-10s %-6s %-10s %-10s %-10s %-10s %-6s %-6s %-9s %-6s %s\n",
+		"index", "root", "fseg", "fseg_id", "allocated", "used", "frag", "full", "not_full", "free", "fill_factor")
+	totalAllocated, totalUsed := uint64(0), uint64(0)
+	for _, index := range indexes {
+		printSegmentSpace(index, "internal", index.NonLeaf)
+		printSegmentSpace(index, "leaf", index.Leaf)
+		totalAllocated += uint64(index.GetAllocatedPages())
+		totalUsed += uint64(index.GetUsedPages())
+	}
+	fmt.Printf("    total allocated: %d pages (%d bytes)\n", totalAllocated, totalAllocated * uint64(physicalPageSize))
+	fmt.Printf("    total used: %d pages (%d bytes)\n", totalUsed, totalUsed * uint64(physicalPageSize))
+	fmt.Println()
+
+	return nil
+}
+
+func (space *TableSpace)Map(path string) error {
+	errPrefix := "TableSpace::Map()"
+
+	file := space.newFile(path)
+	defer file.Close()
+
+	if err := space.MapFile(file); err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return nil
+}
+
+// MapFile 在终端中输出表空间地图，每个区一行，每个页一个带颜色的字符
+func (space *TableSpace)MapFile(file *File) error {
+	errPrefix := "TableSpace::MapFile()"
+
+	spaceMap, err := file.ReadSpaceMap(space.workers)
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	if err := spaceMap.RenderAnsi(os.Stdout, true); err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return nil
+}
+
+func (space *TableSpace)MapSvg(path string, svgPath string) error {
+	errPrefix := "TableSpace::MapSvg()"
+
+	file := space.newFile(path)
+	defer file.Close()
+
+	if err := space.MapSvgFile(file, svgPath); err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return nil
+}
+
+// MapSvgFile 把表空间地图写入 svgPath 指定的 SVG 文件
+func (space *TableSpace)MapSvgFile(file *File, svgPath string) error {
+	errPrefix := "TableSpace::MapSvgFile()"
+
+	spaceMap, err := file.ReadSpaceMap(space.workers)
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	svgFile, err := os.Create(svgPath)
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	if err := spaceMap.RenderSvg(svgFile); err != nil {
+		svgFile.Close()
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	if err := svgFile.Close(); err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return nil
+}
+
+func (space *TableSpace)IBufBitmap(path string) error {
+	errPrefix := "TableSpace::IBufBitmap()"
+
+	file := space.newFile(path)
+	defer file.Close()
+
+	if err := space.IBufBitmapFile(file); err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return nil
+}
+
+// IBufBitmapFile 输出 Change Buffer 位图的统计信息，以及还有待合并操作的页
+func (space *TableSpace)IBufBitmapFile(file *File) error {
+	errPrefix := "TableSpace::IBufBitmapFile()"
+
+	physicalPageSize, err := file.GetPhysicalPageSize()
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	entries, err := file.ReadIBufBitmap()
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	freeSpaceStats := map[uint8]uint32{}
+	bufferedCount, ibufCount := 0, 0
+	for _, entry := range entries {
+		freeSpaceStats[entry.FreeSpace]++
+		if entry.Buffered {
+			bufferedCount++
+		}
+		if entry.IBuf {
+			ibufCount++
+		}
+	}
+
+	fmt.Printf("Change Buffer Bitmap (%s):\n", file.GetPath())
+	fmt.Printf("    total_page: %d\n", len(entries))
+	for freeSpace := uint8(0); freeSpace < 4; freeSpace++ {
+		entry := IBufBitmapEntry{FreeSpace: freeSpace}
+		fmt.Printf("    free_space_%d (>= %d bytes): %d\n", freeSpace, entry.GetFreeSpaceBytes(physicalPageSize), freeSpaceStats[freeSpace])
+	}
+	fmt.Printf("    ibuf_page: %d\n", ibufCount)
+	fmt.Printf("    buffered_page: %d\n", bufferedCount)
+	fmt.Println()
+
+	fmt.Printf("Buffered Pages:\n")
+	for _, entry := range entries {
+		if !entry.Buffered {
+			continue
+		}
+
+		page, err := file.ReadRawPage(entry.PageNo)
+		if err != nil {
+			return fmt.Errorf("%s: [%w]", errPrefix, err)
+		}
+		pageType, err := page.GetPageType()
+		if err != nil {
+			return fmt.Errorf("%s: [%w]", errPrefix, err)
+		}
+
+		fmt.Printf("    page %d (%s", entry.PageNo, pageTypeMap[pageType])
+		if pageType == pageTypeIndex {
+			btreePage := NewBTreePage(page)
+			indexId, err := btreePage.GetIndexId()
+			if err != nil {
+				return fmt.Errorf("%s: [%w]", errPrefix, err)
+			}
+			fmt.Printf(", index %d", indexId)
+		}
+		fmt.Printf("): free space class = %d\n", entry.FreeSpace)
+	}
+	fmt.Println()
+
+	return nil
+}
+
+func (space *TableSpace)Verify(path string) error {
+	errPrefix := "TableSpace::Verify()"
+
+	file := space.newFile(path)
+	defer file.Close()
+
+	if err := space.VerifyFile(file); err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return nil
+}
+
+func (space *TableSpace)VerifySystem(dataHomeDir string, dataFilePath string) error {
+	errPrefix := "TableSpace::VerifySystem()"
+
+	file, err := openSystemTableSpace(dataHomeDir, dataFilePath, space.useMmap)
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	defer file.Close()
+
+	if err := space.VerifyFile(file); err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return nil
+}
+
+// VerifyFile 离线检查空间管理信息的一致性，类似于针对空间管理的 CHECK TABLE，
+// 发现的问题只输出，不作为错误返回
+func (space *TableSpace)VerifyFile(file *File) error {
+	errPrefix := "TableSpace::VerifyFile()"
+
+	result, err := file.Verify(space.workers)
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	fmt.Printf("Verify (%s):\n", file.GetPath())
+	for _, problem := range result.Problems {
+		if problem.PageNo == fileNull {
+			fmt.Printf("    %s: %s\n", problem.Kind, problem.Detail)
+		} else {
+			fmt.Printf("    %s: page %d: %s\n", problem.Kind, problem.PageNo, problem.Detail)
+		}
+	}
+
+	fmt.Printf("    total_page: %d\n", result.PageCount)
+	fmt.Printf("    segment: %d\n", result.SegmentCount)
+	counts := result.GetProblemCounts()
+	for _, kind := range verifyKinds {
+		fmt.Printf("    %s: %d\n", kind, counts[kind])
+	}
+	if result.IsConsistent() {
+		fmt.Printf("    status: OK\n")
+	} else {
+		fmt.Printf("    status: Corrupt\n")
+	}
+	fmt.Println()
+
+	return nil
+}
+
+func (space *TableSpace)DiskUsage(path string) error {
+	errPrefix := "TableSpace::DiskUsage()"
+
+	file := space.newFile(path)
+	defer file.Close()
+
+	if err := space.DiskUsageFile(file); err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return nil
+}
+
+// DiskUsageFile 输出文件大小与磁盘上实际占用的空间，以及每个区、每个含有空洞的页实际占用的字节数
+func (space *TableSpace)DiskUsageFile(file *File) error {
+	errPrefix := "TableSpace::DiskUsageFile()"
+
+	usage, err := file.ReadDiskUsage()
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	fmt.Printf("Disk Usage (%s):\n", file.GetPath())
+	fmt.Printf("    logical_size: %d\n", usage.LogicalSize)
+	fmt.Printf("    allocated_size: %d\n", usage.AllocatedSize)
+	fmt.Printf("    data_size: %d\n", usage.DataSize)
+	if usage.LogicalSize > 0 {
+		fmt.Printf("    saved: %d bytes (%.2f%%)\n", usage.LogicalSize - usage.AllocatedSize,
+			float64(usage.LogicalSize - usage.AllocatedSize) * 100 / float64(usage.LogicalSize))
+	}
+	fmt.Printf("    total_page: %d\n", len(usage.PageDataSizes))
+	fmt.Printf("    holed_page: %d\n", usage.GetHoledPages())
+	fmt.Println()
+
+	pageCount := uint32(len(usage.PageDataSizes))
+	fmt.Printf("Extent Disk Usage:\n")
+	for first := uint32(0); first < pageCount; first += usage.ExtentPages {
+		last := first + usage.ExtentPages
+		if last > pageCount {
+			last = pageCount
+		}
+
+		logical := uint64(last - first) * uint64(usage.PageSize)
+		dataSize := usage.GetExtentDataSize(first / usage.ExtentPages)
+		fmt.Printf("    pages %d-%d: logical = %d, on disk = %d (%.2f%%)\n",
+			first, last - 1, logical, dataSize, float64(dataSize) * 100 / float64(logical))
+
+		for pageNo := first; pageNo < last; pageNo++ {
+			if size := usage.PageDataSizes[pageNo]; size < usage.PageSize {
+				fmt.Printf("        page %d: on disk = %d\n", pageNo, size)
+			}
+		}
+	}
+	fmt.Println()
+
+	return nil
+}
+
+func (space *TableSpace)Sdi(path string) error {
+	errPrefix := "TableSpace::Sdi()"
+
+	file := space.newFile(path)
+	defer file.Close()
+
+	if err := space.SdiFile(file); err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return nil
+}
+
+// SdiFile 按 ibd2sdi 的格式输出表空间中的 SDI（MySQL 8.0 的表定义）
+func (space *TableSpace)SdiFile(file *File) error {
+	errPrefix := "TableSpace::SdiFile()"
+
+	records, err := file.ReadSdi()
+	if errors.Is(err, ErrCompressedPageUnsupported) {
+		return fmt.Errorf("%s: [%s is a compressed tablespace (ROW_FORMAT=COMPRESSED), reading its SDI is not supported: %w]",
+			errPrefix, file.GetPath(), ErrCompressedPageUnsupported)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	if err := WriteSdiJson(os.Stdout, records); err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return nil
+}
+
+func (space *TableSpace)TrxSys(path string) error {
+	errPrefix := "TableSpace::TrxSys()"
+
+	file := space.newFile(path)
+	defer file.Close()
+
+	if err := space.TrxSysFile(file); err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return nil
+}
+
+// TrxSysSystem 输出由多个数据文件组成的系统表空间的 TRX_SYS 页
+func (space *TableSpace)TrxSysSystem(dataHomeDir string, dataFilePath string) error {
+	errPrefix := "TableSpace::TrxSysSystem()"
+
+	file, err := openSystemTableSpace(dataHomeDir, dataFilePath, space.useMmap)
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	defer file.Close()
+
+	if err := space.TrxSysFile(file); err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return nil
+}
+
+// TrxSysFile 输出系统表空间 TRX_SYS 页中的最大事务 ID、回滚段槽、binlog 位置和 doublewrite 头，
+// 恢复冷备份时可以直接从文件中读取最后提交的事务对应的 binlog 位置
+func (space *TableSpace)TrxSysFile(file *File) error {
+	errPrefix := "TableSpace::TrxSysFile()"
+
+	header, err := file.ReadTrxSysHeader()
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	printTrxSysHeader(file.GetPath(), header)
+
+	return nil
+}
+
+// RollbackSegments 输出回滚段和其中的 undo 段，path 为系统表空间（ibdata1）或 8.0 的 undo 表空间（undo_001），
+// undoPaths 为 TRX_SYS 中的回滚段所在的其他表空间（5.7 的 undo001 等）
+func (space *TableSpace)RollbackSegments(path string, undoPaths []string) error {
+	errPrefix := "TableSpace::RollbackSegments()"
+
+	file := space.newFile(path)
+	defer file.Close()
+
+	if err := space.rollbackSegments(file, undoPaths); err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return nil
+}
+
+// RollbackSegmentsSystem 输出由多个数据文件组成的系统表空间中 TRX_SYS 页引用的回滚段
+func (space *TableSpace)RollbackSegmentsSystem(dataHomeDir string, dataFilePath string, undoPaths []string) error {
+	errPrefix := "TableSpace::RollbackSegmentsSystem()"
+
+	file, err := openSystemTableSpace(dataHomeDir, dataFilePath, space.useMmap)
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	defer file.Close()
+
+	if err := space.rollbackSegments(file, undoPaths); err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return nil
+}
+
+func (space *TableSpace)rollbackSegments(file *File, undoPaths []string) error {
+	undoFiles := make([]*File, 0, len(undoPaths))
+	defer func() {
+		for _, undoFile := range undoFiles {
+			_ = undoFile.Close()
+		}
+	}()
+
+	for _, path := range undoPaths {
+		undoFiles = append(undoFiles, space.newFile(path))
+	}
+
+	return space.RollbackSegmentsFile(file, undoFiles)
+}
+
+// RollbackSegmentsFile 输出 file 中的回滚段槽指向的回滚段：最大页数、history 链表的长度和每个已使用的 undo 段的状态。
+// 回滚段在其他表空间中时从 undoFiles 中按表空间 ID 查找，找不到时只输出槽的位置。
+// 所有回滚段的 history 链表长度之和即 history list length，服务器无法启动时可以从文件中直接读取
+func (space *TableSpace)RollbackSegmentsFile(file *File, undoFiles []*File) error {
+	errPrefix := "TableSpace::RollbackSegmentsFile()"
+
+	slots, err := file.ReadRollbackSegmentSlots()
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	files := map[uint32]*File{}
+	for _, f := range append([]*File{file}, undoFiles...) {
+		spaceId, err := f.GetSpaceId()
+		if err != nil {
+			return fmt.Errorf("%s: [%w]", errPrefix, err)
+		}
+		files[spaceId] = f
+	}
+
+	historyLength := uint32(0)
+	historySize := uint32(0)
+	stateCounts := map[uint16]int{}
+	unknownStates := 0
+	headers := []RollbackSegmentHeader{}
+	missing := []RollbackSegmentSlot{}
+	for _, slot := range slots {
+		f := files[slot.SpaceId]
+		if f == nil {
+			missing = append(missing, slot)
+			continue
+		}
+
+		header, err := f.ReadRollbackSegment(slot)
+		if err != nil {
+			return fmt.Errorf("%s: [%s: %w]", errPrefix, f.GetPath(), err)
+		}
+		headers = append(headers, header)
+
+		historyLength += header.History.Length
+		historySize += header.HistorySize
+		for _, segment := range header.UndoSegments {
+			if _, ok := undoStateMap[segment.State]; ok {
+				stateCounts[segment.State]++
+			} else {
+				unknownStates++
+			}
+		}
+	}
+
+	fmt.Printf("Rollback Segments (%s):\n", file.GetPath())
+	fmt.Printf("    rollback_segments: %d\n", len(slots))
+	fmt.Printf("    history_list_length: %d\n", historyLength)
+	fmt.Printf("    history_size: %d pages\n", historySize)
+	states := make([]uint16, 0, len(undoStateMap))
+	for state := range undoStateMap {
+		states = append(states, state)
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i] < states[j] })
+	for _, state := range states {
+		if count := stateCounts[state]; count > 0 {
+			fmt.Printf("    undo_segments_%s: %d\n", undoStateMap[state], count)
+		}
+	}
+	if unknownStates > 0 {
+		fmt.Printf("    undo_segments_unknown: %d\n", unknownStates)
+	}
+	for _, slot := range missing {
+		fmt.Printf("    rollback segment %d: space %d, page %d (tablespace not opened)\n", slot.SlotNo, slot.SpaceId, slot.PageNo)
+	}
+	fmt.Println()
+
+	for _, header := range headers {
+		printRollbackSegment(header)
+	}
+
+	return nil
+}
+
+// newDoublewriteFile 打开 doublewrite buffer 所在的文件：#ib_*.dblwr 文件或系统表空间
+func (space *TableSpace)newDoublewriteFile(path string) (*File, error) {
+	if !IsDoublewriteFile(path) {
+		return space.newFile(path), nil
+	}
+
+	file, err := NewDoublewriteFile(path)
+	if err != nil {
+		return nil, err
+	}
+	file.SetUseMmap(space.useMmap)
+
+	return file, nil
+}
+
+// Doublewrite 列出 doublewrite buffer 中的页副本，bufferPaths 为系统表空间（ibdata1）或 #ib_*.dblwr 文件，
+// targetPath 不为空时与目标表空间中对应的页比较
+func (space *TableSpace)Doublewrite(bufferPaths []string, targetPath string) error {
+	errPrefix := "TableSpace::Doublewrite()"
+
+	if err := space.doublewrite(bufferPaths, targetPath, ""); err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return nil
+}
+
+// DoublewriteRestore 与 Doublewrite() 相同，并把目标表空间复制到 repairedPath，
+// 损坏的页替换为 doublewrite 中完整的副本，原文件不会被修改
+func (space *TableSpace)DoublewriteRestore(bufferPaths []string, targetPath string, repairedPath string) error {
+	errPrefix := "TableSpace::DoublewriteRestore()"
+
+	if targetPath == "" || repairedPath == "" {
+		return fmt.Errorf("%s: [%w]", errPrefix, ErrEmptyPath)
+	}
+
+	if err := space.doublewrite(bufferPaths, targetPath, repairedPath); err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return nil
+}
+
+func (space *TableSpace)doublewrite(bufferPaths []string, targetPath string, repairedPath string) error {
+	buffers := make([]*File, 0, len(bufferPaths))
+	defer func() {
+		for _, buffer := range buffers {
+			_ = buffer.Close()
+		}
+	}()
+
+	for _, path := range bufferPaths {
+		buffer, err := space.newDoublewriteFile(path)
+		if err != nil {
+			return err
+		}
+		buffers = append(buffers, buffer)
+	}
+
+	var target *File
+	if targetPath != "" {
+		target = space.newFile(targetPath)
+		defer target.Close()
+	}
+
+	return space.DoublewriteFile(buffers, target, repairedPath)
+}
+
+// DoublewriteFile 列出 buffers 中的页副本，target 不为空时与其中对应的页比较（Lsn、检验和），
+// repairedPath 不为空时写入修复后的表空间副本
+func (space *TableSpace)DoublewriteFile(buffers []*File, target *File, repairedPath string) error {
+	errPrefix := "TableSpace::DoublewriteFile()"
+
+	copies := []DoublewritePage{}
+	for _, buffer := range buffers {
+		bufferCopies, err := buffer.ReadDoublewritePages()
+		if err != nil {
+			return fmt.Errorf("%s: [%w]", errPrefix, err)
+		}
+
+		fmt.Printf("Doublewrite Buffer (%s):\n", buffer.GetPath())
+		for _, pageCopy := range bufferCopies {
+			fmt.Printf("    slot %d: space %d, page %d, lsn %d\n", pageCopy.Slot, pageCopy.SpaceId, pageCopy.PageNo, pageCopy.Lsn)
+		}
+		fmt.Printf("    total_copy: %d\n", len(bufferCopies))
+		fmt.Println()
+
+		copies = append(copies, bufferCopies...)
+	}
+
+	if target == nil {
+		return nil
+	}
+
+	comparisons, err := target.CompareDoublewrite(copies)
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	fmt.Printf("Doublewrite Check (%s):\n", target.GetPath())
+	counts := map[string]int{}
+	for _, comparison := range comparisons {
+		counts[comparison.Status]++
+
+		pageCopy := comparison.Copy
+		fmt.Printf("    page %d: %s, copy lsn %d (%s slot %d, valid = %t)", pageCopy.PageNo, comparison.Status,
+			pageCopy.Lsn, pageCopy.Source, pageCopy.Slot, comparison.CopyValid)
+		if comparison.Status != DoublewriteOutOfRange {
+			fmt.Printf(", target lsn %d (valid = %t, torn = %t)", comparison.TargetLsn, comparison.TargetValid, comparison.TargetTorn)
+		}
+		fmt.Println()
+	}
+	fmt.Printf("    total_copy: %d\n", len(comparisons))
+	for _, status := range doublewriteStatuses {
+		fmt.Printf("    %s: %d\n", status, counts[status])
+	}
+	fmt.Println()
+
+	if repairedPath == "" {
+		return nil
+	}
+
+	restored, err := target.WriteRepairedCopy(comparisons, repairedPath)
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	fmt.Printf("Repaired Copy (%s):\n", repairedPath)
+	for _, pageNo := range restored {
+		fmt.Printf("    page %d: restored from doublewrite\n", pageNo)
+	}
+	fmt.Printf("    restored_page: %d\n", len(restored))
+	fmt.Println()
+
+	return nil
+}
+
+func (space *TableSpace)Dictionary(path string) error {
+	errPrefix := "TableSpace::Dictionary()"
+
+	file := space.newFile(path)
+	defer file.Close()
+
+	if err := space.DictionaryFile(file); err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return nil
+}
+
+func (space *TableSpace)DictionarySystem(dataHomeDir string, dataFilePath string) error {
+	errPrefix := "TableSpace::DictionarySystem()"
+
+	file, err := openSystemTableSpace(dataHomeDir, dataFilePath, space.useMmap)
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	defer file.Close()
+
+	if err := space.DictionaryFile(file); err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return nil
+}
+
+// DictionaryFile 输出 5.7 数据字典中的所有表：表空间 ID、行格式、列、索引的根页和字段
+func (space *TableSpace)DictionaryFile(file *File) error {
+	errPrefix := "TableSpace::DictionaryFile()"
+
+	dictionary, err := file.ReadDataDictionary()
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	printDataDictionary(file.GetPath(), dictionary)
+
+	return nil
+}
+
+func (space *TableSpace)MysqlDictionary(path string) error {
+	errPrefix := "TableSpace::MysqlDictionary()"
+
+	file := space.newFile(path)
+	defer file.Close()
+
+	if err := space.MysqlDictionaryFile(file); err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return nil
+}
+
+// MysqlDictionaryFile 输出 8.0 mysql.ibd 中的数据字典：表空间 ID 与表空间名，InnoDB 的表 ID、索引 ID 与库名、表名、索引名
+func (space *TableSpace)MysqlDictionaryFile(file *File) error {
+	errPrefix := "TableSpace::MysqlDictionaryFile()"
+
+	dictionary, err := file.ReadMysqlDictionary()
+	if err != nil {
+		return fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	printDataDictionary(file.GetPath(), dictionary)
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package innobase
+
+import (
+	"encoding/binary"
+)
+
+const testPageSize = 16384
+
+// newTestPagesData 构造 pageCount 个 16K 页的数据，每个页中写入页号（FIL_PAGE_OFFSET），
+// 其余字节为 0，FSP_SPACE_FLAGS 为 0 时按 16K 页解析
+func newTestPagesData(pageCount int) []byte {
+	data := make([]byte, pageCount * testPageSize)
+	for pageNo := 0; pageNo < pageCount; pageNo++ {
+		binary.BigEndian.PutUint32(data[pageNo * testPageSize + int(fileOffsetPageNo):], uint32(pageNo))
+	}
+
+	return data
+}
+
+// newTestFile 构造 pageCount 个页的表空间，build 在写入页号之后写入其他数据
+func newTestFile(pageCount int, build func(data []byte)) *File {
+	data := newTestPagesData(pageCount)
+	if build != nil {
+		build(data)
+	}
+
+	return NewFileFromSource(NewBytesSource("test.ibd", data))
+}
+
+func putTestPageType(data []byte, pageNo uint32, pageType uint16) {
+	binary.BigEndian.PutUint16(data[int(pageNo) * testPageSize + int(fileOffsetPageType):], pageType)
+}
+
+// testFill 返回从 start 开始递增的 n 个字节
+func testFill(start byte, n int) []byte {
+	buf := make([]byte, n)
+	for i := range buf {
+		buf[i] = start + byte(i)
+	}
+
+	return buf
+}
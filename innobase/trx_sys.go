@@ -0,0 +1,287 @@
+package innobase
+
+import (
+	"bytes"
+	"fmt"
+)
+
+const (
+	trxSysPageNo uint32 = 5 // 系统表空间中 TRX_SYS 页的页号
+	trxSysOffset uint32 = 38 // TRX_SYS 头在页中的起始位置
+
+	trxSysOffsetTrxIdStore uint32 = 0 // 最大事务 ID，8 字节
+	trxSysOffsetFsegHeader uint32 = 8 // TRX_SYS 段的段头，10 字节
+	trxSysOffsetRsegs uint32 = 18 // 回滚段槽数组
+
+	trxSysRsegSlots uint32 = 128 // TRX_SYS_N_RSEGS
+	trxSysRsegSlotSize uint32 = 8 // 每个槽：回滚段头所在的表空间 ID 4 字节、页号 4 字节
+	trxSysRsegOffsetPageNo uint32 = 4
+
+	trxSysTrxIdWriteMargin uint64 = 256 // TRX_SYS_TRX_ID_WRITE_MARGIN
+
+	trxSysMysqlLogInfoEnd uint32 = 1000 // MySQL binlog 信息距页尾的距离
+	trxSysMysqlLogOffsetMagicN uint32 = 0 // 魔数，4 字节
+	trxSysMysqlLogOffsetHigh uint32 = 4 // binlog 位置的高 4 字节
+	trxSysMysqlLogOffsetLow uint32 = 8 // binlog 位置的低 4 字节
+	trxSysMysqlLogOffsetName uint32 = 12 // binlog 文件名，以 0 结尾
+	trxSysMysqlLogNameLen uint32 = 512
+	trxSysMysqlLogMagicN uint32 = 873422344 // TRX_SYS_MYSQL_LOG_MAGIC_N
+
+	trxSysDoublewriteEnd uint32 = 200 // doublewrite 头距页尾的距离
+	trxSysDoublewriteOffsetFseg uint32 = 0 // doublewrite 段的段头，10 字节
+	trxSysDoublewriteOffsetMagic uint32 = 10 // 魔数，4 字节
+	trxSysDoublewriteOffsetBlock1 uint32 = 14 // 第一个块的起始页号，4 字节
+	trxSysDoublewriteOffsetBlock2 uint32 = 18 // 第二个块的起始页号，4 字节
+	trxSysDoublewriteOffsetSpaceIdStored uint32 = 34 // doublewrite 中的页是否存储了表空间 ID，4 字节
+	trxSysDoublewriteMagicN uint32 = 536853855 // TRX_SYS_DOUBLEWRITE_MAGIC_N
+	trxSysDoublewriteSpaceIdStoredN uint32 = 1783657386 // TRX_SYS_DOUBLEWRITE_SPACE_ID_STORED_N
+)
+
+// RollbackSegmentSlot TRX_SYS 页中一个已使用的回滚段槽
+type RollbackSegmentSlot struct {
+	SlotNo uint32
+	SpaceId uint32 // 回滚段头所在的表空间
+	PageNo uint32 // 回滚段头的页号
+}
+
+// BinlogInfo 最后一个提交的事务对应的 binlog 位置，由 InnoDB 在提交时写入
+type BinlogInfo struct {
+	Valid bool // 魔数正确时才有 binlog 信息
+	Name string
+	Offset uint64
+}
+
+// DoublewriteHeader 系统表空间中 doublewrite buffer 的头，两个块各占一个区
+type DoublewriteHeader struct {
+	Fseg FsegHeader
+	Magic uint32
+	Block1 uint32 // 第一个块的起始页号
+	Block2 uint32 // 第二个块的起始页号
+	BlockPages uint32 // 每个块的页数量，等于区大小
+	SpaceIdStored bool
+}
+
+// TrxSysHeader 系统表空间第 5 页中的事务系统信息
+type TrxSysHeader struct {
+	// 页中存储的最大事务 ID，只在跨过 256 的整数倍时写入，实际分配过的事务 ID 可能比它大，
+	// 启动时 InnoDB 在它的基础上再加 2 * 256
+	MaxTrxId uint64
+	Fseg FsegHeader
+	RollbackSegments []RollbackSegmentSlot // 已使用的回滚段槽，未使用的槽页号为 FIL_NULL
+	Binlog BinlogInfo
+	Doublewrite DoublewriteHeader
+}
+
+// IsCreated doublewrite buffer 是否已经创建
+func (header *DoublewriteHeader)IsCreated() bool {
+	return header.Magic == trxSysDoublewriteMagicN
+}
+
+// Contains 页是否在 doublewrite buffer 的两个块中
+func (header *DoublewriteHeader)Contains(pageNo uint32) bool {
+	if !header.IsCreated() {
+		return false
+	}
+
+	return (pageNo >= header.Block1 && pageNo < header.Block1 + header.BlockPages) ||
+		(pageNo >= header.Block2 && pageNo < header.Block2 + header.BlockPages)
+}
+
+// getBinlogInfo 解析页尾前 1000 字节处的 binlog 信息
+func (page *Page)getBinlogInfo() (BinlogInfo, error) {
+	offset := page.GetSize() - trxSysMysqlLogInfoEnd
+
+	magic, err := page.getUint32(offset + trxSysMysqlLogOffsetMagicN)
+	if err != nil {
+		return BinlogInfo{}, withField(err, "TRX_SYS_MYSQL_LOG_MAGIC_N_FLD")
+	}
+	if magic != trxSysMysqlLogMagicN {
+		return BinlogInfo{}, nil
+	}
+
+	high, err := page.getUint32(offset + trxSysMysqlLogOffsetHigh)
+	if err != nil {
+		return BinlogInfo{}, withField(err, "TRX_SYS_MYSQL_LOG_OFFSET_HIGH")
+	}
+
+	low, err := page.getUint32(offset + trxSysMysqlLogOffsetLow)
+	if err != nil {
+		return BinlogInfo{}, withField(err, "TRX_SYS_MYSQL_LOG_OFFSET_LOW")
+	}
+
+	name, err := page.getBytes(offset + trxSysMysqlLogOffsetName, trxSysMysqlLogNameLen)
+	if err != nil {
+		return BinlogInfo{}, withField(err, "TRX_SYS_MYSQL_LOG_NAME")
+	}
+	if end := bytes.IndexByte(name, 0); end >= 0 {
+		name = name[:end]
+	}
+
+	return BinlogInfo{
+		Valid: true,
+		Name: string(name),
+		Offset: uint64(high) << 32 | uint64(low),
+	}, nil
+}
+
+// getDoublewriteHeader 解析页尾前 200 字节处的 doublewrite 头
+func (page *Page)getDoublewriteHeader(extentPages uint32) (DoublewriteHeader, error) {
+	offset := page.GetSize() - trxSysDoublewriteEnd
+	header := DoublewriteHeader{BlockPages: extentPages}
+
+	fseg, err := page.getFsegHeader(offset + trxSysDoublewriteOffsetFseg)
+	if err != nil {
+		return header, withField(err, "TRX_SYS_DOUBLEWRITE_FSEG")
+	}
+	header.Fseg = fseg
+
+	fields := []struct {
+		offset uint32
+		field string
+		value *uint32
+	}{
+		{trxSysDoublewriteOffsetMagic, "TRX_SYS_DOUBLEWRITE_MAGIC", &header.Magic},
+		{trxSysDoublewriteOffsetBlock1, "TRX_SYS_DOUBLEWRITE_BLOCK1", &header.Block1},
+		{trxSysDoublewriteOffsetBlock2, "TRX_SYS_DOUBLEWRITE_BLOCK2", &header.Block2},
+	}
+	for _, f := range fields {
+		value, err := page.getUint32(offset + f.offset)
+		if err != nil {
+			return header, withField(err, f.field)
+		}
+		*f.value = value
+	}
+
+	spaceIdStored, err := page.getUint32(offset + trxSysDoublewriteOffsetSpaceIdStored)
+	if err != nil {
+		return header, withField(err, "TRX_SYS_DOUBLEWRITE_SPACE_ID_STORED")
+	}
+	header.SpaceIdStored = spaceIdStored == trxSysDoublewriteSpaceIdStoredN
+
+	return header, nil
+}
+
+// GetTrxSysHeader 解析 TRX_SYS 页
+func (page *Page)GetTrxSysHeader(extentPages uint32) (TrxSysHeader, error) {
+	errPrefix := "Page::GetTrxSysHeader()"
+
+	header := TrxSysHeader{}
+
+	maxTrxId, err := page.getUint64(trxSysOffset + trxSysOffsetTrxIdStore)
+	if err != nil {
+		return header, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "TRX_SYS_TRX_ID_STORE"))
+	}
+	header.MaxTrxId = maxTrxId
+
+	fseg, err := page.getFsegHeader(trxSysOffset + trxSysOffsetFsegHeader)
+	if err != nil {
+		return header, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "TRX_SYS_FSEG_HEADER"))
+	}
+	header.Fseg = fseg
+
+	header.RollbackSegments = []RollbackSegmentSlot{}
+	for slotNo := uint32(0); slotNo < trxSysRsegSlots; slotNo++ {
+		offset := trxSysOffset + trxSysOffsetRsegs + slotNo * trxSysRsegSlotSize
+
+		pageNo, err := page.getUint32(offset + trxSysRsegOffsetPageNo)
+		if err != nil {
+			return header, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "TRX_SYS_RSEG_PAGE_NO"))
+		}
+		if pageNo == fileNull {
+			continue
+		}
+
+		spaceId, err := page.getUint32(offset)
+		if err != nil {
+			return header, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "TRX_SYS_RSEG_SPACE"))
+		}
+
+		header.RollbackSegments = append(header.RollbackSegments, RollbackSegmentSlot{
+			SlotNo: slotNo,
+			SpaceId: spaceId,
+			PageNo: pageNo,
+		})
+	}
+
+	binlog, err := page.getBinlogInfo()
+	if err != nil {
+		return header, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	header.Binlog = binlog
+
+	doublewrite, err := page.getDoublewriteHeader(extentPages)
+	if err != nil {
+		return header, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	header.Doublewrite = doublewrite
+
+	return header, nil
+}
+
+// ReadTrxSysHeader 读取系统表空间的 TRX_SYS 页，页类型不是 TRX_SYS 时返回 ErrNotTrxSysPage
+func (file *File)ReadTrxSysHeader() (TrxSysHeader, error) {
+	errPrefix := "File::ReadTrxSysHeader()"
+
+	extentPages, err := file.GetExtentPages()
+	if err != nil {
+		return TrxSysHeader{}, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	page, err := file.ReadPage(trxSysPageNo)
+	if err != nil {
+		return TrxSysHeader{}, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	pageType, err := page.GetPageType()
+	if err != nil {
+		return TrxSysHeader{}, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	if pageType != pageTypeTrxSys {
+		err := fmt.Errorf("%w: page type %d (%s)", ErrNotTrxSysPage, pageType, pageTypeMap[pageType])
+		return TrxSysHeader{}, fmt.Errorf("%s: [%w]", errPrefix, newPageError(trxSysPageNo, uint32(fileOffsetPageType), "FIL_PAGE_TYPE", err))
+	}
+
+	header, err := page.GetTrxSysHeader(extentPages)
+	if err != nil {
+		return TrxSysHeader{}, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return header, nil
+}
+
+func printTrxSysHeader(path string, header TrxSysHeader) {
+	fmt.Printf("Transaction System (%s):\n", path)
+	fmt.Printf("    max_trx_id: %d (stored every %d transactions)\n", header.MaxTrxId, trxSysTrxIdWriteMargin)
+	fmt.Printf("    fseg: space %d, page %d, offset %d\n", header.Fseg.SpaceId, header.Fseg.Inode.PageNo, header.Fseg.Inode.Offset)
+	fmt.Println()
+
+	fmt.Printf("Rollback Segments (%d):\n", len(header.RollbackSegments))
+	for _, slot := range header.RollbackSegments {
+		fmt.Printf("    slot %d: space %d, page %d\n", slot.SlotNo, slot.SpaceId, slot.PageNo)
+	}
+	fmt.Println()
+
+	fmt.Println("Binlog:")
+	if header.Binlog.Valid {
+		fmt.Printf("    file: %s\n", header.Binlog.Name)
+		fmt.Printf("    offset: %d\n", header.Binlog.Offset)
+	} else {
+		fmt.Println("    not recorded")
+	}
+	fmt.Println()
+
+	doublewrite := header.Doublewrite
+	fmt.Println("Doublewrite Buffer:")
+	fmt.Printf("    magic: %d", doublewrite.Magic)
+	if !doublewrite.IsCreated() {
+		fmt.Println(" (not created)")
+		fmt.Println()
+		return
+	}
+	fmt.Println()
+	fmt.Printf("    fseg: space %d, page %d, offset %d\n", doublewrite.Fseg.SpaceId, doublewrite.Fseg.Inode.PageNo, doublewrite.Fseg.Inode.Offset)
+	fmt.Printf("    block1: pages %d-%d\n", doublewrite.Block1, doublewrite.Block1 + doublewrite.BlockPages - 1)
+	fmt.Printf("    block2: pages %d-%d\n", doublewrite.Block2, doublewrite.Block2 + doublewrite.BlockPages - 1)
+	fmt.Printf("    space_id_stored: %t\n", doublewrite.SpaceIdStored)
+	fmt.Println()
+}
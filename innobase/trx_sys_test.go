@@ -0,0 +1,178 @@
+package innobase
+
+import (
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// putTestFsegHeader 写入段头：inode 所在的表空间 ID、页号和页内偏移量
+func putTestFsegHeader(data []byte, offset int, fseg FsegHeader) {
+	binary.BigEndian.PutUint32(data[offset:], fseg.SpaceId)
+	putTestFileAddress(data, offset + int(spaceIdSize), fseg.Inode)
+}
+
+var (
+	testTrxSysFseg = FsegHeader{SpaceId: 0, Inode: testAddr(2, 242)}
+	testDoublewriteFseg = FsegHeader{SpaceId: 0, Inode: testAddr(2, 434)}
+)
+
+// newTestTrxSysFile 构造系统表空间，TRX_SYS 页（第 5 页）中使用第 0、5 两个回滚段槽，
+// 写入 binlog 位置和 doublewrite 头，modify 在写入之后修改 TRX_SYS 页中的数据
+func newTestTrxSysFile(modify func(page []byte)) *File {
+	return newTestFile(6, func(data []byte) {
+		putTestPageType(data, trxSysPageNo, pageTypeTrxSys)
+		page := data[int(trxSysPageNo) * testPageSize:int(trxSysPageNo + 1) * testPageSize]
+
+		binary.BigEndian.PutUint64(page[trxSysOffset + trxSysOffsetTrxIdStore:], 0x1234500)
+		putTestFsegHeader(page, int(trxSysOffset + trxSysOffsetFsegHeader), testTrxSysFseg)
+		for slotNo := uint32(0); slotNo < trxSysRsegSlots; slotNo++ {
+			offset := trxSysOffset + trxSysOffsetRsegs + slotNo * trxSysRsegSlotSize
+			binary.BigEndian.PutUint32(page[offset:], fileNull)
+			binary.BigEndian.PutUint32(page[offset + trxSysRsegOffsetPageNo:], fileNull)
+		}
+		for _, slot := range []RollbackSegmentSlot{{SlotNo: 0, SpaceId: 0, PageNo: 6}, {SlotNo: 5, SpaceId: 3, PageNo: 3}} {
+			offset := trxSysOffset + trxSysOffsetRsegs + slot.SlotNo * trxSysRsegSlotSize
+			binary.BigEndian.PutUint32(page[offset:], slot.SpaceId)
+			binary.BigEndian.PutUint32(page[offset + trxSysRsegOffsetPageNo:], slot.PageNo)
+		}
+
+		binlog := page[testPageSize - int(trxSysMysqlLogInfoEnd):]
+		binary.BigEndian.PutUint32(binlog[trxSysMysqlLogOffsetMagicN:], trxSysMysqlLogMagicN)
+		binary.BigEndian.PutUint32(binlog[trxSysMysqlLogOffsetHigh:], 1)
+		binary.BigEndian.PutUint32(binlog[trxSysMysqlLogOffsetLow:], 157)
+		copy(binlog[trxSysMysqlLogOffsetName:], "binlog.000003\x00garbage")
+
+		doublewrite := page[testPageSize - int(trxSysDoublewriteEnd):]
+		putTestFsegHeader(doublewrite, int(trxSysDoublewriteOffsetFseg), testDoublewriteFseg)
+		binary.BigEndian.PutUint32(doublewrite[trxSysDoublewriteOffsetMagic:], trxSysDoublewriteMagicN)
+		binary.BigEndian.PutUint32(doublewrite[trxSysDoublewriteOffsetBlock1:], 64)
+		binary.BigEndian.PutUint32(doublewrite[trxSysDoublewriteOffsetBlock2:], 128)
+		binary.BigEndian.PutUint32(doublewrite[trxSysDoublewriteOffsetSpaceIdStored:], trxSysDoublewriteSpaceIdStoredN)
+
+		if modify != nil {
+			modify(page)
+		}
+	})
+}
+
+func TestReadTrxSysHeader(t *testing.T) {
+	wantDoublewrite := DoublewriteHeader{
+		Fseg: testDoublewriteFseg,
+		Magic: trxSysDoublewriteMagicN,
+		Block1: 64,
+		Block2: 128,
+		BlockPages: 64,
+		SpaceIdStored: true,
+	}
+
+	tests := []struct {
+		name string
+		modify func(page []byte)
+		wantBinlog BinlogInfo
+		wantDoublewrite DoublewriteHeader
+		wantErrIs error
+	}{
+		{
+			name: "all fields",
+			wantBinlog: BinlogInfo{Valid: true, Name: "binlog.000003", Offset: 1 << 32 | 157},
+			wantDoublewrite: wantDoublewrite,
+		},
+		{
+			name: "binlog name without terminator",
+			modify: func(page []byte) {
+				copy(page[testPageSize - int(trxSysMysqlLogInfoEnd) + int(trxSysMysqlLogOffsetName):], strings.Repeat("b", int(trxSysMysqlLogNameLen)))
+			},
+			wantBinlog: BinlogInfo{Valid: true, Name: strings.Repeat("b", int(trxSysMysqlLogNameLen)), Offset: 1 << 32 | 157},
+			wantDoublewrite: wantDoublewrite,
+		},
+		{
+			name: "no binlog",
+			modify: func(page []byte) {
+				binary.BigEndian.PutUint32(page[testPageSize - int(trxSysMysqlLogInfoEnd):], 0)
+			},
+			wantDoublewrite: wantDoublewrite,
+		},
+		{
+			name: "no doublewrite and space id not stored",
+			modify: func(page []byte) {
+				doublewrite := page[testPageSize - int(trxSysDoublewriteEnd):]
+				binary.BigEndian.PutUint32(doublewrite[trxSysDoublewriteOffsetMagic:], 0)
+				binary.BigEndian.PutUint32(doublewrite[trxSysDoublewriteOffsetSpaceIdStored:], 0)
+			},
+			wantBinlog: BinlogInfo{Valid: true, Name: "binlog.000003", Offset: 1 << 32 | 157},
+			wantDoublewrite: DoublewriteHeader{Fseg: testDoublewriteFseg, Block1: 64, Block2: 128, BlockPages: 64},
+		},
+		{
+			name: "not a TRX_SYS page",
+			modify: func(page []byte) {
+				binary.BigEndian.PutUint16(page[fileOffsetPageType:], pageTypeSys)
+			},
+			wantErrIs: ErrNotTrxSysPage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := newTestTrxSysFile(tt.modify)
+			defer file.Close()
+
+			header, err := file.ReadTrxSysHeader()
+			if tt.wantErrIs != nil {
+				if !errors.Is(err, tt.wantErrIs) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErrIs)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if header.MaxTrxId != 0x1234500 {
+				t.Fatalf("max trx id = %#x, want %#x", header.MaxTrxId, 0x1234500)
+			}
+			if header.Fseg != testTrxSysFseg {
+				t.Fatalf("fseg = %+v, want %+v", header.Fseg, testTrxSysFseg)
+			}
+			wantSlots := []RollbackSegmentSlot{{SlotNo: 0, SpaceId: 0, PageNo: 6}, {SlotNo: 5, SpaceId: 3, PageNo: 3}}
+			if !reflect.DeepEqual(header.RollbackSegments, wantSlots) {
+				t.Fatalf("rollback segments = %+v, want %+v", header.RollbackSegments, wantSlots)
+			}
+			if header.Binlog != tt.wantBinlog {
+				t.Fatalf("binlog = %+v, want %+v", header.Binlog, tt.wantBinlog)
+			}
+			if header.Doublewrite != tt.wantDoublewrite {
+				t.Fatalf("doublewrite = %+v, want %+v", header.Doublewrite, tt.wantDoublewrite)
+			}
+		})
+	}
+}
+
+func TestDoublewriteHeaderContains(t *testing.T) {
+	header := DoublewriteHeader{Magic: trxSysDoublewriteMagicN, Block1: 64, Block2: 128, BlockPages: 64}
+	tests := []struct {
+		pageNo uint32
+		want bool
+	}{
+		{63, false},
+		{64, true},
+		{127, true},
+		{128, true},
+		{191, true},
+		{192, false},
+	}
+
+	for _, tt := range tests {
+		if got := header.Contains(tt.pageNo); got != tt.want {
+			t.Fatalf("Contains(%d) = %t, want %t", tt.pageNo, got, tt.want)
+		}
+	}
+
+	// 没有创建 doublewrite buffer 时不包含任何页
+	header.Magic = 0
+	if header.Contains(64) {
+		t.Fatalf("Contains(64) = true for a doublewrite buffer that was not created")
+	}
+}
@@ -0,0 +1,319 @@
+package innobase
+
+import (
+	"fmt"
+)
+
+const (
+	rsegArrayPageNo uint32 = 3 // 8.0 undo 表空间中 RSEG_ARRAY 页的页号（FSP_RSEG_ARRAY_PAGE_NO）
+	rsegArrayOffset uint32 = 38
+
+	rsegArrayOffsetSize uint32 = 4 // 数组中回滚段的数量，4 字节，之前为 4 字节的版本号
+	rsegArrayOffsetPages uint32 = 18 // 回滚段头页号数组，每个元素 4 字节，之前为 RSEG_ARRAY 段的段头
+	rsegArraySlotSize uint32 = 4
+
+	rsegOffset uint32 = 38 // 回滚段头在页中的起始位置
+
+	rsegOffsetMaxSize uint32 = 0 // 回滚段最多可以使用的页数量，4 字节
+	rsegOffsetHistorySize uint32 = 4 // history 链表中的 undo 页数量，4 字节
+	rsegOffsetHistory uint32 = 8 // history 链表的基节点，链表中是已提交、等待 purge 的 undo log，16 字节
+	rsegOffsetFsegHeader uint32 = 24 // 回滚段的段头，10 字节
+	rsegOffsetUndoSlots uint32 = 34 // undo 段槽数组，每个槽 4 字节，未使用的槽为 FIL_NULL
+
+	rsegUndoSlotSize uint32 = 4
+	rsegUndoSlotsDivisor uint32 = 16 // 槽的数量为页大小的 1/16（TRX_RSEG_N_SLOTS），16K 页为 1024 个
+
+	undoPageOffset uint32 = 38 // undo 页头在页中的起始位置
+	undoPageOffsetType uint32 = 0 // undo log 类型，2 字节
+
+	undoSegOffset uint32 = 56 // undo 段头在 undo 段第一个页中的起始位置，在 undo 页头之后
+	undoSegOffsetState uint32 = 0 // undo 段的状态，2 字节
+	undoSegOffsetLastLog uint32 = 2 // 段中最后一个 undo log 头在页中的位置，2 字节
+	undoSegOffsetFsegHeader uint32 = 4 // undo 段的段头，10 字节
+	undoSegOffsetPageList uint32 = 14 // undo 段中所有页组成的链表的基节点，16 字节
+
+	undoLogOffsetTrxId uint32 = 0 // undo log 头中的事务 ID，8 字节
+	undoLogOffsetTrxNo uint32 = 8 // 事务提交的序号，8 字节
+)
+
+// undo 段的状态（TRX_UNDO_STATE）
+const (
+	UndoStateActive uint16 = 1 // 事务正在使用
+	UndoStateCached uint16 = 2 // 缓存起来供新事务使用
+	UndoStateToFree uint16 = 3 // insert undo 段，事务提交后释放
+	UndoStateToPurge uint16 = 4 // update undo 段，等待 purge 之后释放
+	UndoStatePrepared80028 uint16 = 5 // 8.0.28 及之前版本中已经 PREPARE 的 XA 事务（TRX_UNDO_PREPARED_80028）
+	UndoStatePrepared uint16 = 6 // 8.0.29 开始，XA 事务已经 PREPARE
+	UndoStatePreparedInTc uint16 = 7 // 8.0.29 开始，事务在事务协调器中已经 PREPARE
+)
+
+var undoStateMap = map[uint16]string{
+	UndoStateActive: "active",
+	UndoStateCached: "cached",
+	UndoStateToFree: "to_free",
+	UndoStateToPurge: "to_purge",
+	UndoStatePrepared80028: "prepared_80028",
+	UndoStatePrepared: "prepared",
+	UndoStatePreparedInTc: "prepared_in_tc",
+}
+
+// undo log 类型（TRX_UNDO_PAGE_TYPE）
+var undoTypeMap = map[uint16]string{
+	1: "insert",
+	2: "update",
+}
+
+// UndoSegment 回滚段中一个已使用的 undo 段，从段的第一个页中读取
+type UndoSegment struct {
+	SlotNo uint32
+	PageNo uint32 // undo 段第一个页的页号
+	Type uint16 // undo log 类型：1 insert、2 update
+	State uint16
+	LastLog uint16 // 最后一个 undo log 头在页中的位置
+	TrxId uint64 // 最后一个 undo log 的事务 ID
+	TrxNo uint64 // 最后一个 undo log 的事务提交序号，事务没有提交时为 0
+	Fseg FsegHeader
+	PageList ListBaseNode // 段中的所有页，Length 为页数量
+}
+
+// RollbackSegmentHeader 回滚段头页
+type RollbackSegmentHeader struct {
+	Slot RollbackSegmentSlot
+	MaxSize uint32
+	HistorySize uint32 // history 链表中的 undo 页数量
+	History ListBaseNode // History.Length 为回滚段中等待 purge 的 undo log 数量，所有回滚段之和即 history list length
+	Fseg FsegHeader
+	UndoSlots uint32 // 槽的数量
+	UndoSegments []UndoSegment // 已使用的槽
+}
+
+func (segment *UndoSegment)GetStateName() string {
+	if name, ok := undoStateMap[segment.State]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("unknown (%d)", segment.State)
+}
+
+func (segment *UndoSegment)GetTypeName() string {
+	if name, ok := undoTypeMap[segment.Type]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("unknown (%d)", segment.Type)
+}
+
+// readUndoPage 读取回滚段头页或 undo 页，页类型不是 expectedPageType 时返回 notTypeErr
+func (file *File)readUndoPage(pageNo uint32, expectedPageType uint16, notTypeErr error) (*Page, error) {
+	page, err := file.ReadPage(pageNo)
+	if err != nil {
+		return nil, err
+	}
+
+	pageType, err := page.GetPageType()
+	if err != nil {
+		return nil, err
+	}
+	if pageType != expectedPageType {
+		err := fmt.Errorf("%w: page type %d (%s), expected %s", notTypeErr, pageType, pageTypeMap[pageType], pageTypeMap[expectedPageType])
+		return nil, newPageError(pageNo, uint32(fileOffsetPageType), "FIL_PAGE_TYPE", err)
+	}
+
+	return page, nil
+}
+
+// ReadRollbackSegmentSlots 读取表空间中的回滚段槽：系统表空间从 TRX_SYS 页中读取，回滚段可能在其他表空间中；
+// 8.0 undo 表空间从第 3 页 RSEG_ARRAY 中读取，回滚段都在当前表空间中
+func (file *File)ReadRollbackSegmentSlots() ([]RollbackSegmentSlot, error) {
+	errPrefix := "File::ReadRollbackSegmentSlots()"
+
+	spaceId, err := file.GetSpaceId()
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	if spaceId == 0 {
+		header, err := file.ReadTrxSysHeader()
+		if err != nil {
+			return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+		}
+
+		return header.RollbackSegments, nil
+	}
+
+	pageCount, err := file.getPageCount()
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	if pageCount <= rsegArrayPageNo {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, ErrNoRollbackSegments)
+	}
+
+	page, err := file.ReadPage(rsegArrayPageNo)
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	pageType, err := page.GetPageType()
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	if pageType != pageTypeRsegArray {
+		err := fmt.Errorf("%w: page type %d (%s)", ErrNoRollbackSegments, pageType, pageTypeMap[pageType])
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, newPageError(rsegArrayPageNo, uint32(fileOffsetPageType), "FIL_PAGE_TYPE", err))
+	}
+
+	size, err := page.getUint32(rsegArrayOffset + rsegArrayOffsetSize)
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "RSEG_ARRAY_SIZE"))
+	}
+
+	slots := []RollbackSegmentSlot{}
+	for slotNo := uint32(0); slotNo < size; slotNo++ {
+		pageNo, err := page.getUint32(rsegArrayOffset + rsegArrayOffsetPages + slotNo * rsegArraySlotSize)
+		if err != nil {
+			return nil, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "RSEG_ARRAY_PAGES"))
+		}
+		if pageNo == fileNull {
+			continue
+		}
+
+		slots = append(slots, RollbackSegmentSlot{SlotNo: slotNo, SpaceId: spaceId, PageNo: pageNo})
+	}
+
+	return slots, nil
+}
+
+// ReadRollbackSegment 读取 slot 指向的回滚段头页，并读取每个已使用的 undo 段的段头
+func (file *File)ReadRollbackSegment(slot RollbackSegmentSlot) (RollbackSegmentHeader, error) {
+	errPrefix := "File::ReadRollbackSegment()"
+
+	header := RollbackSegmentHeader{Slot: slot}
+
+	page, err := file.readUndoPage(slot.PageNo, pageTypeSys, ErrNotRsegPage)
+	if err != nil {
+		return header, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	fields := []struct {
+		offset uint32
+		field string
+		value *uint32
+	}{
+		{rsegOffsetMaxSize, "TRX_RSEG_MAX_SIZE", &header.MaxSize},
+		{rsegOffsetHistorySize, "TRX_RSEG_HISTORY_SIZE", &header.HistorySize},
+	}
+	for _, f := range fields {
+		value, err := page.getUint32(rsegOffset + f.offset)
+		if err != nil {
+			return header, fmt.Errorf("%s: [%w]", errPrefix, withField(err, f.field))
+		}
+		*f.value = value
+	}
+
+	history, err := page.getListBaseNode(rsegOffset + rsegOffsetHistory)
+	if err != nil {
+		return header, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "TRX_RSEG_HISTORY"))
+	}
+	header.History = history
+
+	fseg, err := page.getFsegHeader(rsegOffset + rsegOffsetFsegHeader)
+	if err != nil {
+		return header, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "TRX_RSEG_FSEG_HEADER"))
+	}
+	header.Fseg = fseg
+
+	header.UndoSlots = page.GetSize() / rsegUndoSlotsDivisor
+	header.UndoSegments = []UndoSegment{}
+	for slotNo := uint32(0); slotNo < header.UndoSlots; slotNo++ {
+		pageNo, err := page.getUint32(rsegOffset + rsegOffsetUndoSlots + slotNo * rsegUndoSlotSize)
+		if err != nil {
+			return header, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "TRX_RSEG_UNDO_SLOTS"))
+		}
+		if pageNo == fileNull {
+			continue
+		}
+
+		segment, err := file.ReadUndoSegment(pageNo)
+		if err != nil {
+			return header, fmt.Errorf("%s: [%w]", errPrefix, err)
+		}
+		segment.SlotNo = slotNo
+		header.UndoSegments = append(header.UndoSegments, segment)
+	}
+
+	return header, nil
+}
+
+// ReadUndoSegment 读取 undo 段第一个页中的 undo 页头、undo 段头和最后一个 undo log 头
+func (file *File)ReadUndoSegment(pageNo uint32) (UndoSegment, error) {
+	errPrefix := "File::ReadUndoSegment()"
+
+	segment := UndoSegment{PageNo: pageNo}
+
+	page, err := file.readUndoPage(pageNo, pageTypeUndoLog, ErrNotUndoPage)
+	if err != nil {
+		return segment, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	fields := []struct {
+		offset uint32
+		field string
+		value *uint16
+	}{
+		{undoPageOffset + undoPageOffsetType, "TRX_UNDO_PAGE_TYPE", &segment.Type},
+		{undoSegOffset + undoSegOffsetState, "TRX_UNDO_STATE", &segment.State},
+		{undoSegOffset + undoSegOffsetLastLog, "TRX_UNDO_LAST_LOG", &segment.LastLog},
+	}
+	for _, f := range fields {
+		value, err := page.getUint16(f.offset)
+		if err != nil {
+			return segment, fmt.Errorf("%s: [%w]", errPrefix, withField(err, f.field))
+		}
+		*f.value = value
+	}
+
+	fseg, err := page.getFsegHeader(undoSegOffset + undoSegOffsetFsegHeader)
+	if err != nil {
+		return segment, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "TRX_UNDO_FSEG_HEADER"))
+	}
+	segment.Fseg = fseg
+
+	pageList, err := page.getListBaseNode(undoSegOffset + undoSegOffsetPageList)
+	if err != nil {
+		return segment, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "TRX_UNDO_PAGE_LIST"))
+	}
+	segment.PageList = pageList
+
+	if segment.LastLog == 0 {
+		return segment, nil
+	}
+
+	trxId, err := page.getUint64(uint32(segment.LastLog) + undoLogOffsetTrxId)
+	if err != nil {
+		return segment, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "TRX_UNDO_TRX_ID"))
+	}
+	segment.TrxId = trxId
+
+	trxNo, err := page.getUint64(uint32(segment.LastLog) + undoLogOffsetTrxNo)
+	if err != nil {
+		return segment, fmt.Errorf("%s: [%w]", errPrefix, withField(err, "TRX_UNDO_TRX_NO"))
+	}
+	segment.TrxNo = trxNo
+
+	return segment, nil
+}
+
+func printRollbackSegment(header RollbackSegmentHeader) {
+	slot := header.Slot
+	fmt.Printf("Rollback Segment %d (space %d, page %d):\n", slot.SlotNo, slot.SpaceId, slot.PageNo)
+	fmt.Printf("    max_size: %d\n", header.MaxSize)
+	fmt.Printf("    history_size: %d pages\n", header.HistorySize)
+	fmt.Printf("    history_length: %d (first %s, last %s)\n", header.History.Length, header.History.First, header.History.Last)
+	fmt.Printf("    fseg: space %d, page %d, offset %d\n", header.Fseg.SpaceId, header.Fseg.Inode.PageNo, header.Fseg.Inode.Offset)
+	fmt.Printf("    undo_segments: %d / %d\n", len(header.UndoSegments), header.UndoSlots)
+	for _, segment := range header.UndoSegments {
+		fmt.Printf("        slot %d: page %d, %s, %s, pages %d, trx_id %d, trx_no %d\n", segment.SlotNo, segment.PageNo,
+			segment.GetTypeName(), segment.GetStateName(), segment.PageList.Length, segment.TrxId, segment.TrxNo)
+	}
+	fmt.Println()
+}
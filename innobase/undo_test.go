@@ -0,0 +1,259 @@
+package innobase
+
+import (
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+const testUndoSpaceId = 3
+
+// testUndoSegment undo 段第一个页中的字段，页号为 pageNo
+type testUndoSegment struct {
+	pageNo uint32
+	undoType uint16
+	state uint16
+	lastLog uint16
+	trxId uint64
+	trxNo uint64
+}
+
+var testUndoSegments = []testUndoSegment{
+	{pageNo: 8, undoType: 1, state: UndoStateActive, lastLog: 200, trxId: 1001},
+	{pageNo: 9, undoType: 2, state: UndoStateToPurge, lastLog: 300, trxId: 1002, trxNo: 900},
+}
+
+var (
+	testRsegHistory = ListBaseNode{Length: 2, First: testAddr(9, 300), Last: testAddr(10, 150)}
+	testRsegFseg = FsegHeader{SpaceId: testUndoSpaceId, Inode: testAddr(2, 50)}
+	testUndoFseg = FsegHeader{SpaceId: testUndoSpaceId, Inode: testAddr(2, 242)}
+	testUndoPageList = ListBaseNode{Length: 1, First: testAddr(8, 38 + 4), Last: testAddr(8, 38 + 4)}
+)
+
+func putTestUndoSegment(data []byte, segment testUndoSegment) {
+	putTestPageType(data, segment.pageNo, pageTypeUndoLog)
+	page := data[int(segment.pageNo) * testPageSize:]
+	binary.BigEndian.PutUint16(page[undoPageOffset + undoPageOffsetType:], segment.undoType)
+	binary.BigEndian.PutUint16(page[undoSegOffset + undoSegOffsetState:], segment.state)
+	binary.BigEndian.PutUint16(page[undoSegOffset + undoSegOffsetLastLog:], segment.lastLog)
+	putTestFsegHeader(page, int(undoSegOffset + undoSegOffsetFsegHeader), testUndoFseg)
+	putTestListBase(page, int(undoSegOffset + undoSegOffsetPageList), testUndoPageList)
+	if segment.lastLog != 0 {
+		binary.BigEndian.PutUint64(page[uint32(segment.lastLog) + undoLogOffsetTrxId:], segment.trxId)
+		binary.BigEndian.PutUint64(page[uint32(segment.lastLog) + undoLogOffsetTrxNo:], segment.trxNo)
+	}
+}
+
+// newTestUndoFile 构造 8.0 undo 表空间：第 3 页为 RSEG_ARRAY，3 个槽中第 0、2 个指向回滚段头页 6、7；
+// 回滚段头页 6 的第 0 个和最后一个 undo 段槽分别指向 undo 页 8、9。modify 在写入之后修改数据
+func newTestUndoFile(modify func(data []byte)) *File {
+	return newTestFile(11, func(data []byte) {
+		binary.BigEndian.PutUint32(data[fileOffsetSpaceId:], testUndoSpaceId)
+
+		putTestPageType(data, rsegArrayPageNo, pageTypeRsegArray)
+		rsegArray := data[int(rsegArrayPageNo) * testPageSize + int(rsegArrayOffset):]
+		binary.BigEndian.PutUint32(rsegArray[rsegArrayOffsetSize:], 3)
+		for i, pageNo := range []uint32{6, fileNull, 7} {
+			binary.BigEndian.PutUint32(rsegArray[rsegArrayOffsetPages + uint32(i) * rsegArraySlotSize:], pageNo)
+		}
+
+		for _, pageNo := range []uint32{6, 7} {
+			putTestPageType(data, pageNo, pageTypeSys)
+			rseg := data[int(pageNo) * testPageSize + int(rsegOffset):]
+			for slotNo := uint32(0); slotNo < testPageSize / rsegUndoSlotsDivisor; slotNo++ {
+				binary.BigEndian.PutUint32(rseg[rsegOffsetUndoSlots + slotNo * rsegUndoSlotSize:], fileNull)
+			}
+		}
+		rseg := data[6 * testPageSize + int(rsegOffset):]
+		binary.BigEndian.PutUint32(rseg[rsegOffsetMaxSize:], 0xFFFFFFFE)
+		binary.BigEndian.PutUint32(rseg[rsegOffsetHistorySize:], 3)
+		putTestListBase(rseg, int(rsegOffsetHistory), testRsegHistory)
+		putTestFsegHeader(rseg, int(rsegOffsetFsegHeader), testRsegFseg)
+		binary.BigEndian.PutUint32(rseg[rsegOffsetUndoSlots:], 8)
+		binary.BigEndian.PutUint32(rseg[rsegOffsetUndoSlots + (testPageSize / rsegUndoSlotsDivisor - 1) * rsegUndoSlotSize:], 9)
+
+		for _, segment := range testUndoSegments {
+			putTestUndoSegment(data, segment)
+		}
+
+		if modify != nil {
+			modify(data)
+		}
+	})
+}
+
+func TestReadRollbackSegmentSlots(t *testing.T) {
+	tests := []struct {
+		name string
+		newFile func() *File
+		want []RollbackSegmentSlot
+		wantErrIs error
+	}{
+		{
+			name: "system tablespace",
+			newFile: func() *File { return newTestTrxSysFile(nil) },
+			want: []RollbackSegmentSlot{{SlotNo: 0, SpaceId: 0, PageNo: 6}, {SlotNo: 5, SpaceId: 3, PageNo: 3}},
+		},
+		{
+			name: "undo tablespace",
+			newFile: func() *File { return newTestUndoFile(nil) },
+			want: []RollbackSegmentSlot{{SlotNo: 0, SpaceId: testUndoSpaceId, PageNo: 6}, {SlotNo: 2, SpaceId: testUndoSpaceId, PageNo: 7}},
+		},
+		{
+			name: "no RSEG_ARRAY page",
+			newFile: func() *File {
+				return newTestUndoFile(func(data []byte) {
+					putTestPageType(data, rsegArrayPageNo, pageTypeInode)
+				})
+			},
+			wantErrIs: ErrNoRollbackSegments,
+		},
+		{
+			name: "too few pages",
+			newFile: func() *File {
+				return newTestFile(3, func(data []byte) {
+					binary.BigEndian.PutUint32(data[fileOffsetSpaceId:], testUndoSpaceId)
+				})
+			},
+			wantErrIs: ErrNoRollbackSegments,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := tt.newFile()
+			defer file.Close()
+
+			slots, err := file.ReadRollbackSegmentSlots()
+			if tt.wantErrIs != nil {
+				if !errors.Is(err, tt.wantErrIs) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErrIs)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(slots, tt.want) {
+				t.Fatalf("slots = %+v, want %+v", slots, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadRollbackSegment(t *testing.T) {
+	slot := RollbackSegmentSlot{SlotNo: 0, SpaceId: testUndoSpaceId, PageNo: 6}
+
+	tests := []struct {
+		name string
+		modify func(data []byte)
+		slot RollbackSegmentSlot
+		wantErrIs error
+	}{
+		{name: "all fields", slot: slot},
+		{
+			name: "not a rollback segment page",
+			slot: RollbackSegmentSlot{SlotNo: 0, SpaceId: testUndoSpaceId, PageNo: 8},
+			wantErrIs: ErrNotRsegPage,
+		},
+		{
+			name: "undo slot points to a non-undo page",
+			modify: func(data []byte) {
+				putTestPageType(data, 9, pageTypeIndex)
+			},
+			slot: slot,
+			wantErrIs: ErrNotUndoPage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := newTestUndoFile(tt.modify)
+			defer file.Close()
+
+			header, err := file.ReadRollbackSegment(tt.slot)
+			if tt.wantErrIs != nil {
+				if !errors.Is(err, tt.wantErrIs) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErrIs)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if header.Slot != tt.slot || header.MaxSize != 0xFFFFFFFE || header.HistorySize != 3 {
+				t.Fatalf("header = %+v", header)
+			}
+			if header.History != testRsegHistory {
+				t.Fatalf("history = %+v, want %+v", header.History, testRsegHistory)
+			}
+			if header.Fseg != testRsegFseg {
+				t.Fatalf("fseg = %+v, want %+v", header.Fseg, testRsegFseg)
+			}
+			if header.UndoSlots != 1024 {
+				t.Fatalf("undo slots = %d, want 1024", header.UndoSlots)
+			}
+
+			want := []UndoSegment{
+				{SlotNo: 0, PageNo: 8, Type: 1, State: UndoStateActive, LastLog: 200, TrxId: 1001, Fseg: testUndoFseg, PageList: testUndoPageList},
+				{SlotNo: 1023, PageNo: 9, Type: 2, State: UndoStateToPurge, LastLog: 300, TrxId: 1002, TrxNo: 900, Fseg: testUndoFseg, PageList: testUndoPageList},
+			}
+			if !reflect.DeepEqual(header.UndoSegments, want) {
+				t.Fatalf("undo segments = %+v, want %+v", header.UndoSegments, want)
+			}
+		})
+	}
+}
+
+func TestReadUndoSegment(t *testing.T) {
+	tests := []struct {
+		name string
+		segment testUndoSegment
+		wantState string
+		wantType string
+	}{
+		{"active insert", testUndoSegment{pageNo: 8, undoType: 1, state: UndoStateActive, lastLog: 200, trxId: 1}, "active", "insert"},
+		{"cached", testUndoSegment{pageNo: 8, undoType: 2, state: UndoStateCached, lastLog: 200, trxId: 2, trxNo: 3}, "cached", "update"},
+		{"to free", testUndoSegment{pageNo: 8, undoType: 1, state: UndoStateToFree, lastLog: 200, trxId: 4}, "to_free", "insert"},
+		{"to purge", testUndoSegment{pageNo: 8, undoType: 2, state: UndoStateToPurge, lastLog: 200, trxId: 5, trxNo: 6}, "to_purge", "update"},
+		{"prepared before 8.0.29", testUndoSegment{pageNo: 8, undoType: 2, state: UndoStatePrepared80028, lastLog: 200, trxId: 7}, "prepared_80028", "update"},
+		{"prepared", testUndoSegment{pageNo: 8, undoType: 2, state: UndoStatePrepared, lastLog: 200, trxId: 8}, "prepared", "update"},
+		{"prepared in tc", testUndoSegment{pageNo: 8, undoType: 2, state: UndoStatePreparedInTc, lastLog: 200, trxId: 9}, "prepared_in_tc", "update"},
+		{"unknown state and type", testUndoSegment{pageNo: 8, undoType: 3, state: 8, lastLog: 200, trxId: 10}, "unknown (8)", "unknown (3)"},
+		// 没有 undo log 头时不读取事务 ID
+		{"no undo log", testUndoSegment{pageNo: 8, undoType: 1, state: UndoStateCached}, "cached", "insert"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := newTestUndoFile(func(data []byte) {
+				putTestUndoSegment(data, tt.segment)
+			})
+			defer file.Close()
+
+			segment, err := file.ReadUndoSegment(tt.segment.pageNo)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			want := UndoSegment{
+				PageNo: tt.segment.pageNo,
+				Type: tt.segment.undoType,
+				State: tt.segment.state,
+				LastLog: tt.segment.lastLog,
+				TrxId: tt.segment.trxId,
+				TrxNo: tt.segment.trxNo,
+				Fseg: testUndoFseg,
+				PageList: testUndoPageList,
+			}
+			if segment != want {
+				t.Fatalf("segment = %+v, want %+v", segment, want)
+			}
+			if segment.GetStateName() != tt.wantState || segment.GetTypeName() != tt.wantType {
+				t.Fatalf("state %q, type %q, want %q, %q", segment.GetStateName(), segment.GetTypeName(), tt.wantState, tt.wantType)
+			}
+		})
+	}
+}
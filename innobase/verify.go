@@ -0,0 +1,429 @@
+package innobase
+
+import (
+	"errors"
+	"fmt"
+)
+
+// 一致性检查发现的问题类型
+const (
+	VerifyOrphanedPage = "orphaned_page" // 页正在使用（碎片页、FULL 区中的页、空间管理页），区描述符中却是空闲的
+	VerifyLeakedPage = "leaked_page" // 区描述符中已分配，但不属于任何段，也不是空间管理页
+	VerifyDoubleOwnedPage = "double_owned_page" // 同一个页属于两个段
+	VerifyWrongSpaceId = "wrong_space_id" // 页中存储的表空间 ID 与 FSP 头中的不一致
+	VerifyMisplacedPage = "misplaced_page" // 页中存储的页号与页的位置不一致
+	VerifyPageType = "page_type" // 页类型与页的用途不一致
+	VerifyExtentList = "extent_list" // 链表损坏，或区的状态、所属段与所在链表不一致
+	VerifySpaceSize = "space_size" // 文件比 FSP_SIZE 小，或 FREE_LIMIT 与 FSP_SIZE 不一致
+)
+
+var verifyKinds = []string{
+	VerifyOrphanedPage,
+	VerifyLeakedPage,
+	VerifyDoubleOwnedPage,
+	VerifyWrongSpaceId,
+	VerifyMisplacedPage,
+	VerifyPageType,
+	VerifyExtentList,
+	VerifySpaceSize,
+}
+
+// verifyOwnerSpace 空间管理页（FSP_HDR、XDES、Change Buffer 位图、INODE 页）的所有者，
+// 段 ID 从 1 开始，不会与它冲突
+const verifyOwnerSpace uint64 = 0xFFFFFFFFFFFFFFFF
+
+// VerifyProblem 一致性检查发现的一个问题
+type VerifyProblem struct {
+	Kind string
+	PageNo uint32 // 问题所在的页，与具体页无关时为 FIL_NULL
+	Detail string
+}
+
+// VerifyResult 一致性检查的结果
+type VerifyResult struct {
+	PageCount uint32 // 文件中的页数量
+	FSPHeader FSPHeader
+	SegmentCount int
+	Problems []VerifyProblem
+}
+
+func (result *VerifyResult)add(kind string, pageNo uint32, format string, args ...interface{}) {
+	result.Problems = append(result.Problems, VerifyProblem{
+		Kind: kind,
+		PageNo: pageNo,
+		Detail: fmt.Sprintf(format, args...),
+	})
+}
+
+func (result *VerifyResult)IsConsistent() bool {
+	return len(result.Problems) == 0
+}
+
+// GetProblemCounts 每类问题的数量
+func (result *VerifyResult)GetProblemCounts() map[string]int {
+	counts := map[string]int{}
+	for _, kind := range verifyKinds {
+		counts[kind] = 0
+	}
+	for _, problem := range result.Problems {
+		counts[problem.Kind]++
+	}
+
+	return counts
+}
+
+// isListCorruption 链表本身损坏，记录为问题后继续检查
+func isListCorruption(err error) bool {
+	return errors.Is(err, ErrListCycle) || errors.Is(err, ErrListBrokenLink) || errors.Is(err, ErrListLengthMismatch) ||
+		errors.Is(err, ErrInodeMagicMismatch)
+}
+
+// verifier 一致性检查过程中的状态
+type verifier struct {
+	file *File
+	result *VerifyResult
+	allocation *AllocationMap
+	spaceMap *SpaceMap
+	limit uint32 // 需要检查的页号上限：FSP_SIZE 与文件页数量中较小的一个
+	owners []uint64 // 每个页的所有者：段 ID 或 verifyOwnerSpace，0 表示没有
+	inUse []bool // 有证据表明页正在使用
+}
+
+func (v *verifier)formatOwner(owner uint64) string {
+	if owner == verifyOwnerSpace {
+		return "space management"
+	}
+
+	return fmt.Sprintf("segment %d", owner)
+}
+
+// own 记录页的所有者，已经属于其他所有者时记录问题
+func (v *verifier)own(pageNo uint32, owner uint64, inUse bool) {
+	if pageNo >= v.limit {
+		v.result.add(VerifySpaceSize, pageNo, "page is referenced by %s but is beyond the end of the space", v.formatOwner(owner))
+		return
+	}
+
+	if current := v.owners[pageNo]; current != 0 && current != owner {
+		v.result.add(VerifyDoubleOwnedPage, pageNo, "page is owned by %s and %s", v.formatOwner(current), v.formatOwner(owner))
+	} else {
+		v.owners[pageNo] = owner
+	}
+	if inUse {
+		v.inUse[pageNo] = true
+	}
+}
+
+// checkPageType 空间管理页的页类型必须是预期的类型，FREE_LIMIT 之后的页还没有初始化，不检查
+func (v *verifier)checkPageType(pageNo uint32, expected ...uint16) {
+	if pageNo >= v.limit || pageNo >= v.result.FSPHeader.FreeLimit {
+		return
+	}
+
+	pageType := v.spaceMap.Pages[pageNo].PageType
+	for _, t := range expected {
+		if pageType == t {
+			return
+		}
+	}
+	v.result.add(VerifyPageType, pageNo, "page type %d (%s), expected %s", pageType, pageTypeMap[pageType], pageTypeMap[expected[0]])
+}
+
+// readExtentList 遍历区链表，链表损坏时记录问题，并当作空链表继续检查
+func (v *verifier)readExtentList(name string, base ListBaseNode) ([]Extent, error) {
+	extents, err := v.file.ReadExtentList(base)
+	if err != nil {
+		if !isListCorruption(err) {
+			return nil, err
+		}
+		v.result.add(VerifyExtentList, fileNull, "%s: %v", name, err)
+		return nil, nil
+	}
+
+	return extents, nil
+}
+
+// checkSpaceSize 检查 FSP_SIZE、FREE_LIMIT 与文件大小。InnoDB 先扩展文件再更新 FSP_SIZE，
+// 崩溃后或自动扩展预分配时文件比 FSP_SIZE 大是正常的，只有文件比 FSP_SIZE 小（被截断）才是问题
+func (v *verifier)checkSpaceSize() {
+	header := v.result.FSPHeader
+	if header.Size > v.result.PageCount {
+		v.result.add(VerifySpaceSize, fileNull, "FSP_SIZE = %d pages, but the file has only %d pages", header.Size, v.result.PageCount)
+	}
+	// 小表空间初始化第一个区时，FREE_LIMIT 会超过 FSP_SIZE，但不会超过 FSP_SIZE 所在区的末尾
+	extentPages := v.allocation.ExtentPages
+	if header.FreeLimit > (header.Size + extentPages - 1) / extentPages * extentPages {
+		v.result.add(VerifySpaceSize, fileNull, "FSP_FREE_LIMIT = %d is beyond the extent containing FSP_SIZE = %d", header.FreeLimit, header.Size)
+	}
+
+	// FREE_LIMIT 之后的页还没有初始化
+	for pageNo := header.FreeLimit; pageNo < v.limit; pageNo++ {
+		entry := v.spaceMap.Pages[pageNo]
+		if entry.PageType != pageTypeAllocated && !entry.Misplaced {
+			v.result.add(VerifySpaceSize, pageNo, "page type %d (%s) beyond FSP_FREE_LIMIT = %d",
+				entry.PageType, pageTypeMap[entry.PageType], header.FreeLimit)
+		}
+	}
+}
+
+// checkSpaceLists 检查 FSP_FREE、FSP_FREE_FRAG、FSP_FULL_FRAG 中区的状态
+func (v *verifier)checkSpaceLists() error {
+	header := v.result.FSPHeader
+	lists := []struct {
+		name string
+		base ListBaseNode
+		state uint32
+	}{
+		{"FSP_FREE", header.Free, xdesStateFree},
+		{"FSP_FREE_FRAG", header.FreeFrag, xdesStateFreeFrag},
+		{"FSP_FULL_FRAG", header.FullFrag, xdesStateFullFrag},
+	}
+
+	fragUsed := uint32(0)
+	for _, list := range lists {
+		extents, err := v.readExtentList(list.name, list.base)
+		if err != nil {
+			return err
+		}
+
+		for i := range extents {
+			extent := &extents[i]
+			if extent.State != list.state {
+				v.result.add(VerifyExtentList, extent.FirstPageNo, "extent in %s has state %s", list.name, extent.GetStateName())
+				continue
+			}
+
+			used := uint32(0)
+			for pageIndex := uint32(0); pageIndex < extent.PageCount; pageIndex++ {
+				if !extent.getBit(pageIndex, xdesFreeBit) {
+					used++
+				}
+			}
+			switch {
+			case list.state == xdesStateFree && used > 0:
+				v.result.add(VerifyExtentList, extent.FirstPageNo, "extent in %s has %d used pages", list.name, used)
+			case list.state == xdesStateFullFrag && used != extent.PageCount:
+				v.result.add(VerifyExtentList, extent.FirstPageNo, "extent in %s has %d free pages", list.name, extent.PageCount - used)
+			case list.state == xdesStateFreeFrag:
+				fragUsed += used
+			}
+		}
+	}
+
+	if fragUsed != header.FragNUsed {
+		v.result.add(VerifyExtentList, fileNull, "FSP_FRAG_N_USED = %d, but extents in FSP_FREE_FRAG have %d used pages", header.FragNUsed, fragUsed)
+	}
+
+	return nil
+}
+
+// checkSpacePages 描述符页、Change Buffer 位图页和 INODE 页属于空间管理，返回所有已使用的 inode
+func (v *verifier)checkSpacePages() ([]Inode, error) {
+	physicalPageSize, err := v.file.GetPhysicalPageSize()
+	if err != nil {
+		return nil, err
+	}
+
+	for pageNo := uint32(0); pageNo < v.limit; pageNo += physicalPageSize {
+		if pageNo == 0 {
+			v.checkPageType(pageNo, pageTypeFSP)
+		} else {
+			v.checkPageType(pageNo, pageTypeXDES)
+		}
+		v.own(pageNo, verifyOwnerSpace, pageNo < v.result.FSPHeader.FreeLimit)
+
+		bitmapPageNo := pageNo + ibufBitmapPageOffset
+		if bitmapPageNo < v.limit {
+			v.checkPageType(bitmapPageNo, pageTypeIBufBitmap)
+			v.own(bitmapPageNo, verifyOwnerSpace, bitmapPageNo < v.result.FSPHeader.FreeLimit)
+		}
+
+		if pageNo + physicalPageSize < pageNo {
+			break
+		}
+	}
+
+	var inodes []Inode
+	header := v.result.FSPHeader
+	visit := func(page *Page, addr FileAddress, node ListNode) error {
+		v.checkPageType(addr.PageNo, pageTypeInode)
+		v.own(addr.PageNo, verifyOwnerSpace, true)
+
+		pageInodes, err := page.GetInodes(v.allocation.ExtentPages)
+		if err != nil {
+			return err
+		}
+		for _, inode := range pageInodes {
+			if inode.IsUsed() {
+				inodes = append(inodes, inode)
+			}
+		}
+
+		return nil
+	}
+	for _, list := range []struct {
+		name string
+		base ListBaseNode
+	}{
+		{"FSP_SEG_INODES_FULL", header.SegInodesFull},
+		{"FSP_SEG_INODES_FREE", header.SegInodesFree},
+	} {
+		if err := v.file.WalkListBase(list.base, ListDirectionForward, visit); err != nil {
+			if !isListCorruption(err) {
+				return nil, err
+			}
+			v.result.add(VerifyExtentList, fileNull, "%s: %v", list.name, err)
+		}
+	}
+
+	return inodes, nil
+}
+
+// checkSegment 段的区链表和碎片页
+func (v *verifier)checkSegment(inode Inode) error {
+	lists := []struct {
+		name string
+		base ListBaseNode
+	}{
+		{"FSEG_FULL", inode.Full},
+		{"FSEG_NOT_FULL", inode.NotFull},
+		{"FSEG_FREE", inode.Free},
+	}
+
+	for _, list := range lists {
+		name := fmt.Sprintf("segment %d %s", inode.SegmentId, list.name)
+		extents, err := v.readExtentList(name, list.base)
+		if err != nil {
+			return err
+		}
+
+		notFullUsed := uint32(0)
+		for i := range extents {
+			extent := &extents[i]
+			if extent.State != xdesStateFseg || extent.SegmentId != inode.SegmentId {
+				v.result.add(VerifyExtentList, extent.FirstPageNo, "extent in %s has state %s and XDES_ID %d",
+					name, extent.GetStateName(), extent.SegmentId)
+			}
+
+			used := extent.GetUsedPages()
+			switch list.name {
+			case "FSEG_FREE":
+				if used > 0 {
+					v.result.add(VerifyExtentList, extent.FirstPageNo, "extent in %s has %d used pages", name, used)
+				}
+			case "FSEG_NOT_FULL":
+				notFullUsed += used
+			}
+
+			for pageIndex := uint32(0); pageIndex < extent.PageCount; pageIndex++ {
+				v.own(extent.FirstPageNo + pageIndex, inode.SegmentId, list.name == "FSEG_FULL")
+			}
+		}
+
+		if list.name == "FSEG_NOT_FULL" && notFullUsed != inode.NotFullNUsed {
+			v.result.add(VerifyExtentList, inode.Address.PageNo, "segment %d FSEG_NOT_FULL_N_USED = %d, but its extents have %d used pages",
+				inode.SegmentId, inode.NotFullNUsed, notFullUsed)
+		}
+	}
+
+	for _, pageNo := range inode.GetFragPages() {
+		v.own(pageNo, inode.SegmentId, true)
+	}
+
+	return nil
+}
+
+// checkPages 逐页比较区描述符、所有者和页中存储的信息
+func (v *verifier)checkPages() {
+	spaceId := v.result.FSPHeader.SpaceId
+	for pageNo := uint32(0); pageNo < v.limit; pageNo++ {
+		entry := v.spaceMap.Pages[pageNo]
+
+		if entry.Misplaced {
+			v.result.add(VerifyMisplacedPage, pageNo, "page stores a different page number")
+		} else if entry.PageType != pageTypeAllocated && entry.SpaceId != spaceId {
+			v.result.add(VerifyWrongSpaceId, pageNo, "page stores space id %d, expected %d", entry.SpaceId, spaceId)
+		}
+
+		owner := v.owners[pageNo]
+		switch {
+		case v.inUse[pageNo] && !entry.Allocated:
+			v.result.add(VerifyOrphanedPage, pageNo, "page is used by %s but marked free", v.formatOwner(owner))
+		case entry.Allocated && owner == 0:
+			v.result.add(VerifyLeakedPage, pageNo, "page is allocated (type %s) but not owned by any segment", pageTypeMap[entry.PageType])
+		case v.inUse[pageNo] && entry.PageType == pageTypeAllocated && !entry.Misplaced:
+			v.result.add(VerifyPageType, pageNo, "page is used by %s but was never initialized", v.formatOwner(owner))
+		}
+	}
+
+	// 属于段的区，其所有页都应该属于该段
+	for i := range v.allocation.Extents {
+		extent := &v.allocation.Extents[i]
+		if extent.State != xdesStateFseg || extent.FirstPageNo >= v.limit {
+			continue
+		}
+		if owner := v.owners[extent.FirstPageNo]; owner != extent.SegmentId {
+			v.result.add(VerifyExtentList, extent.FirstPageNo, "extent belongs to segment %d (XDES_ID) but is not in any of its lists", extent.SegmentId)
+		}
+	}
+}
+
+// Verify 离线检查表空间的空间管理信息：区描述符中的位图、扫描得到的页类型，
+// 以及 inode 中记录的段的区链表和碎片页，三者必须一致。
+// 页被释放后页类型不会被清除，所以只根据所有者判断页是否正在使用
+func (file *File)Verify(workers int) (*VerifyResult, error) {
+	errPrefix := "File::Verify()"
+
+	pageCount, err := file.getPageCount()
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	allocation, err := file.ReadAllocationMap()
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	spaceMap, err := file.ReadSpaceMap(workers)
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	v := &verifier{
+		file: file,
+		result: &VerifyResult{
+			PageCount: pageCount,
+			FSPHeader: allocation.FSPHeader,
+		},
+		allocation: allocation,
+		spaceMap: spaceMap,
+		limit: allocation.FSPHeader.Size,
+	}
+	if v.limit > pageCount {
+		v.limit = pageCount
+	}
+	v.owners = make([]uint64, v.limit)
+	v.inUse = make([]bool, v.limit)
+
+	v.checkSpaceSize()
+
+	if err := v.checkSpaceLists(); err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	inodes, err := v.checkSpacePages()
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	v.result.SegmentCount = len(inodes)
+
+	for _, inode := range inodes {
+		if err := v.checkSegment(inode); err != nil {
+			return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+		}
+	}
+
+	v.checkPages()
+
+	return v.result, nil
+}
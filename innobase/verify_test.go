@@ -0,0 +1,245 @@
+package innobase
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+const (
+	testVerifySpaceId = 5
+	testVerifyPageCount = 128 // 两个区，FSP_SIZE 和 FREE_LIMIT 都是 128
+	testVerifyExtentPages = 64
+)
+
+var (
+	testVerifyEntrySize = int(getXdesEntrySize(testVerifyExtentPages))
+	testVerifyInodeSize = int(getInodeSize(testVerifyExtentPages))
+
+	// 第 0 个区在 FSP_FREE_FRAG 中，第 1 个区在段 1 的 FSEG_NOT_FULL 中
+	testVerifyExtent0 = testAddr(0, xdesArrOffset + xdesOffsetFlstNode)
+	testVerifyExtent1 = testAddr(0, xdesArrOffset + uint16(testVerifyEntrySize) + xdesOffsetFlstNode)
+)
+
+func putTestListBase(data []byte, offset int, base ListBaseNode) {
+	binary.BigEndian.PutUint32(data[offset + int(flstOffsetLen):], base.Length)
+	putTestFileAddress(data, offset + int(flstOffsetFirst), base.First)
+	putTestFileAddress(data, offset + int(flstOffsetLast), base.Last)
+}
+
+// putTestOnlyListNode 写入链表中唯一一个节点，前后节点都为空
+func putTestOnlyListNode(data []byte, addr FileAddress) {
+	offset := int(addr.PageNo) * testPageSize + int(addr.Offset)
+	putTestFileAddress(data, offset + int(flstOffsetPrev), testNullAddr)
+	putTestFileAddress(data, offset + int(flstOffsetNext), testNullAddr)
+}
+
+// putTestXdesPageFree 修改第 0 页中描述 pageNo 的区描述符中的 XDES_FREE_BIT
+func putTestXdesPageFree(data []byte, pageNo uint32, free bool) {
+	extent := int(pageNo / testVerifyExtentPages)
+	bit := int(pageNo % testVerifyExtentPages * xdesBitsPerPage + xdesFreeBit)
+	offset := int(xdesArrOffset) + extent * testVerifyEntrySize + int(xdesOffsetBitmap) + bit / 8
+	if free {
+		data[offset] |= 1 << uint(bit % 8)
+	} else {
+		data[offset] &^= 1 << uint(bit % 8)
+	}
+}
+
+// putTestInode 在 INODE 页（第 2 页）的第 slot 个 inode 中写入段 ID 和碎片页，区链表都为空
+func putTestInode(data []byte, slot int, segmentId uint64, fragPages ...uint32) []byte {
+	inode := data[2 * testPageSize + int(fsegArrOffset) + slot * testVerifyInodeSize:]
+	binary.BigEndian.PutUint64(inode[fsegOffsetId:], segmentId)
+	for _, offset := range []uint16{fsegOffsetFree, fsegOffsetNotFull, fsegOffsetFull} {
+		putTestListBase(inode, int(offset), ListBaseNode{First: testNullAddr, Last: testNullAddr})
+	}
+	binary.BigEndian.PutUint32(inode[fsegOffsetMagicN:], fsegMagicN)
+	for i := 0; i < int(getFragSlots(testVerifyExtentPages)); i++ {
+		pageNo := fileNull
+		if i < len(fragPages) {
+			pageNo = fragPages[i]
+		}
+		binary.BigEndian.PutUint32(inode[int(fsegOffsetFragArr) + i * int(fsegFragSlotSize):], pageNo)
+	}
+
+	return inode
+}
+
+// newTestVerifyFile 构造一致的表空间：第 0、1、2 页为 FSP_HDR、IBUF_BITMAP、INODE，
+// 段 1 有碎片页 3、4，以及 NOT_FULL 区中已使用的页 64、65，其余页都空闲。modify 在写入之后修改页中的数据
+func newTestVerifyFile(modify func(data []byte)) *File {
+	return newTestFile(testVerifyPageCount, func(data []byte) {
+		for pageNo, pageType := range map[uint32]uint16{
+			0: pageTypeFSP,
+			1: pageTypeIBufBitmap,
+			2: pageTypeInode,
+			3: pageTypeIndex,
+			4: pageTypeIndex,
+			64: pageTypeIndex,
+			65: pageTypeIndex,
+		} {
+			putTestPageType(data, pageNo, pageType)
+			binary.BigEndian.PutUint32(data[int(pageNo) * testPageSize + int(fileOffsetSpaceId):], testVerifySpaceId)
+		}
+
+		fsp := data[fspHeaderOffset:]
+		binary.BigEndian.PutUint32(fsp[fspOffsetSpaceId:], testVerifySpaceId)
+		binary.BigEndian.PutUint32(fsp[fspOffsetSize:], testVerifyPageCount)
+		binary.BigEndian.PutUint32(fsp[fspOffsetFreeLimit:], testVerifyPageCount)
+		binary.BigEndian.PutUint32(fsp[fspOffsetFragNUsed:], 5)
+		emptyList := ListBaseNode{First: testNullAddr, Last: testNullAddr}
+		putTestListBase(fsp, int(fspOffsetFree), emptyList)
+		putTestListBase(fsp, int(fspOffsetFreeFrag), ListBaseNode{Length: 1, First: testVerifyExtent0, Last: testVerifyExtent0})
+		putTestListBase(fsp, int(fspOffsetFullFrag), emptyList)
+		binary.BigEndian.PutUint64(fsp[fspOffsetSegId:], 2)
+		putTestListBase(fsp, int(fspOffsetSegInodesFull), emptyList)
+		putTestListBase(fsp, int(fspOffsetSegInodesFree), ListBaseNode{Length: 1, First: testAddr(2, fsegInodePageNode), Last: testAddr(2, fsegInodePageNode)})
+
+		extent0 := data[xdesArrOffset:]
+		binary.BigEndian.PutUint32(extent0[xdesOffsetState:], xdesStateFreeFrag)
+		extent1 := data[int(xdesArrOffset) + testVerifyEntrySize:]
+		binary.BigEndian.PutUint64(extent1[xdesOffsetId:], 1)
+		binary.BigEndian.PutUint32(extent1[xdesOffsetState:], xdesStateFseg)
+		putTestOnlyListNode(data, testVerifyExtent0)
+		putTestOnlyListNode(data, testVerifyExtent1)
+		for pageNo := uint32(0); pageNo < testVerifyPageCount; pageNo++ {
+			free := pageNo > 4 && pageNo != 64 && pageNo != 65
+			putTestXdesPageFree(data, pageNo, free)
+		}
+
+		putTestOnlyListNode(data, testAddr(2, fsegInodePageNode))
+		inode := putTestInode(data, 0, 1, 3, 4)
+		binary.BigEndian.PutUint32(inode[fsegOffsetNotFullNUsed:], 2)
+		putTestListBase(inode, int(fsegOffsetNotFull), ListBaseNode{Length: 1, First: testVerifyExtent1, Last: testVerifyExtent1})
+
+		if modify != nil {
+			modify(data)
+		}
+	})
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name string
+		modify func(data []byte)
+		wantKind string // 为空时表空间应该是一致的
+		wantPageNo uint32
+		wantSegments int
+	}{
+		{
+			name: "consistent",
+			wantSegments: 1,
+		},
+		{
+			name: "orphaned page",
+			modify: func(data []byte) {
+				putTestXdesPageFree(data, 3, true)
+				binary.BigEndian.PutUint32(data[fspHeaderOffset + fspOffsetFragNUsed:], 4)
+			},
+			wantKind: VerifyOrphanedPage,
+			wantPageNo: 3,
+			wantSegments: 1,
+		},
+		{
+			name: "leaked page",
+			modify: func(data []byte) {
+				putTestXdesPageFree(data, 10, false)
+				binary.BigEndian.PutUint32(data[fspHeaderOffset + fspOffsetFragNUsed:], 6)
+			},
+			wantKind: VerifyLeakedPage,
+			wantPageNo: 10,
+			wantSegments: 1,
+		},
+		{
+			name: "double owned page",
+			modify: func(data []byte) {
+				putTestInode(data, 1, 2, 4)
+			},
+			wantKind: VerifyDoubleOwnedPage,
+			wantPageNo: 4,
+			wantSegments: 2,
+		},
+		{
+			name: "wrong space id",
+			modify: func(data []byte) {
+				binary.BigEndian.PutUint32(data[64 * testPageSize + int(fileOffsetSpaceId):], testVerifySpaceId + 1)
+			},
+			wantKind: VerifyWrongSpaceId,
+			wantPageNo: 64,
+			wantSegments: 1,
+		},
+		{
+			name: "misplaced page",
+			modify: func(data []byte) {
+				binary.BigEndian.PutUint32(data[65 * testPageSize + int(fileOffsetPageNo):], 66)
+			},
+			wantKind: VerifyMisplacedPage,
+			wantPageNo: 65,
+			wantSegments: 1,
+		},
+		{
+			name: "page type",
+			modify: func(data []byte) {
+				putTestPageType(data, 1, pageTypeIndex)
+			},
+			wantKind: VerifyPageType,
+			wantPageNo: 1,
+			wantSegments: 1,
+		},
+		{
+			name: "extent list",
+			modify: func(data []byte) {
+				binary.BigEndian.PutUint32(data[2 * testPageSize + int(fsegArrOffset + fsegOffsetNotFullNUsed):], 3)
+			},
+			wantKind: VerifyExtentList,
+			wantPageNo: 2,
+			wantSegments: 1,
+		},
+		{
+			name: "free limit beyond size",
+			modify: func(data []byte) {
+				binary.BigEndian.PutUint32(data[fspHeaderOffset + fspOffsetFreeLimit:], testVerifyPageCount + testVerifyExtentPages)
+			},
+			wantKind: VerifySpaceSize,
+			wantPageNo: fileNull,
+			wantSegments: 1,
+		},
+		{
+			name: "size beyond file",
+			modify: func(data []byte) {
+				binary.BigEndian.PutUint32(data[fspHeaderOffset + fspOffsetSize:], testVerifyPageCount + testVerifyExtentPages)
+			},
+			wantKind: VerifySpaceSize,
+			wantPageNo: fileNull,
+			wantSegments: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := newTestVerifyFile(tt.modify)
+			defer file.Close()
+
+			result, err := file.Verify(1)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.SegmentCount != tt.wantSegments {
+				t.Fatalf("got %d segments, want %d", result.SegmentCount, tt.wantSegments)
+			}
+
+			if tt.wantKind == "" {
+				if !result.IsConsistent() {
+					t.Fatalf("expected no problems, got %+v", result.Problems)
+				}
+				return
+			}
+			if len(result.Problems) != 1 {
+				t.Fatalf("got problems %+v, want exactly one %s", result.Problems, tt.wantKind)
+			}
+			problem := result.Problems[0]
+			if problem.Kind != tt.wantKind || problem.PageNo != tt.wantPageNo {
+				t.Fatalf("got %s on page %d (%s), want %s on page %d", problem.Kind, problem.PageNo, problem.Detail, tt.wantKind, tt.wantPageNo)
+			}
+		})
+	}
+}
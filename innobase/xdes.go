@@ -0,0 +1,341 @@
+package innobase
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	xdesArrOffset uint16 = fspHeaderOffset + fspHeaderSize // XDES 数组在描述符页中的起始位置
+
+	xdesOffsetId uint16 = 0 // 区所属段的 ID，8 字节
+	xdesOffsetFlstNode uint16 = 8 // 区所在链表的节点，12 字节
+	xdesOffsetState uint16 = 20 // 区的状态，4 字节
+	xdesOffsetBitmap uint16 = 24 // 区中每个页的状态位图，每页 2 位
+
+	xdesBitsPerPage uint32 = 2
+	xdesFreeBit uint32 = 0 // 页是否空闲
+	xdesCleanBit uint32 = 1 // 页是否干净（未使用）
+)
+
+const (
+	xdesStateNotInited uint32 = 0 // 区还没有初始化（FREE_LIMIT 之后）
+	xdesStateFree uint32 = 1 // 在 FSP_FREE 链表中
+	xdesStateFreeFrag uint32 = 2 // 在 FSP_FREE_FRAG 链表中
+	xdesStateFullFrag uint32 = 3 // 在 FSP_FULL_FRAG 链表中
+	xdesStateFseg uint32 = 4 // 属于某个段
+	xdesStateFsegFrag uint32 = 5 // 属于某个段的碎片区（8.0）
+)
+
+var xdesStateMap = map[uint32]string {
+	xdesStateNotInited: "not_inited",
+	xdesStateFree: "free",
+	xdesStateFreeFrag: "free_frag",
+	xdesStateFullFrag: "full_frag",
+	xdesStateFseg: "fseg",
+	xdesStateFsegFrag: "fseg_frag",
+}
+
+// Extent 一个区的描述符（XDES entry）
+type Extent struct {
+	FirstPageNo uint32 // 区中第一个页的页号
+	PageCount uint32 // 区中页的数量
+	Address FileAddress // 描述符在表空间中的地址，链表节点指向这里
+	SegmentId uint64 // 所属段的 ID，不属于段时为 0
+	Node ListNode // 区所在链表的节点
+	State uint32
+	bitmap []byte
+}
+
+// getXdesEntrySize 一个描述符的大小，位图的大小与区中页的数量有关
+func getXdesEntrySize(extentPages uint32) uint32 {
+	return uint32(xdesOffsetBitmap) + (extentPages * xdesBitsPerPage + 7) / 8
+}
+
+// getXdesPageNo 描述 pageNo 的描述符页，每个描述符页描述 physicalPageSize 个页
+func getXdesPageNo(pageNo uint32, physicalPageSize uint32) uint32 {
+	return pageNo - pageNo % physicalPageSize
+}
+
+func (extent *Extent)GetStateName() string {
+	if name, exists := xdesStateMap[extent.State]; exists {
+		return name
+	}
+
+	return fmt.Sprintf("unknown(%d)", extent.State)
+}
+
+func (extent *Extent)getBit(pageIndex uint32, bit uint32) bool {
+	index := pageIndex * xdesBitsPerPage + bit
+
+	return (extent.bitmap[index / 8] >> (index % 8)) & 1 == 1
+}
+
+// IsPageFree 区中第 pageIndex 个页（从 0 开始）是否空闲
+func (extent *Extent)IsPageFree(pageIndex uint32) bool {
+	if extent.State == xdesStateNotInited || extent.State == xdesStateFree {
+		return true
+	}
+
+	return extent.getBit(pageIndex, xdesFreeBit)
+}
+
+// IsPageClean 区中第 pageIndex 个页的 XDES_CLEAN_BIT，InnoDB 目前总是设置为 1
+func (extent *Extent)IsPageClean(pageIndex uint32) bool {
+	return extent.getBit(pageIndex, xdesCleanBit)
+}
+
+// GetUsedPages 区中已使用的页数量
+func (extent *Extent)GetUsedPages() uint32 {
+	used := uint32(0)
+	for i := uint32(0); i < extent.PageCount; i++ {
+		if !extent.IsPageFree(i) {
+			used++
+		}
+	}
+
+	return used
+}
+
+// GetBitmapString 用一个字符表示一个页，'#' 为已使用，'.' 为空闲
+func (extent *Extent)GetBitmapString() string {
+	var builder strings.Builder
+	for i := uint32(0); i < extent.PageCount; i++ {
+		if extent.IsPageFree(i) {
+			builder.WriteByte('.')
+		} else {
+			builder.WriteByte('#')
+		}
+	}
+
+	return builder.String()
+}
+
+// GetExtents 解析描述符页（第 0 页或 XDES 页）中的所有区描述符，
+// 描述符页本身的位置决定了第一个区的页号
+func (page *Page)GetExtents(extentPages uint32) ([]Extent, error) {
+	errPrefix := "Page::GetExtents()"
+
+	if extentPages == 0 {
+		return nil, fmt.Errorf("%s: [%w: extent pages is zero]", errPrefix, ErrInvalidPageSize)
+	}
+
+	// 描述符的数量由物理页大小决定
+	physicalPageSize := page.GetSize()
+	entrySize := getXdesEntrySize(extentPages)
+	entryCount := physicalPageSize / extentPages
+
+	extents := make([]Extent, 0, entryCount)
+	for i := uint32(0); i < entryCount; i++ {
+		offset := uint32(xdesArrOffset) + i * entrySize
+
+		extent, err := page.getExtent(offset, extentPages)
+		if err != nil {
+			return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+		}
+
+		extents = append(extents, extent)
+	}
+
+	return extents, nil
+}
+
+// getExtent 解析页中 offset 处的区描述符，区的第一个页号由描述符在数组中的位置计算
+func (page *Page)getExtent(offset uint32, extentPages uint32) (Extent, error) {
+	entrySize := getXdesEntrySize(extentPages)
+	if offset < uint32(xdesArrOffset) || (offset - uint32(xdesArrOffset)) % entrySize != 0 {
+		return Extent{}, newPageError(page.pageNo, offset, "XDES", fmt.Errorf("offset is not an extent descriptor"))
+	}
+	index := (offset - uint32(xdesArrOffset)) / entrySize
+
+	segmentId, err := page.getUint64(offset + uint32(xdesOffsetId))
+	if err != nil {
+		return Extent{}, withField(err, "XDES_ID")
+	}
+
+	node, err := page.getListNode(offset + uint32(xdesOffsetFlstNode))
+	if err != nil {
+		return Extent{}, withField(err, "XDES_FLST_NODE")
+	}
+
+	state, err := page.getUint32(offset + uint32(xdesOffsetState))
+	if err != nil {
+		return Extent{}, withField(err, "XDES_STATE")
+	}
+
+	bitmap, err := page.getBytes(offset + uint32(xdesOffsetBitmap), entrySize - uint32(xdesOffsetBitmap))
+	if err != nil {
+		return Extent{}, withField(err, "XDES_BITMAP")
+	}
+
+	return Extent{
+		FirstPageNo: page.pageNo + index * extentPages,
+		PageCount: extentPages,
+		Address: FileAddress{PageNo: page.pageNo, Offset: uint16(offset)},
+		SegmentId: segmentId,
+		Node: node,
+		State: state,
+		bitmap: append([]byte(nil), bitmap...),
+	}, nil
+}
+
+// ReadExtentList 遍历由区描述符组成的链表（FSP_FREE、FSP_FREE_FRAG、FSP_FULL_FRAG
+// 以及段的 FSEG_FREE、FSEG_NOT_FULL、FSEG_FULL），链表节点指向描述符中的 XDES_FLST_NODE
+func (file *File)ReadExtentList(base ListBaseNode) ([]Extent, error) {
+	errPrefix := "File::ReadExtentList()"
+
+	extentPages, err := file.GetExtentPages()
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	extents := make([]Extent, 0, base.Length)
+	err = file.WalkListBase(base, ListDirectionForward, func(page *Page, addr FileAddress, node ListNode) error {
+		extent, err := page.getExtent(uint32(addr.Offset) - uint32(xdesOffsetFlstNode), extentPages)
+		if err != nil {
+			return err
+		}
+		extents = append(extents, extent)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	return extents, nil
+}
+
+// AllocationMap 整个表空间的区描述符，用于查询每个页是否已分配
+type AllocationMap struct {
+	FSPHeader FSPHeader
+	ExtentPages uint32
+	Extents []Extent // 按页号顺序排列，覆盖 FSP_SIZE 之内的所有页
+}
+
+// ReadAllocationMap 读取第 0 页以及每隔物理页大小个页的 XDES 页，解析所有区描述符，
+// FREE_LIMIT 之后的描述符还没有初始化，按空闲处理
+func (file *File)ReadAllocationMap() (*AllocationMap, error) {
+	errPrefix := "File::ReadAllocationMap()"
+
+	fspHeader, err := file.ReadFSPHeader()
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	logicalPageSize, err := file.GetLogicalPageSize()
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	physicalPageSize, err := file.GetPhysicalPageSize()
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	pageCount, err := file.getPageCount()
+	if err != nil {
+		return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	allocation := &AllocationMap{
+		FSPHeader: fspHeader,
+		ExtentPages: getExtentPages(logicalPageSize),
+	}
+
+	// 描述符页不能超出文件实际大小
+	limit := fspHeader.Size
+	if limit > pageCount {
+		limit = pageCount
+	}
+
+	for xdesPageNo := uint32(0); xdesPageNo < limit; xdesPageNo += physicalPageSize {
+		page, err := file.ReadPage(xdesPageNo)
+		if err != nil {
+			return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+		}
+
+		extents, err := page.GetExtents(allocation.ExtentPages)
+		if err != nil {
+			return nil, fmt.Errorf("%s: [%w]", errPrefix, err)
+		}
+
+		for _, extent := range extents {
+			if extent.FirstPageNo >= limit {
+				break
+			}
+			if extent.FirstPageNo >= fspHeader.FreeLimit {
+				extent.State = xdesStateNotInited
+			}
+			allocation.Extents = append(allocation.Extents, extent)
+		}
+
+		if xdesPageNo + physicalPageSize < xdesPageNo {
+			break
+		}
+	}
+
+	return allocation, nil
+}
+
+// GetExtent 读取包含 pageNo 的区
+func (allocation *AllocationMap)GetExtent(pageNo uint32) (*Extent, bool) {
+	index := pageNo / allocation.ExtentPages
+	if index >= uint32(len(allocation.Extents)) {
+		return nil, false
+	}
+
+	return &allocation.Extents[index], true
+}
+
+// IsPageAllocated pageNo 在 InnoDB 看来是否已分配，超出 FSP_SIZE 的页都是未分配的
+func (allocation *AllocationMap)IsPageAllocated(pageNo uint32) bool {
+	extent, exists := allocation.GetExtent(pageNo)
+	if !exists {
+		return false
+	}
+
+	return !extent.IsPageFree(pageNo - extent.FirstPageNo)
+}
+
+// IsPageAllocated 读取 pageNo 所在的描述符页，判断页是否已分配
+func (file *File)IsPageAllocated(pageNo uint32) (bool, error) {
+	errPrefix := "File::IsPageAllocated()"
+
+	fspHeader, err := file.ReadFSPHeader()
+	if err != nil {
+		return false, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+	if pageNo >= fspHeader.FreeLimit {
+		return false, nil
+	}
+
+	logicalPageSize, err := file.GetLogicalPageSize()
+	if err != nil {
+		return false, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	physicalPageSize, err := file.GetPhysicalPageSize()
+	if err != nil {
+		return false, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	page, err := file.ReadPage(getXdesPageNo(pageNo, physicalPageSize))
+	if err != nil {
+		return false, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	extentPages := getExtentPages(logicalPageSize)
+	extents, err := page.GetExtents(extentPages)
+	if err != nil {
+		return false, fmt.Errorf("%s: [%w]", errPrefix, err)
+	}
+
+	index := (pageNo - page.GetPosition()) / extentPages
+	if index >= uint32(len(extents)) {
+		return false, fmt.Errorf("%s: [%w]", errPrefix, newPageError(pageNo, 0, "", ErrInvalidPageNo))
+	}
+	extent := extents[index]
+
+	return !extent.IsPageFree(pageNo - extent.FirstPageNo), nil
+}